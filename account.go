@@ -0,0 +1,637 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+	"github.com/twomotive/gohost/internal/jobs"
+	"github.com/twomotive/gohost/internal/storage"
+)
+
+const (
+	defaultAccountDeletionGrace = 30 * 24 * time.Hour
+	dataExportTTL               = 7 * 24 * time.Hour
+
+	jobDeleteAccount  = "delete_account"
+	jobExportUserData = "export_user_data"
+
+	exportPending = "pending"
+	exportReady   = "ready"
+	exportFailed  = "failed"
+)
+
+type deleteAccountRequest struct {
+	CurrentPassword string `json:"current_password"`
+}
+
+type deleteAccountResponse struct {
+	DeletionScheduledFor time.Time `json:"deletion_scheduled_for"`
+}
+
+type accountJobPayload struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type dataExportJobPayload struct {
+	ExportID uuid.UUID `json:"export_id"`
+}
+
+type dataExportResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Status      string     `json:"status"`
+	SizeBytes   *int64     `json:"size_bytes,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+// dataExportBundle is everything we hold about a user, as handed to them in
+// a data export.
+type dataExportBundle struct {
+	GeneratedAt          time.Time                    `json:"generated_at"`
+	Account              createdUser                  `json:"account"`
+	Profile              publicProfile                `json:"profile"`
+	Gobits               []dataExportGobit            `json:"gobits"`
+	Media                []mediaResponse              `json:"media"`
+	Sessions             []dataExportSession          `json:"sessions"`
+	SubscriptionHistory  []dataExportSubscription     `json:"subscription_history"`
+	AccountStatusHistory []accountStatusEventResponse `json:"account_status_history"`
+	Blocks               []userRelationResponse       `json:"blocks"`
+	Mutes                []userRelationResponse       `json:"mutes"`
+	Following            []userRelationResponse       `json:"following"`
+	Followers            []userRelationResponse       `json:"followers"`
+	Bookmarks            []dataExportBookmark         `json:"bookmarks"`
+	Messages             []dataExportMessage          `json:"messages"`
+}
+
+type dataExportGobit struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Body       string     `json:"body"`
+	Status     string     `json:"status"`
+	Visibility string     `json:"visibility"`
+	ReplyToID  *uuid.UUID `json:"reply_to_id,omitempty"`
+	RepostOfID *uuid.UUID `json:"repost_of_id,omitempty"`
+	QuoteOfID  *uuid.UUID `json:"quote_of_id,omitempty"`
+	HiddenAt   *time.Time `json:"hidden_at,omitempty"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+type dataExportBookmark struct {
+	GobitID   uuid.UUID `json:"gobit_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// dataExportMessage is a direct message the user sent.
+type dataExportMessage struct {
+	ID             uuid.UUID `json:"id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Body           string    `json:"body"`
+}
+
+// dataExportSession describes a refresh token without revealing it.
+type dataExportSession struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type dataExportSubscription struct {
+	CreatedAt time.Time `json:"created_at"`
+	Event     string    `json:"event"`
+}
+
+func toDataExportResponse(export database.DataExport) dataExportResponse {
+	response := dataExportResponse{
+		ID:        export.ID,
+		CreatedAt: export.CreatedAt,
+		Status:    export.Status,
+	}
+	if export.SizeBytes.Valid {
+		response.SizeBytes = &export.SizeBytes.Int64
+	}
+	if export.ExpiresAt.Valid {
+		response.ExpiresAt = &export.ExpiresAt.Time
+	}
+	if export.Status == exportReady {
+		response.DownloadURL = "/api/users/export/" + export.ID.String() + "/download"
+	}
+	return response
+}
+
+func (cfg *apiConfig) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for account deletion: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for account deletion: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req deleteAccountRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("JSON account deletion decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Unauthorized: Unknown user", http.StatusUnauthorized)
+		} else {
+			log.Printf("Error getting user %s for deletion: %v", userID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := auth.CheckPasswordHash(user.HashedPassword, req.CurrentPassword); err != nil {
+		log.Printf("Current password mismatch for account deletion of user %s", userID)
+		http.Error(w, "Current password is incorrect", http.StatusUnauthorized)
+		return
+	}
+
+	// The schedule, its job and the sign-out happen together, so a schedule
+	// never exists without the job that carries it out
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		user, err = q.GetUserByIDForUpdate(r.Context(), userID)
+		if err != nil {
+			return err
+		}
+
+		// Asking again keeps the original schedule
+		if !user.DeletionScheduledFor.Valid {
+			deleteAt := time.Now().Add(cfg.accountDeletionGrace)
+			user, err = q.ScheduleUserDeletion(r.Context(), database.ScheduleUserDeletionParams{
+				ID:                   userID,
+				DeletionScheduledFor: sql.NullTime{Time: deleteAt, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = cfg.jobs.EnqueueIn(r.Context(), q, jobDeleteAccount, accountJobPayload{UserID: userID}, deleteAt)
+			if err != nil {
+				return err
+			}
+
+			log.Printf("Account %s scheduled for deletion at %s", userID, deleteAt.Format(time.RFC3339))
+		}
+
+		// Sign out everywhere; logging back in cancels the deletion
+		return q.RevokeUserRefreshTokens(r.Context(), userID)
+	})
+	if err != nil {
+		log.Printf("cannot schedule deletion for user %s: %v", userID, err)
+		http.Error(w, "Failed to delete account", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(deleteAccountResponse{
+		DeletionScheduledFor: user.DeletionScheduledFor.Time,
+	})
+	if err != nil {
+		log.Printf("Error marshalling account deletion response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(data)
+}
+
+// runDeleteAccount permanently removes an account whose grace period is
+// over. It does nothing if the user logged back in and cancelled, or asked
+// again later and so has a newer schedule.
+func (cfg *apiConfig) runDeleteAccount(ctx context.Context, payload json.RawMessage) error {
+	var p accountJobPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+
+	user, err := cfg.db.GetUserByID(ctx, p.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	if !user.DeletionScheduledFor.Valid || user.DeletionScheduledFor.Time.After(time.Now()) {
+		return nil
+	}
+
+	// Collect stored files first; their rows go with the user
+	var keys []string
+	files, err := cfg.db.ListMediaFilesByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		keys = append(keys, file.StorageKey, file.ThumbnailKey)
+	}
+	exports, err := cfg.db.ListDataExportsByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if export.StorageKey.Valid {
+			keys = append(keys, export.StorageKey.String)
+		}
+	}
+
+	deleted, err := cfg.db.DeleteUserIfDue(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return nil
+	}
+
+	for _, key := range keys {
+		if err := cfg.storage.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Error deleting %s for removed account %s: %v", key, user.ID, err)
+		}
+	}
+
+	log.Printf("Account %s permanently deleted", user.ID)
+	return nil
+}
+
+func (cfg *apiConfig) requestDataExport(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for data export: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for data export: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	// The export and the job that builds it are created together, so an
+	// export is never left pending with nothing to finish it
+	var export database.DataExport
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		export, err = q.CreateDataExport(r.Context(), userID)
+		if err != nil {
+			return err
+		}
+		_, err = cfg.jobs.EnqueueIn(r.Context(), q, jobExportUserData, dataExportJobPayload{ExportID: export.ID}, time.Now())
+		return err
+	})
+	if err != nil {
+		log.Printf("cannot start data export for user %s: %v", userID, err)
+		http.Error(w, "Failed to start export", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(toDataExportResponse(export))
+	if err != nil {
+		log.Printf("Error marshalling data export response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(data)
+}
+
+// ownDataExport authenticates the caller and loads the export named in the
+// path, which must be theirs. On failure it writes the error response and
+// returns false.
+func (cfg *apiConfig) ownDataExport(w http.ResponseWriter, r *http.Request) (database.DataExport, bool) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for data export: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return database.DataExport{}, false
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for data export: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return database.DataExport{}, false
+	}
+	// --- Authentication End ---
+
+	exportID, err := uuid.Parse(r.PathValue("exportID"))
+	if err != nil {
+		http.Error(w, "Invalid export ID format", http.StatusBadRequest)
+		return database.DataExport{}, false
+	}
+
+	export, err := cfg.db.GetDataExport(r.Context(), exportID)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error getting data export %s: %v", exportID, err)
+		http.Error(w, "Failed to get export", http.StatusInternalServerError)
+		return database.DataExport{}, false
+	}
+	if err == sql.ErrNoRows || export.UserID != userID {
+		http.Error(w, "export not found", http.StatusNotFound)
+		return database.DataExport{}, false
+	}
+
+	return export, true
+}
+
+func (cfg *apiConfig) getDataExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := cfg.ownDataExport(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := json.Marshal(toDataExportResponse(export))
+	if err != nil {
+		log.Printf("Error marshalling data export response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) downloadDataExport(w http.ResponseWriter, r *http.Request) {
+	export, ok := cfg.ownDataExport(w, r)
+	if !ok {
+		return
+	}
+
+	if export.Status != exportReady {
+		http.Error(w, "Export is not ready", http.StatusConflict)
+		return
+	}
+	if export.ExpiresAt.Valid && time.Now().After(export.ExpiresAt.Time) {
+		http.Error(w, "Export has expired", http.StatusGone)
+		return
+	}
+
+	body, err := cfg.storage.Open(r.Context(), export.StorageKey.String)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Export has expired", http.StatusGone)
+		} else {
+			log.Printf("Error opening data export %s: %v", export.ID, err)
+			http.Error(w, "Failed to get export", http.StatusInternalServerError)
+		}
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"gohost-export-%s.json\"", export.ID))
+	w.Header().Set("Content-Length", strconv.FormatInt(export.SizeBytes.Int64, 10))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
+
+// runExportUserData builds a user's data export bundle and stores it for
+// download. A failure is retried by the queue, and the export is only marked
+// failed once the job has no attempts left.
+func (cfg *apiConfig) runExportUserData(ctx context.Context, payload json.RawMessage) error {
+	var p dataExportJobPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+
+	export, err := cfg.db.GetDataExport(ctx, p.ExportID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	if export.Status != exportPending {
+		return nil
+	}
+
+	err = cfg.storeDataExport(ctx, export)
+	if err != nil && jobs.LastAttempt(ctx) {
+		if ferr := cfg.db.FailDataExport(ctx, export.ID); ferr != nil {
+			log.Printf("Error marking data export %s failed: %v", export.ID, ferr)
+		}
+	}
+	return err
+}
+
+// storeDataExport builds the export's bundle, stores it and marks the export
+// ready.
+func (cfg *apiConfig) storeDataExport(ctx context.Context, export database.DataExport) error {
+	bundle, err := cfg.buildDataExport(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	key := "exports/" + export.ID.String() + ".json"
+	if err := cfg.storage.Put(ctx, key, bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	_, err = cfg.db.CompleteDataExport(ctx, database.CompleteDataExportParams{
+		ID:         export.ID,
+		StorageKey: sql.NullString{String: key, Valid: true},
+		SizeBytes:  sql.NullInt64{Int64: int64(len(data)), Valid: true},
+		ExpiresAt:  sql.NullTime{Time: time.Now().Add(dataExportTTL), Valid: true},
+	})
+	return err
+}
+
+func (cfg *apiConfig) buildDataExport(ctx context.Context, userID uuid.UUID) (dataExportBundle, error) {
+	user, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return dataExportBundle{}, err
+	}
+
+	bundle := dataExportBundle{
+		GeneratedAt:          time.Now().UTC(),
+		Account:              toCreatedUser(user),
+		Profile:              toPublicProfile(user),
+		Gobits:               []dataExportGobit{},
+		Media:                []mediaResponse{},
+		Sessions:             []dataExportSession{},
+		SubscriptionHistory:  []dataExportSubscription{},
+		AccountStatusHistory: []accountStatusEventResponse{},
+		Blocks:               []userRelationResponse{},
+		Mutes:                []userRelationResponse{},
+		Following:            []userRelationResponse{},
+		Followers:            []userRelationResponse{},
+		Bookmarks:            []dataExportBookmark{},
+		Messages:             []dataExportMessage{},
+	}
+
+	gobits, err := cfg.db.ListGobitsByUser(ctx, userID)
+	if err != nil {
+		return dataExportBundle{}, err
+	}
+	for _, gobit := range gobits {
+		exported := dataExportGobit{
+			ID:         gobit.ID,
+			CreatedAt:  gobit.CreatedAt,
+			UpdatedAt:  gobit.UpdatedAt,
+			Body:       gobit.Body,
+			Status:     gobit.Status,
+			Visibility: gobit.Visibility,
+		}
+		if gobit.ReplyToID.Valid {
+			exported.ReplyToID = &gobit.ReplyToID.UUID
+		}
+		if gobit.RepostOfID.Valid {
+			exported.RepostOfID = &gobit.RepostOfID.UUID
+		}
+		if gobit.QuoteOfID.Valid {
+			exported.QuoteOfID = &gobit.QuoteOfID.UUID
+		}
+		if gobit.HiddenAt.Valid {
+			exported.HiddenAt = &gobit.HiddenAt.Time
+		}
+		if gobit.DeletedAt.Valid {
+			exported.DeletedAt = &gobit.DeletedAt.Time
+		}
+		bundle.Gobits = append(bundle.Gobits, exported)
+	}
+
+	files, err := cfg.db.ListMediaFilesByUser(ctx, userID)
+	if err != nil {
+		return dataExportBundle{}, err
+	}
+	for _, file := range files {
+		bundle.Media = append(bundle.Media, toMediaResponse(file))
+	}
+
+	tokens, err := cfg.db.ListRefreshTokensByUser(ctx, userID)
+	if err != nil {
+		return dataExportBundle{}, err
+	}
+	for _, token := range tokens {
+		session := dataExportSession{
+			CreatedAt: token.CreatedAt,
+			ExpiresAt: token.ExpiresAt,
+		}
+		if token.RevokedAt.Valid {
+			session.RevokedAt = &token.RevokedAt.Time
+		}
+		bundle.Sessions = append(bundle.Sessions, session)
+	}
+
+	events, err := cfg.db.ListSubscriptionEventsByUser(ctx, userID)
+	if err != nil {
+		return dataExportBundle{}, err
+	}
+	for _, event := range events {
+		bundle.SubscriptionHistory = append(bundle.SubscriptionHistory, dataExportSubscription{
+			CreatedAt: event.CreatedAt,
+			Event:     event.Event,
+		})
+	}
+
+	statusEvents, err := cfg.db.ListAccountStatusEvents(ctx, userID)
+	if err != nil {
+		return dataExportBundle{}, err
+	}
+	for _, event := range statusEvents {
+		bundle.AccountStatusHistory = append(bundle.AccountStatusHistory, toAccountStatusEventResponse(event))
+	}
+
+	blocks, err := cfg.db.ListUserBlocks(ctx, userID)
+	if err != nil {
+		return dataExportBundle{}, err
+	}
+	for _, block := range blocks {
+		bundle.Blocks = append(bundle.Blocks, userRelationResponse{UserID: block.BlockedID, CreatedAt: block.CreatedAt})
+	}
+
+	mutes, err := cfg.db.ListUserMutes(ctx, userID)
+	if err != nil {
+		return dataExportBundle{}, err
+	}
+	for _, mute := range mutes {
+		bundle.Mutes = append(bundle.Mutes, userRelationResponse{UserID: mute.MutedID, CreatedAt: mute.CreatedAt})
+	}
+
+	following, err := cfg.db.ListFollowing(ctx, userID)
+	if err != nil {
+		return dataExportBundle{}, err
+	}
+	for _, follow := range following {
+		bundle.Following = append(bundle.Following, userRelationResponse{UserID: follow.FolloweeID, CreatedAt: follow.CreatedAt})
+	}
+
+	followers, err := cfg.db.ListFollowers(ctx, userID)
+	if err != nil {
+		return dataExportBundle{}, err
+	}
+	for _, follow := range followers {
+		bundle.Followers = append(bundle.Followers, userRelationResponse{UserID: follow.FollowerID, CreatedAt: follow.CreatedAt})
+	}
+
+	bookmarks, err := cfg.db.ListBookmarksByUser(ctx, userID)
+	if err != nil {
+		return dataExportBundle{}, err
+	}
+	for _, bookmark := range bookmarks {
+		bundle.Bookmarks = append(bundle.Bookmarks, dataExportBookmark{GobitID: bookmark.GobitID, CreatedAt: bookmark.CreatedAt})
+	}
+
+	messages, err := cfg.db.ListMessagesBySender(ctx, userID)
+	if err != nil {
+		return dataExportBundle{}, err
+	}
+	for _, message := range messages {
+		bundle.Messages = append(bundle.Messages, dataExportMessage{
+			ID:             message.ID,
+			ConversationID: message.ConversationID,
+			CreatedAt:      message.CreatedAt,
+			Body:           message.Body,
+		})
+	}
+
+	return bundle, nil
+}
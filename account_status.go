@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	accountActive       = "active"
+	accountSuspended    = "suspended"
+	accountBanned       = "banned"
+	accountShadowbanned = "shadowbanned"
+)
+
+type accountStatusRequest struct {
+	Status         string     `json:"status"`
+	SuspendedUntil *time.Time `json:"suspended_until"`
+	Reason         string     `json:"reason"`
+}
+
+type accountStatusEventResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UserID         uuid.UUID  `json:"user_id"`
+	ActorID        *uuid.UUID `json:"actor_id,omitempty"`
+	OldStatus      string     `json:"old_status"`
+	NewStatus      string     `json:"new_status"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+	Reason         string     `json:"reason"`
+}
+
+func toAccountStatusEventResponse(event database.AccountStatusEvent) accountStatusEventResponse {
+	response := accountStatusEventResponse{
+		ID:        event.ID,
+		CreatedAt: event.CreatedAt,
+		UserID:    event.UserID,
+		OldStatus: event.OldStatus,
+		NewStatus: event.NewStatus,
+		Reason:    event.Reason,
+	}
+	if event.ActorID.Valid {
+		response.ActorID = &event.ActorID.UUID
+	}
+	if event.SuspendedUntil.Valid {
+		response.SuspendedUntil = &event.SuspendedUntil.Time
+	}
+	return response
+}
+
+// accountLockout reports whether the user is currently barred from logging
+// in, refreshing tokens or posting, and the message to give them. A
+// suspension whose end time has passed no longer applies. Shadowbanned
+// accounts are deliberately not locked out.
+func accountLockout(user database.User) (string, bool) {
+	switch user.AccountStatus {
+	case accountBanned:
+		return "Forbidden: account banned", true
+	case accountSuspended:
+		if !user.SuspendedUntil.Valid {
+			return "Forbidden: account suspended", true
+		}
+		if time.Now().Before(user.SuspendedUntil.Time) {
+			return "Forbidden: account suspended until " + user.SuspendedUntil.Time.Format(time.RFC3339), true
+		}
+	}
+	return "", false
+}
+
+// setAccountStatus changes a user's account state and records the change in
+// the audit log, in one transaction. actorID is empty for changes made by the
+// system.
+func (cfg *apiConfig) setAccountStatus(ctx context.Context, userID uuid.UUID, actorID uuid.NullUUID, status string, suspendedUntil sql.NullTime, reason string) (database.User, error) {
+	var updated database.User
+	err := cfg.withTx(ctx, func(q *database.Queries) error {
+		var err error
+		updated, err = applyAccountStatus(ctx, q, userID, actorID, status, suspendedUntil, reason)
+		return err
+	})
+	return updated, err
+}
+
+// applyAccountStatus is setAccountStatus for callers already in a
+// transaction.
+func applyAccountStatus(ctx context.Context, q *database.Queries, userID uuid.UUID, actorID uuid.NullUUID, status string, suspendedUntil sql.NullTime, reason string) (database.User, error) {
+	user, err := q.GetUserByIDForUpdate(ctx, userID)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	if status != accountSuspended {
+		suspendedUntil = sql.NullTime{}
+	}
+
+	updated, err := q.SetUserAccountStatus(ctx, database.SetUserAccountStatusParams{
+		ID:             userID,
+		AccountStatus:  status,
+		SuspendedUntil: suspendedUntil,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+
+	_, err = q.CreateAccountStatusEvent(ctx, database.CreateAccountStatusEventParams{
+		UserID:         userID,
+		ActorID:        actorID,
+		OldStatus:      user.AccountStatus,
+		NewStatus:      status,
+		SuspendedUntil: suspendedUntil,
+		Reason:         reason,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+
+	log.Printf("Account %s changed from %s to %s: %s", userID, user.AccountStatus, status, reason)
+	return updated, nil
+}
+
+func (cfg *apiConfig) updateAccountStatus(w http.ResponseWriter, r *http.Request) {
+	admin, ok := cfg.authorizeRole(w, r, roleAdmin)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req accountStatusRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("JSON account status decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return
+	}
+
+	switch req.Status {
+	case accountActive, accountBanned, accountShadowbanned:
+	case accountSuspended:
+		if req.SuspendedUntil == nil || !req.SuspendedUntil.After(time.Now()) {
+			http.Error(w, "suspended_until must be a time in the future", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "status must be one of active, suspended, banned or shadowbanned", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		http.Error(w, "Reason is required", http.StatusBadRequest)
+		return
+	}
+
+	var suspendedUntil sql.NullTime
+	if req.SuspendedUntil != nil {
+		suspendedUntil = sql.NullTime{Time: *req.SuspendedUntil, Valid: true}
+	}
+
+	user, err := cfg.setAccountStatus(r.Context(), userID, uuid.NullUUID{UUID: admin.ID, Valid: true}, req.Status, suspendedUntil, req.Reason)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "user not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error updating account status for user %s: %v", userID, err)
+			http.Error(w, "Failed to update account status", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	type accountStatusResponse struct {
+		ID             uuid.UUID  `json:"id"`
+		Status         string     `json:"status"`
+		SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+	}
+
+	response := accountStatusResponse{
+		ID:     user.ID,
+		Status: user.AccountStatus,
+	}
+	if user.SuspendedUntil.Valid {
+		response.SuspendedUntil = &user.SuspendedUntil.Time
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("Error marshalling account status response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) listAccountStatusEvents(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.authorizeRole(w, r, roleModerator, roleAdmin); !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+		return
+	}
+
+	events, err := cfg.db.ListAccountStatusEvents(r.Context(), userID)
+	if err != nil {
+		log.Printf("cannot list account status events for user %s: %v", userID, err)
+		http.Error(w, "Failed to list account status history", http.StatusInternalServerError)
+		return
+	}
+
+	responseEvents := make([]accountStatusEventResponse, len(events))
+	for i, event := range events {
+		responseEvents[i] = toAccountStatusEventResponse(event)
+	}
+
+	data, err := json.Marshal(responseEvents)
+	if err != nil {
+		log.Printf("Error marshalling account status history: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
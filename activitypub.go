@@ -0,0 +1,837 @@
+package main
+
+import (
+	"context"
+	"crypto/rsa"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/activitypub"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	jobDeliverActivity = "deliver_activity"
+
+	// Cached remote actors are fetched again after this long, picking up
+	// new keys and inboxes
+	remoteActorRefreshAfter = 24 * time.Hour
+	// A remote actor fetched this recently is not fetched again just
+	// because a signature failed to verify
+	remoteActorMinRefresh = time.Minute
+
+	outboxPageSize  = 20
+	deliveryTimeout = 30 * time.Second
+)
+
+// deliveryJobPayload is one activity on its way to one remote inbox.
+type deliveryJobPayload struct {
+	UserID   uuid.UUID       `json:"user_id"`
+	Inbox    string          `json:"inbox"`
+	Activity json.RawMessage `json:"activity"`
+}
+
+// federationEnabled reports whether PUBLIC_URL is set. Remote servers keep
+// the URLs of our actors and notes, so they can't be derived per request.
+func (cfg *apiConfig) federationEnabled() bool {
+	return cfg.publicURL != ""
+}
+
+func (cfg *apiConfig) actorURL(userID uuid.UUID) string {
+	return cfg.publicURL + "/ap/users/" + userID.String()
+}
+
+func (cfg *apiConfig) noteURL(gobitID uuid.UUID) string {
+	return cfg.publicURL + "/ap/gobits/" + gobitID.String()
+}
+
+// localID extracts the id from one of our actor or note URLs under prefix,
+// such as "/ap/users/".
+func (cfg *apiConfig) localID(rawURL, prefix string) (uuid.UUID, bool) {
+	rest, ok := strings.CutPrefix(rawURL, cfg.publicURL+prefix)
+	if !ok {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(rest)
+	return id, err == nil
+}
+
+// federates reports whether a user is visible to other servers. Accounts
+// need a handle to be found through WebFinger, and banned and shadowbanned
+// accounts are hidden.
+func federates(user database.User) bool {
+	return user.Handle.Valid &&
+		user.AccountStatus != accountBanned && user.AccountStatus != accountShadowbanned
+}
+
+// federatedUser loads a user that other servers may see. ok is false if
+// there is no such user.
+func (cfg *apiConfig) federatedUser(ctx context.Context, userID uuid.UUID) (database.User, bool, error) {
+	user, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return database.User{}, false, nil
+		}
+		return database.User{}, false, err
+	}
+	return user, federates(user), nil
+}
+
+// federatedGobit loads a gobit that other servers may see: public, live,
+// not a repost, and by a user who federates.
+func (cfg *apiConfig) federatedGobit(ctx context.Context, gobitID uuid.UUID) (database.Gobit, bool, error) {
+	gobit, err := cfg.db.GetGobit(ctx, gobitID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return database.Gobit{}, false, nil
+		}
+		return database.Gobit{}, false, err
+	}
+	if !gobitFederates(gobit) {
+		return database.Gobit{}, false, nil
+	}
+	_, ok, err := cfg.federatedUser(ctx, gobit.UserID)
+	return gobit, ok, err
+}
+
+func gobitFederates(gobit database.Gobit) bool {
+	return gobit.Visibility == visibilityPublic && gobit.Status == gobitPublished &&
+		!gobit.HiddenAt.Valid && !gobit.DeletedAt.Valid && !gobit.RepostOfID.Valid
+}
+
+// actorKey returns the user's key pair, generating it on first use.
+func (cfg *apiConfig) actorKey(ctx context.Context, userID uuid.UUID) (database.ActorKey, error) {
+	key, err := cfg.db.GetActorKey(ctx, userID)
+	if err != sql.ErrNoRows {
+		return key, err
+	}
+
+	publicPEM, privatePEM, err := activitypub.GenerateKey()
+	if err != nil {
+		return database.ActorKey{}, err
+	}
+	return cfg.db.CreateActorKey(ctx, database.CreateActorKeyParams{
+		UserID:        userID,
+		PublicKeyPem:  publicPEM,
+		PrivateKeyPem: privatePEM,
+	})
+}
+
+// respondWithActivity writes an ActivityPub document.
+func respondWithActivity(w http.ResponseWriter, status int, doc any) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		log.Printf("Error marshalling ActivityPub document: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", activitypub.ContentType)
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// toNote renders a gobit as a Note.
+func (cfg *apiConfig) toNote(gobit database.Gobit) activitypub.Note {
+	actor := cfg.actorURL(gobit.UserID)
+	published := gobit.CreatedAt
+	if gobit.PublishedAt.Valid {
+		published = gobit.PublishedAt.Time
+	}
+
+	note := activitypub.Note{
+		ID:           cfg.noteURL(gobit.ID),
+		Type:         "Note",
+		AttributedTo: actor,
+		Content:      activitypub.HTMLContent(gobit.Body),
+		URL:          cfg.publicURL + "/api/gobits/" + gobit.ID.String(),
+		Published:    published.UTC().Format(time.RFC3339),
+		To:           activitypub.Audience{activitypub.Public},
+		CC:           activitypub.Audience{actor + "/followers"},
+	}
+	if gobit.ReplyToID.Valid {
+		note.InReplyTo = cfg.noteURL(gobit.ReplyToID.UUID)
+	}
+	return note
+}
+
+// createActivity wraps a gobit's Note in the Create that announces it.
+func (cfg *apiConfig) createActivity(gobit database.Gobit) (activitypub.Activity, error) {
+	note := cfg.toNote(gobit)
+	object, err := json.Marshal(note)
+	if err != nil {
+		return activitypub.Activity{}, err
+	}
+	return activitypub.Activity{
+		ID:        note.ID + "/activity",
+		Type:      "Create",
+		Actor:     note.AttributedTo,
+		Object:    object,
+		Published: note.Published,
+		To:        note.To,
+		CC:        note.CC,
+	}, nil
+}
+
+// webfinger resolves acct:handle@host, or one of our actor URLs, to the
+// user's actor.
+func (cfg *apiConfig) webfinger(w http.ResponseWriter, r *http.Request) {
+	if !cfg.federationEnabled() {
+		http.NotFound(w, r)
+		return
+	}
+
+	resource := r.URL.Query().Get("resource")
+	if resource == "" {
+		http.Error(w, "resource is required", http.StatusBadRequest)
+		return
+	}
+
+	var user database.User
+	var err error
+	if userID, ok := cfg.localID(resource, "/ap/users/"); ok {
+		user, err = cfg.db.GetUserByID(r.Context(), userID)
+	} else {
+		account, ok := strings.CutPrefix(resource, "acct:")
+		handle, host, found := strings.Cut(account, "@")
+		public, _ := url.Parse(cfg.publicURL)
+		if !ok || !found || !strings.EqualFold(host, public.Host) {
+			http.Error(w, "resource not found", http.StatusNotFound)
+			return
+		}
+		user, err = cfg.db.GetUserByHandle(r.Context(), normalizeHandle(handle))
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "resource not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error looking up WebFinger resource %s: %v", resource, err)
+			http.Error(w, "Failed to look up resource", http.StatusInternalServerError)
+		}
+		return
+	}
+	if !federates(user) {
+		http.Error(w, "resource not found", http.StatusNotFound)
+		return
+	}
+
+	public, _ := url.Parse(cfg.publicURL)
+	actor := cfg.actorURL(user.ID)
+	data, err := json.Marshal(activitypub.WebFinger{
+		Subject: "acct:" + user.Handle.String + "@" + public.Host,
+		Aliases: []string{actor},
+		Links: []activitypub.WebFingerLink{
+			{Rel: "self", Type: activitypub.ContentType, Href: actor},
+			{Rel: "http://webfinger.net/rel/profile-page", Type: "application/json", Href: cfg.publicURL + "/api/users/" + user.Handle.String},
+		},
+	})
+	if err != nil {
+		log.Printf("Error marshalling WebFinger response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/jrd+json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// federatedUserFromPath loads the user named by {userID}, writing a 404 if
+// they don't exist or don't federate.
+func (cfg *apiConfig) federatedUserFromPath(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	if !cfg.federationEnabled() {
+		http.NotFound(w, r)
+		return database.User{}, false
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+		return database.User{}, false
+	}
+
+	user, ok, err := cfg.federatedUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting user %s for ActivityPub: %v", userID, err)
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return database.User{}, false
+	}
+	if !ok {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// getActor serves a user's actor document.
+func (cfg *apiConfig) getActor(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.federatedUserFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	key, err := cfg.actorKey(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error getting key for user %s: %v", user.ID, err)
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return
+	}
+
+	id := cfg.actorURL(user.ID)
+	actor := activitypub.Actor{
+		Context:           activitypub.Context,
+		ID:                id,
+		Type:              "Person",
+		PreferredUsername: user.Handle.String,
+		Name:              user.DisplayName,
+		Summary:           activitypub.HTMLContent(user.Bio),
+		URL:               cfg.publicURL + "/api/users/" + user.Handle.String,
+		Inbox:             id + "/inbox",
+		Outbox:            id + "/outbox",
+		Followers:         id + "/followers",
+		Endpoints:         &activitypub.Endpoints{SharedInbox: cfg.publicURL + "/ap/inbox"},
+		PublicKey: activitypub.PublicKey{
+			ID:           id + "#main-key",
+			Owner:        id,
+			PublicKeyPem: key.PublicKeyPem,
+		},
+	}
+	if avatar := avatarURL(user); avatar != "" {
+		actor.Icon = &activitypub.Image{Type: "Image", URL: cfg.publicURL + avatar}
+	}
+
+	respondWithActivity(w, http.StatusOK, actor)
+}
+
+// getOutbox serves a user's public gobits as Create activities, newest
+// first. Without ?page= it returns just the collection's size and first page.
+func (cfg *apiConfig) getOutbox(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.federatedUserFromPath(w, r)
+	if !ok {
+		return
+	}
+	outbox := cfg.actorURL(user.ID) + "/outbox"
+
+	page := r.URL.Query().Get("page")
+	if page == "" {
+		total, err := cfg.db.CountPublicGobits(r.Context(), user.ID)
+		if err != nil {
+			log.Printf("Error counting gobits in %s's outbox: %v", user.ID, err)
+			http.Error(w, "Failed to get outbox", http.StatusInternalServerError)
+			return
+		}
+		respondWithActivity(w, http.StatusOK, activitypub.OrderedCollection{
+			Context:    activitypub.Context,
+			ID:         outbox,
+			Type:       "OrderedCollection",
+			TotalItems: total,
+			First:      outbox + "?page=true",
+		})
+		return
+	}
+
+	params := database.ListPublicGobitsParams{
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		Limit:  outboxPageSize + 1,
+	}
+	if page != "true" {
+		publishedAt, gobitID, err := decodeCursor(page)
+		if err != nil {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		params.BeforePublishedAt = sql.NullTime{Time: publishedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: gobitID, Valid: true}
+	}
+
+	gobits, err := cfg.db.ListPublicGobits(r.Context(), params)
+	if err != nil {
+		log.Printf("Error listing gobits in %s's outbox: %v", user.ID, err)
+		http.Error(w, "Failed to get outbox", http.StatusInternalServerError)
+		return
+	}
+
+	result := activitypub.OrderedCollectionPage{
+		Context:      activitypub.Context,
+		ID:           outbox + "?page=" + url.QueryEscape(page),
+		Type:         "OrderedCollectionPage",
+		PartOf:       outbox,
+		OrderedItems: []any{},
+	}
+	if len(gobits) > outboxPageSize {
+		gobits = gobits[:outboxPageSize]
+		last := gobits[len(gobits)-1]
+		result.Next = outbox + "?page=" + encodeCursor(last.PublishedAt.Time, last.ID)
+	}
+	for _, gobit := range gobits {
+		activity, err := cfg.createActivity(gobit)
+		if err != nil {
+			log.Printf("Error rendering gobit %s for outbox: %v", gobit.ID, err)
+			http.Error(w, "Failed to get outbox", http.StatusInternalServerError)
+			return
+		}
+		result.OrderedItems = append(result.OrderedItems, activity)
+	}
+
+	respondWithActivity(w, http.StatusOK, result)
+}
+
+// getFollowersCollection serves the size of a user's remote following. The
+// followers themselves are not listed.
+func (cfg *apiConfig) getFollowersCollection(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.federatedUserFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	total, err := cfg.db.CountRemoteFollowers(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error counting remote followers of %s: %v", user.ID, err)
+		http.Error(w, "Failed to get followers", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithActivity(w, http.StatusOK, activitypub.OrderedCollection{
+		Context:    activitypub.Context,
+		ID:         cfg.actorURL(user.ID) + "/followers",
+		Type:       "OrderedCollection",
+		TotalItems: total,
+	})
+}
+
+// getNote serves a public gobit as a Note.
+func (cfg *apiConfig) getNote(w http.ResponseWriter, r *http.Request) {
+	if !cfg.federationEnabled() {
+		http.NotFound(w, r)
+		return
+	}
+
+	gobitID, err := uuid.Parse(r.PathValue("gobitID"))
+	if err != nil {
+		http.Error(w, "Invalid gobit ID format", http.StatusBadRequest)
+		return
+	}
+
+	gobit, ok, err := cfg.federatedGobit(r.Context(), gobitID)
+	if err != nil {
+		log.Printf("Error getting gobit %s for ActivityPub: %v", gobitID, err)
+		http.Error(w, "Failed to get gobit", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "gobit not found", http.StatusNotFound)
+		return
+	}
+
+	likes, err := cfg.db.CountRemoteLikes(r.Context(), gobit.ID)
+	if err != nil {
+		log.Printf("Error counting remote likes of gobit %s: %v", gobit.ID, err)
+		http.Error(w, "Failed to get gobit", http.StatusInternalServerError)
+		return
+	}
+
+	note := cfg.toNote(gobit)
+	note.Context = activitypub.Context
+	note.Likes = &activitypub.Count{Type: "Collection", TotalItems: likes}
+	respondWithActivity(w, http.StatusOK, note)
+}
+
+// fetchRemoteActor fetches an actor document, or the owner of a key
+// document, and caches it.
+func (cfg *apiConfig) fetchRemoteActor(ctx context.Context, uri string) (database.RemoteActor, error) {
+	uri, _, _ = strings.Cut(uri, "#")
+
+	var doc struct {
+		activitypub.Actor
+		// Some servers serve keys as documents of their own
+		Owner string `json:"owner"`
+	}
+	if err := activitypub.Fetch(ctx, uri, &doc); err != nil {
+		return database.RemoteActor{}, err
+	}
+	if doc.Inbox == "" && doc.Owner != "" && doc.Owner != uri {
+		uri = doc.Owner
+		doc.Actor = activitypub.Actor{}
+		if err := activitypub.Fetch(ctx, uri, &doc.Actor); err != nil {
+			return database.RemoteActor{}, err
+		}
+	}
+	actor := doc.Actor
+
+	// The document must come from the server that owns it
+	fetched, _ := url.Parse(uri)
+	id, err := url.Parse(actor.ID)
+	if err != nil || id.Host != fetched.Host || actor.Inbox == "" {
+		return database.RemoteActor{}, fmt.Errorf("%s is not a valid actor", uri)
+	}
+	if actor.PublicKey.Owner != actor.ID || actor.PublicKey.PublicKeyPem == "" {
+		return database.RemoteActor{}, fmt.Errorf("actor %s has no public key", actor.ID)
+	}
+	if _, err := activitypub.ParsePublicKey(actor.PublicKey.PublicKeyPem); err != nil {
+		return database.RemoteActor{}, fmt.Errorf("actor %s has an unusable public key: %w", actor.ID, err)
+	}
+
+	params := database.UpsertRemoteActorParams{
+		Uri:          actor.ID,
+		Handle:       actor.PreferredUsername + "@" + id.Host,
+		DisplayName:  actor.Name,
+		InboxUrl:     actor.Inbox,
+		FollowersUrl: sql.NullString{String: actor.Followers, Valid: actor.Followers != ""},
+		PublicKeyID:  actor.PublicKey.ID,
+		PublicKeyPem: actor.PublicKey.PublicKeyPem,
+	}
+	if actor.Endpoints != nil && actor.Endpoints.SharedInbox != "" {
+		params.SharedInboxUrl = sql.NullString{String: actor.Endpoints.SharedInbox, Valid: true}
+	}
+	return cfg.db.UpsertRemoteActor(ctx, params)
+}
+
+// remoteActorKey looks up the key a remote request was signed with, fetching
+// the actor if we haven't seen them or our copy is stale.
+func (cfg *apiConfig) remoteActorKey(ctx context.Context, keyID string, refresh bool) (*rsa.PublicKey, error) {
+	actor, err := cfg.db.GetRemoteActorByKeyID(ctx, keyID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	age := time.Since(actor.UpdatedAt)
+	if err == sql.ErrNoRows || age > remoteActorRefreshAfter || (refresh && age > remoteActorMinRefresh) {
+		actor, err = cfg.fetchRemoteActor(ctx, keyID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: fetching key %s: %v", activitypub.ErrInvalidSignature, keyID, err)
+		}
+		if actor.PublicKeyID != keyID {
+			return nil, fmt.Errorf("%w: key %s does not belong to %s", activitypub.ErrInvalidSignature, keyID, actor.Uri)
+		}
+	}
+	return activitypub.ParsePublicKey(actor.PublicKeyPem)
+}
+
+// postInbox accepts activities from other servers. It serves both the
+// per-user inboxes and the shared inbox; either way the activity itself says
+// who it is for.
+func (cfg *apiConfig) postInbox(w http.ResponseWriter, r *http.Request) {
+	if !cfg.federationEnabled() {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, activitypub.MaxDocumentBytes))
+	if err != nil {
+		http.Error(w, "Activity too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var activity activitypub.Activity
+	if err := json.Unmarshal(body, &activity); err != nil || activity.Type == "" || activity.Actor == "" {
+		http.Error(w, "Invalid activity", http.StatusBadRequest)
+		return
+	}
+
+	keyID, err := activitypub.Verify(r, body, cfg.remoteActorKey)
+	if err != nil {
+		if errors.Is(err, activitypub.ErrMissingSignature) || errors.Is(err, activitypub.ErrInvalidSignature) {
+			log.Printf("Rejected %s activity from %s: %v", activity.Type, activity.Actor, err)
+			http.Error(w, "Unauthorized: invalid signature", http.StatusUnauthorized)
+		} else {
+			log.Printf("Error verifying %s activity from %s: %v", activity.Type, activity.Actor, err)
+			http.Error(w, "Failed to verify signature", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	actor, err := cfg.db.GetRemoteActorByKeyID(r.Context(), keyID)
+	if err != nil {
+		log.Printf("Error getting remote actor for key %s: %v", keyID, err)
+		http.Error(w, "Failed to process activity", http.StatusInternalServerError)
+		return
+	}
+	if activity.Actor != actor.Uri {
+		http.Error(w, "Forbidden: activity is not signed by its actor", http.StatusForbidden)
+		return
+	}
+
+	if err := cfg.handleActivity(r.Context(), actor, activity); err != nil {
+		log.Printf("Error handling %s activity %s from %s: %v", activity.Type, activity.ID, actor.Uri, err)
+		http.Error(w, "Failed to process activity", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleActivity applies an activity from a verified remote actor.
+// Activities we don't act on are accepted and dropped.
+func (cfg *apiConfig) handleActivity(ctx context.Context, actor database.RemoteActor, activity activitypub.Activity) error {
+	objectID := activity.ObjectID()
+
+	switch activity.Type {
+	case "Follow":
+		userID, ok := cfg.localID(objectID, "/ap/users/")
+		if !ok {
+			return nil
+		}
+		user, ok, err := cfg.federatedUser(ctx, userID)
+		if err != nil || !ok {
+			return err
+		}
+		err = cfg.db.CreateRemoteFollower(ctx, database.CreateRemoteFollowerParams{
+			UserID:      user.ID,
+			ActorID:     actor.ID,
+			ActivityUri: activity.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Follows are accepted straight away, echoing the Follow back
+		follow, err := json.Marshal(activity)
+		if err != nil {
+			return err
+		}
+		local := cfg.actorURL(user.ID)
+		return cfg.deliver(ctx, user.ID, actor.InboxUrl, activitypub.Activity{
+			Context: activitypub.Context,
+			ID:      local + "#accepts/" + uuid.NewString(),
+			Type:    "Accept",
+			Actor:   local,
+			Object:  follow,
+		})
+
+	case "Undo":
+		if _, err := cfg.db.DeleteRemoteFollower(ctx, database.DeleteRemoteFollowerParams{
+			ActorID:     actor.ID,
+			ActivityUri: objectID,
+		}); err != nil {
+			return err
+		}
+		_, err := cfg.db.DeleteRemoteLike(ctx, database.DeleteRemoteLikeParams{
+			ActorID:     actor.ID,
+			ActivityUri: objectID,
+		})
+		return err
+
+	case "Create":
+		if activity.ObjectType() != "Note" {
+			return nil
+		}
+		return cfg.receiveNote(ctx, actor, activity.Object)
+
+	case "Like":
+		gobitID, ok := cfg.localID(objectID, "/ap/gobits/")
+		if !ok {
+			return nil
+		}
+		gobit, ok, err := cfg.federatedGobit(ctx, gobitID)
+		if err != nil || !ok {
+			return err
+		}
+		return cfg.db.CreateRemoteLike(ctx, database.CreateRemoteLikeParams{
+			ActorID:     actor.ID,
+			GobitID:     gobit.ID,
+			ActivityUri: activity.ID,
+		})
+
+	case "Accept":
+		_, err := cfg.db.AcceptRemoteFollowing(ctx, database.AcceptRemoteFollowingParams{
+			ActorID:     actor.ID,
+			ActivityUri: objectID,
+		})
+		return err
+
+	case "Reject":
+		_, err := cfg.db.RejectRemoteFollowing(ctx, database.RejectRemoteFollowingParams{
+			ActorID:     actor.ID,
+			ActivityUri: objectID,
+		})
+		return err
+
+	case "Delete":
+		// The actor deleting their own account takes everything of theirs
+		// with it
+		if objectID == actor.Uri {
+			_, err := cfg.db.DeleteRemoteActorByURI(ctx, actor.Uri)
+			return err
+		}
+		_, err := cfg.db.DeleteRemoteNote(ctx, database.DeleteRemoteNoteParams{
+			Uri:     objectID,
+			ActorID: actor.ID,
+		})
+		return err
+	}
+	return nil
+}
+
+// receiveNote keeps a remote note that a local user follows the author of or
+// that replies to a local gobit. Direct messages are not kept.
+func (cfg *apiConfig) receiveNote(ctx context.Context, actor database.RemoteActor, object json.RawMessage) error {
+	var note activitypub.Note
+	if err := json.Unmarshal(object, &note); err != nil || note.ID == "" {
+		return nil
+	}
+	if note.AttributedTo != actor.Uri {
+		return nil
+	}
+	// An actor can only publish notes on its own server
+	noteURL, err := url.Parse(note.ID)
+	actorURL, _ := url.Parse(actor.Uri)
+	if err != nil || actorURL == nil || !strings.EqualFold(noteURL.Host, actorURL.Host) {
+		return nil
+	}
+
+	addressed := note.To.Contains(activitypub.Public) || note.CC.Contains(activitypub.Public) ||
+		(actor.FollowersUrl.Valid && (note.To.Contains(actor.FollowersUrl.String) || note.CC.Contains(actor.FollowersUrl.String)))
+	if !addressed {
+		return nil
+	}
+
+	var inReplyTo uuid.NullUUID
+	if gobitID, ok := cfg.localID(note.InReplyTo, "/ap/gobits/"); ok {
+		if _, ok, err := cfg.federatedGobit(ctx, gobitID); err != nil {
+			return err
+		} else if ok {
+			inReplyTo = uuid.NullUUID{UUID: gobitID, Valid: true}
+		}
+	}
+	if !inReplyTo.Valid {
+		followed, err := cfg.db.HasLocalFollowers(ctx, actor.ID)
+		if err != nil || !followed {
+			return err
+		}
+	}
+
+	published, err := time.Parse(time.RFC3339, note.Published)
+	if err != nil {
+		published = time.Now()
+	}
+	link := note.URL
+	if link == "" {
+		link = note.ID
+	}
+
+	return cfg.db.CreateRemoteNote(ctx, database.CreateRemoteNoteParams{
+		Uri:         note.ID,
+		ActorID:     actor.ID,
+		Url:         link,
+		Content:     activitypub.PlainText(note.Content),
+		InReplyToID: inReplyTo,
+		PublishedAt: published.UTC(),
+	})
+}
+
+// deliver queues an activity from a local user for delivery to an inbox.
+func (cfg *apiConfig) deliver(ctx context.Context, userID uuid.UUID, inbox string, activity activitypub.Activity) error {
+	if activity.Context == nil {
+		activity.Context = activitypub.Context
+	}
+	data, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	_, err = cfg.jobs.Enqueue(ctx, jobDeliverActivity, deliveryJobPayload{
+		UserID:   userID,
+		Inbox:    inbox,
+		Activity: data,
+	}, time.Now())
+	return err
+}
+
+// deliverToFollowers queues an activity for every server with a remote
+// follower of the user.
+func (cfg *apiConfig) deliverToFollowers(ctx context.Context, userID uuid.UUID, activity activitypub.Activity) error {
+	inboxes, err := cfg.db.ListRemoteFollowerInboxes(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, inbox := range inboxes {
+		if err := cfg.deliver(ctx, userID, inbox, activity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runDeliverActivity signs and POSTs one queued activity. Servers that
+// refuse it outright are not retried.
+func (cfg *apiConfig) runDeliverActivity(ctx context.Context, payload json.RawMessage) error {
+	var p deliveryJobPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+
+	// Nothing more goes out once an account is deleted or banned
+	_, ok, err := cfg.federatedUser(ctx, p.UserID)
+	if err != nil || !ok {
+		return err
+	}
+
+	key, err := cfg.actorKey(ctx, p.UserID)
+	if err != nil {
+		return err
+	}
+	privateKey, err := activitypub.ParsePrivateKey(key.PrivateKeyPem)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	err = activitypub.Deliver(ctx, p.Inbox, p.Activity, cfg.actorURL(p.UserID)+"#main-key", privateKey)
+	var statusErr *activitypub.StatusError
+	if errors.As(err, &statusErr) && statusErr.Permanent() {
+		log.Printf("Delivery to %s refused, not retrying: %v", p.Inbox, err)
+		return nil
+	}
+	return err
+}
+
+// federatePublished sends a newly published gobit to the author's remote
+// followers.
+func (cfg *apiConfig) federatePublished(ctx context.Context, gobit database.Gobit) error {
+	if !cfg.federationEnabled() || !gobitFederates(gobit) {
+		return nil
+	}
+	user, ok, err := cfg.federatedUser(ctx, gobit.UserID)
+	if err != nil || !ok {
+		return err
+	}
+
+	activity, err := cfg.createActivity(gobit)
+	if err != nil {
+		return err
+	}
+	return cfg.deliverToFollowers(ctx, user.ID, activity)
+}
+
+// federateDeleted tells the author's remote followers to drop a gobit.
+func (cfg *apiConfig) federateDeleted(ctx context.Context, gobit database.Gobit) error {
+	if !cfg.federationEnabled() || gobit.Visibility != visibilityPublic || gobit.RepostOfID.Valid {
+		return nil
+	}
+
+	actor := cfg.actorURL(gobit.UserID)
+	tombstone, err := json.Marshal(map[string]string{
+		"id":   cfg.noteURL(gobit.ID),
+		"type": "Tombstone",
+	})
+	if err != nil {
+		return err
+	}
+	return cfg.deliverToFollowers(ctx, gobit.UserID, activitypub.Activity{
+		ID:     actor + "#deletes/" + gobit.ID.String(),
+		Type:   "Delete",
+		Actor:  actor,
+		Object: tombstone,
+		To:     activitypub.Audience{activitypub.Public},
+	})
+}
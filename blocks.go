@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+type userRelationRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type userRelationResponse struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// blockedBetween reports whether either user has blocked the other. Blocked
+// users cannot interact with each other, so anything that lets one user act
+// on another's account or gobits should check this first.
+func (cfg *apiConfig) blockedBetween(ctx context.Context, userID, otherID uuid.UUID) (bool, error) {
+	return cfg.db.IsBlockedEitherWay(ctx, database.IsBlockedEitherWayParams{
+		UserID:  userID,
+		OtherID: otherID,
+	})
+}
+
+// decodeRelationTarget authenticates the caller and reads the user they want
+// to block, mute or follow from the body (POST) or path (DELETE). On failure it
+// writes the error response and returns false.
+func (cfg *apiConfig) decodeRelationTarget(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for %s: %v", r.URL.Path, err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for %s: %v", r.URL.Path, err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+	// --- Authentication End ---
+
+	var targetID uuid.UUID
+	if r.Method == http.MethodDelete {
+		targetID, err = uuid.Parse(r.PathValue("userID"))
+		if err != nil {
+			http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+			return uuid.Nil, uuid.Nil, false
+		}
+	} else {
+		if r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return uuid.Nil, uuid.Nil, false
+		}
+
+		var req userRelationRequest
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Printf("JSON user relation decode error: %v", err)
+			http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+			return uuid.Nil, uuid.Nil, false
+		}
+		targetID = req.UserID
+
+		_, err = cfg.db.GetUserByID(r.Context(), targetID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "user not found", http.StatusNotFound)
+			} else {
+				log.Printf("Error getting user %s: %v", targetID, err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			}
+			return uuid.Nil, uuid.Nil, false
+		}
+	}
+
+	if targetID == userID {
+		http.Error(w, "You cannot block, mute or follow yourself", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, targetID, true
+}
+
+func (cfg *apiConfig) createBlock(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.decodeRelationTarget(w, r)
+	if !ok {
+		return
+	}
+
+	// Blocking ends any follow between the two users, in both directions
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		err := q.CreateUserBlock(r.Context(), database.CreateUserBlockParams{
+			BlockerID: userID,
+			BlockedID: targetID,
+		})
+		if err != nil {
+			return err
+		}
+		return q.DeleteFollowsBetween(r.Context(), database.DeleteFollowsBetweenParams{
+			UserID:  userID,
+			OtherID: targetID,
+		})
+	})
+	if err != nil {
+		log.Printf("cannot block user %s for %s: %v", targetID, userID, err)
+		http.Error(w, "Failed to block user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) deleteBlock(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.decodeRelationTarget(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteUserBlock(r.Context(), database.DeleteUserBlockParams{
+		BlockerID: userID,
+		BlockedID: targetID,
+	})
+	if err != nil {
+		log.Printf("cannot unblock user %s for %s: %v", targetID, userID, err)
+		http.Error(w, "Failed to unblock user", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "block not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) listBlocks(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for block listing: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for block listing: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	blocks, err := cfg.db.ListUserBlocks(r.Context(), userID)
+	if err != nil {
+		log.Printf("cannot list blocks for user %s: %v", userID, err)
+		http.Error(w, "Failed to list blocks", http.StatusInternalServerError)
+		return
+	}
+
+	responseBlocks := make([]userRelationResponse, len(blocks))
+	for i, block := range blocks {
+		responseBlocks[i] = userRelationResponse{
+			UserID:    block.BlockedID,
+			CreatedAt: block.CreatedAt,
+		}
+	}
+
+	data, err := json.Marshal(responseBlocks)
+	if err != nil {
+		log.Printf("Error marshalling blocks response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) createMute(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.decodeRelationTarget(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.CreateUserMute(r.Context(), database.CreateUserMuteParams{
+		MuterID: userID,
+		MutedID: targetID,
+	})
+	if err != nil {
+		log.Printf("cannot mute user %s for %s: %v", targetID, userID, err)
+		http.Error(w, "Failed to mute user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) deleteMute(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.decodeRelationTarget(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteUserMute(r.Context(), database.DeleteUserMuteParams{
+		MuterID: userID,
+		MutedID: targetID,
+	})
+	if err != nil {
+		log.Printf("cannot unmute user %s for %s: %v", targetID, userID, err)
+		http.Error(w, "Failed to unmute user", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "mute not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) listMutes(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for mute listing: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for mute listing: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	mutes, err := cfg.db.ListUserMutes(r.Context(), userID)
+	if err != nil {
+		log.Printf("cannot list mutes for user %s: %v", userID, err)
+		http.Error(w, "Failed to list mutes", http.StatusInternalServerError)
+		return
+	}
+
+	responseMutes := make([]userRelationResponse, len(mutes))
+	for i, mute := range mutes {
+		responseMutes[i] = userRelationResponse{
+			UserID:    mute.MutedID,
+			CreatedAt: mute.CreatedAt,
+		}
+	}
+
+	data, err := json.Marshal(responseMutes)
+	if err != nil {
+		log.Printf("Error marshalling mutes response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	defaultBookmarksLimit = 20
+	maxBookmarksLimit     = 100
+)
+
+type bookmarkResponse struct {
+	BookmarkedAt time.Time    `json:"bookmarked_at"`
+	Gobit        createdGobit `json:"gobit"`
+}
+
+type bookmarkPage struct {
+	Bookmarks []bookmarkResponse `json:"bookmarks"`
+	// NextCursor is passed back as ?cursor= for the next page; it is left
+	// out on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// createBookmark privately saves a gobit the caller can see.
+func (cfg *apiConfig) createBookmark(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for bookmark: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for bookmark: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	gobitID, err := uuid.Parse(r.PathValue("gobitID"))
+	if err != nil {
+		http.Error(w, "Invalid gobit ID format", http.StatusBadRequest)
+		return
+	}
+
+	visible, err := cfg.db.GetVisibleGobitsByIDs(r.Context(), database.GetVisibleGobitsByIDsParams{
+		Ids:      []uuid.UUID{gobitID},
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		log.Printf("Error getting gobit %s to bookmark: %v", gobitID, err)
+		http.Error(w, "Failed to bookmark gobit", http.StatusInternalServerError)
+		return
+	}
+	if len(visible) == 0 {
+		http.Error(w, "gobit not found", http.StatusNotFound)
+		return
+	}
+
+	err = cfg.db.CreateBookmark(r.Context(), database.CreateBookmarkParams{
+		UserID:  userID,
+		GobitID: gobitID,
+	})
+	if err != nil {
+		log.Printf("Error bookmarking gobit %s for %s: %v", gobitID, userID, err)
+		http.Error(w, "Failed to bookmark gobit", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) deleteBookmark(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for bookmark removal: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for bookmark removal: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	gobitID, err := uuid.Parse(r.PathValue("gobitID"))
+	if err != nil {
+		http.Error(w, "Invalid gobit ID format", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteBookmark(r.Context(), database.DeleteBookmarkParams{
+		UserID:  userID,
+		GobitID: gobitID,
+	})
+	if err != nil {
+		log.Printf("Error removing bookmark of %s for %s: %v", gobitID, userID, err)
+		http.Error(w, "Failed to remove bookmark", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "bookmark not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listBookmarks returns the caller's bookmarks, newest first, a page at a
+// time. Bookmarked gobits the caller can no longer see are skipped.
+func (cfg *apiConfig) listBookmarks(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for bookmark listing: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for bookmark listing: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	params := database.ListBookmarksParams{
+		UserID: userID,
+		Limit:  defaultBookmarksLimit,
+	}
+	query := r.URL.Query()
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxBookmarksLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		params.Limit = int32(limit)
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		createdAt, gobitID, err := decodeCursor(cursor)
+		if err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+		params.BeforeGobitID = uuid.NullUUID{UUID: gobitID, Valid: true}
+	}
+
+	// Fetch one extra to find out whether there is another page
+	pageSize := params.Limit
+	params.Limit++
+	bookmarks, err := cfg.db.ListBookmarks(r.Context(), params)
+	if err != nil {
+		log.Printf("cannot list bookmarks for user %s: %v", userID, err)
+		http.Error(w, "Failed to list bookmarks", http.StatusInternalServerError)
+		return
+	}
+
+	page := bookmarkPage{Bookmarks: []bookmarkResponse{}}
+	if len(bookmarks) > int(pageSize) {
+		bookmarks = bookmarks[:pageSize]
+		last := bookmarks[len(bookmarks)-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.GobitID)
+	}
+
+	gobitIDs := make([]uuid.UUID, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		gobitIDs[i] = bookmark.GobitID
+	}
+	gobits, err := cfg.db.GetVisibleGobitsByIDs(r.Context(), database.GetVisibleGobitsByIDsParams{
+		Ids:      gobitIDs,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		log.Printf("cannot get bookmarked gobits for user %s: %v", userID, err)
+		http.Error(w, "Failed to list bookmarks", http.StatusInternalServerError)
+		return
+	}
+	responseGobits, err := cfg.gobitResponses(r, gobits)
+	if err != nil {
+		log.Printf("cannot build bookmarks response for user %s: %v", userID, err)
+		http.Error(w, "Failed to list bookmarks", http.StatusInternalServerError)
+		return
+	}
+
+	byID := make(map[uuid.UUID]createdGobit, len(responseGobits))
+	for _, responseGobit := range responseGobits {
+		byID[responseGobit.ID] = responseGobit
+	}
+	for _, bookmark := range bookmarks {
+		if gobit, ok := byID[bookmark.GobitID]; ok {
+			page.Bookmarks = append(page.Bookmarks, bookmarkResponse{
+				BookmarkedAt: bookmark.CreatedAt,
+				Gobit:        gobit,
+			})
+		}
+	}
+
+	data, err := json.Marshal(page)
+	if err != nil {
+		log.Printf("Error marshalling bookmarks response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Login starts a session with the account's email and password. The
+// client keeps the returned tokens and uses them for later calls.
+func (c *Client) Login(ctx context.Context, email, password string) (*User, error) {
+	var resp struct {
+		User
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/login",
+		body:   map[string]string{"email": email, "password": password},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	c.replaceTokens(Tokens{AccessToken: resp.Token, RefreshToken: resp.RefreshToken})
+	return &resp.User, nil
+}
+
+// Refresh gets a new access token with the refresh token. Calls that need
+// one refresh it automatically, so this is only needed to renew eagerly.
+func (c *Client) Refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	return c.refresh(ctx)
+}
+
+// refresh renews the access token. The caller holds refreshMu.
+func (c *Client) refresh(ctx context.Context) error {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/refresh",
+		auth:   authRefresh,
+	}, &resp)
+	if err != nil {
+		return err
+	}
+	c.setAccessToken(resp.Token)
+	return nil
+}
+
+// Revoke ends the session: the refresh token stops working and the client
+// forgets its tokens. The current access token stays valid until it
+// expires.
+func (c *Client) Revoke(ctx context.Context) error {
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/revoke",
+		auth:   authRefresh,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	c.replaceTokens(Tokens{})
+	return nil
+}
+
+// Sessions lists the user's signed-in sessions, oldest first.
+func (c *Client) Sessions(ctx context.Context) ([]Session, error) {
+	var sessions []Session
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/sessions",
+		auth:   authAccess,
+	}, &sessions)
+	return sessions, err
+}
+
+// RevokeSession signs one of the user's sessions out. To end the client's
+// own session, use Revoke.
+func (c *Client) RevokeSession(ctx context.Context, id string) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   "/api/sessions/" + url.PathEscape(id),
+		auth:   authAccess,
+	}, nil)
+}
@@ -0,0 +1,301 @@
+// Package client is a Go client for the Gohost HTTP API.
+//
+// A Client holds the access and refresh tokens of one session. When the
+// access token expires it is renewed with the refresh token automatically,
+// so a long-running service only needs to log in once:
+//
+//	c := client.New("https://gohost.example")
+//	if _, err := c.Login(ctx, email, password); err != nil {
+//		return err
+//	}
+//	gobit, err := c.CreateGobit(ctx, client.GobitRequest{Body: "hello"})
+//
+// Errors from the API are *APIError values that match the Err* sentinels
+// with errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds each call when Client.Timeout is not set.
+const DefaultTimeout = 30 * time.Second
+
+// refreshMargin is how long before its expiry an access token is renewed,
+// so it doesn't lapse while a request is in flight.
+const refreshMargin = 30 * time.Second
+
+// Tokens are the credentials of a session.
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Client calls the Gohost API. It is safe for concurrent use.
+type Client struct {
+	// BaseURL is the server's root, such as "https://gohost.example".
+	BaseURL string
+	// HTTPClient sends the requests; http.DefaultClient if nil.
+	HTTPClient *http.Client
+	// Timeout bounds each call, including any token refresh and retry. The
+	// context's own deadline still applies when it is sooner. Zero means
+	// DefaultTimeout; a negative value disables the limit.
+	Timeout time.Duration
+	// WebhookKey is the API key SendWebhook authenticates with.
+	WebhookKey string
+	// OnTokens, if set, is called with the new tokens whenever they change:
+	// on Login, each refresh, a password change, and Revoke or DeleteAccount
+	// (with empty tokens). It lets callers persist the session.
+	OnTokens func(Tokens)
+
+	mu     sync.Mutex
+	tokens Tokens
+	// refreshMu serialises refreshes so concurrent calls that find the
+	// access token expired renew it once.
+	refreshMu sync.Mutex
+}
+
+// New returns a Client for the server at baseURL.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Tokens returns the current session's tokens.
+func (c *Client) Tokens() Tokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens
+}
+
+// SetTokens resumes a session, for example with tokens saved by OnTokens.
+func (c *Client) SetTokens(t Tokens) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens = t
+}
+
+// AccessToken returns a current access token, refreshing it first if it is
+// about to expire. It is for requests the client doesn't make itself, such
+// as opening a WebSocket.
+func (c *Client) AccessToken(ctx context.Context) (string, error) {
+	return c.credential(ctx, authAccess)
+}
+
+// replaceTokens switches to a new session, or to none, and tells OnTokens.
+func (c *Client) replaceTokens(t Tokens) {
+	c.SetTokens(t)
+	if c.OnTokens != nil {
+		c.OnTokens(t)
+	}
+}
+
+func (c *Client) setAccessToken(token string) {
+	c.mu.Lock()
+	c.tokens.AccessToken = token
+	t := c.tokens
+	c.mu.Unlock()
+	if c.OnTokens != nil {
+		c.OnTokens(t)
+	}
+}
+
+// authMode says which credential a request carries.
+type authMode int
+
+const (
+	authNone authMode = iota
+	// authAccess sends the access token, refreshing it when needed.
+	authAccess
+	// authOptional sends the access token if there is one.
+	authOptional
+	// authRefresh sends the refresh token.
+	authRefresh
+	// authWebhook sends WebhookKey.
+	authWebhook
+)
+
+// request describes one API call.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+	auth   authMode
+}
+
+// do sends req and decodes a successful JSON response into out, which may
+// be nil.
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return fmt.Errorf("gohost: encode %s %s: %w", req.method, req.path, err)
+		}
+	}
+
+	token, err := c.credential(ctx, req.auth)
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(ctx, req, body, token)
+	if err != nil {
+		return err
+	}
+
+	// An access token can be revoked or expire early; renew it once and
+	// retry before giving up.
+	if resp.StatusCode == http.StatusUnauthorized && req.auth == authAccess && c.Tokens().RefreshToken != "" {
+		drain(resp)
+		if _, err := c.refreshFrom(ctx, token); err != nil {
+			return err
+		}
+		if token, err = c.credential(ctx, req.auth); err != nil {
+			return err
+		}
+		if resp, err = c.send(ctx, req, body, token); err != nil {
+			return err
+		}
+	}
+	defer drain(resp)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newAPIError(req.method, req.path, resp)
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("gohost: decode %s %s response: %w", req.method, req.path, err)
+		}
+	}
+	return nil
+}
+
+// credential returns the token to send for mode, refreshing the access
+// token first when it is about to expire.
+func (c *Client) credential(ctx context.Context, mode authMode) (string, error) {
+	t := c.Tokens()
+	switch mode {
+	case authAccess:
+		if t.AccessToken == "" && t.RefreshToken == "" {
+			return "", ErrNotLoggedIn
+		}
+		if t.RefreshToken != "" && expiresWithin(t.AccessToken, refreshMargin) {
+			return c.refreshFrom(ctx, t.AccessToken)
+		}
+		return t.AccessToken, nil
+	case authOptional:
+		if t.RefreshToken != "" && expiresWithin(t.AccessToken, refreshMargin) {
+			if token, err := c.refreshFrom(ctx, t.AccessToken); err == nil {
+				return token, nil
+			}
+			return "", nil
+		}
+		return t.AccessToken, nil
+	case authRefresh:
+		if t.RefreshToken == "" {
+			return "", ErrNotLoggedIn
+		}
+		return t.RefreshToken, nil
+	case authWebhook:
+		return c.WebhookKey, nil
+	}
+	return "", nil
+}
+
+// refreshFrom renews the access token unless another call already replaced
+// stale, and returns the current access token.
+func (c *Client) refreshFrom(ctx context.Context, stale string) (string, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	if t := c.Tokens(); t.AccessToken != stale && t.AccessToken != "" {
+		return t.AccessToken, nil
+	}
+	if err := c.refresh(ctx); err != nil {
+		return "", err
+	}
+	return c.Tokens().AccessToken, nil
+}
+
+func (c *Client) send(ctx context.Context, req request, body []byte, token string) (*http.Response, error) {
+	u := c.BaseURL + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u, reader)
+	if err != nil {
+		return nil, fmt.Errorf("gohost: %s %s: %w", req.method, req.path, err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		if req.auth == authWebhook {
+			httpReq.Header.Set("Authorization", "ApiKey "+token)
+		} else {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("gohost: %s %s: %w", req.method, req.path, err)
+	}
+	return resp, nil
+}
+
+// drain reads what is left of the body so the connection can be reused.
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	resp.Body.Close()
+}
+
+// expiresWithin reports whether the JWT's exp claim is less than d away.
+// The signature isn't checked; only the server can do that. Tokens that
+// can't be read are treated as expired.
+func expiresWithin(token string, d time.Duration) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return true
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return true
+	}
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == 0 {
+		return true
+	}
+	return time.Until(time.Unix(claims.ExpiresAt, 0)) < d
+}
@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testToken is an unsigned JWT expiring at exp, which is all the client
+// reads from it. name tells tokens apart.
+func testToken(name string, exp time.Time) string {
+	encode := func(v any) string {
+		data, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	return encode(map[string]string{"alg": "none"}) + "." +
+		encode(map[string]any{"exp": exp.Unix(), "sub": name}) + ".sig"
+}
+
+// refreshServer accepts only the access token it last handed out, and
+// counts the refreshes it is asked for.
+type refreshServer struct {
+	refreshes atomic.Int32
+	mu        sync.Mutex
+	current   string
+}
+
+func (s *refreshServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/refresh":
+		if r.Header.Get("Authorization") != "Bearer refresh" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		n := s.refreshes.Add(1)
+		// Slow enough for the other calls to pile up behind this one
+		time.Sleep(20 * time.Millisecond)
+		s.mu.Lock()
+		s.current = testToken(fmt.Sprintf("access-%d", n), time.Now().Add(time.Hour))
+		token := s.current
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"token": %q}`, token)
+	case "/api/sessions":
+		s.mu.Lock()
+		ok := r.Header.Get("Authorization") == "Bearer "+s.current
+		s.mu.Unlock()
+		if !ok {
+			http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestConcurrentCallsRefreshOnce(t *testing.T) {
+	tests := []struct {
+		name string
+		// access is the token the client starts with
+		access string
+	}{
+		{name: "expired", access: testToken("old", time.Now().Add(-time.Minute))},
+		{name: "about to expire", access: testToken("old", time.Now().Add(refreshMargin/2))},
+		// Not expired by its claims, but the server has stopped taking it
+		{name: "rejected", access: testToken("old", time.Now().Add(time.Hour))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &refreshServer{}
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+
+			c := New(ts.URL)
+			c.SetTokens(Tokens{AccessToken: tt.access, RefreshToken: "refresh"})
+			var updates atomic.Int32
+			c.OnTokens = func(Tokens) { updates.Add(1) }
+
+			const calls = 10
+			var wg sync.WaitGroup
+			errs := make(chan error, calls)
+			for i := 0; i < calls; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := c.Sessions(context.Background())
+					errs <- err
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				if err != nil {
+					t.Errorf("Sessions: %v", err)
+				}
+			}
+			if got := server.refreshes.Load(); got != 1 {
+				t.Errorf("refreshes = %d, want 1", got)
+			}
+			if got := updates.Load(); got != 1 {
+				t.Errorf("OnTokens called %d times, want 1", got)
+			}
+			if got := c.Tokens().AccessToken; got != server.current {
+				t.Errorf("client kept %q, want the refreshed token", got)
+			}
+		})
+	}
+}
+
+func TestNotLoggedIn(t *testing.T) {
+	c := New("http://gohost.invalid")
+	if _, err := c.Sessions(context.Background()); !errors.Is(err, ErrNotLoggedIn) {
+		t.Errorf("err = %v, want ErrNotLoggedIn", err)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		sentinel    error
+		message     string
+		violations  []Violation
+	}{
+		{
+			name:        "validation",
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+			body:        `{"error": "Length is too long", "violations": [{"field": "body", "code": "too_long", "message": "Length is too long", "limit": 140}]}`,
+			sentinel:    ErrBadRequest,
+			message:     "Length is too long",
+			violations:  []Violation{{Field: "body", Code: "too_long", Message: "Length is too long", Limit: 140}},
+		},
+		{
+			name:        "plain text",
+			status:      http.StatusNotFound,
+			contentType: "text/plain; charset=utf-8",
+			body:        "gobit not found\n",
+			sentinel:    ErrNotFound,
+			message:     "gobit not found",
+		},
+		{
+			name:        "json with charset",
+			status:      http.StatusConflict,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"error": "Handle is taken"}`,
+			sentinel:    ErrConflict,
+			message:     "Handle is taken",
+		},
+		{
+			name:        "unreadable json",
+			status:      http.StatusUnprocessableEntity,
+			contentType: "application/json",
+			body:        "not json",
+			sentinel:    ErrUnprocessable,
+			message:     "not json",
+		},
+		{name: "forbidden", status: http.StatusForbidden, body: "Forbidden", sentinel: ErrForbidden, message: "Forbidden"},
+		{name: "gone", status: http.StatusGone, sentinel: ErrGone},
+		{name: "too large", status: http.StatusRequestEntityTooLarge, sentinel: ErrTooLarge},
+		{name: "unsupported media type", status: http.StatusUnsupportedMediaType, sentinel: ErrUnsupportedMediaType},
+		{name: "server error", status: http.StatusInternalServerError, body: "Failed to get gobit", sentinel: ErrServer, message: "Failed to get gobit"},
+		{name: "bad gateway", status: http.StatusBadGateway, sentinel: ErrServer},
+		{name: "no sentinel", status: http.StatusTeapot, body: "short and stout", message: "short and stout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+
+			_, err := New(ts.URL).Search(context.Background(), "kerfuffle", SearchOptions{})
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, tt.status)
+			}
+			if apiErr.Method != http.MethodGet || apiErr.Path != "/api/gobits/search" {
+				t.Errorf("request = %s %s, want GET /api/gobits/search", apiErr.Method, apiErr.Path)
+			}
+			if apiErr.Message != tt.message {
+				t.Errorf("Message = %q, want %q", apiErr.Message, tt.message)
+			}
+			if len(apiErr.Violations) != len(tt.violations) {
+				t.Fatalf("Violations = %+v, want %+v", apiErr.Violations, tt.violations)
+			}
+			for i := range tt.violations {
+				if apiErr.Violations[i] != tt.violations[i] {
+					t.Errorf("Violations[%d] = %+v, want %+v", i, apiErr.Violations[i], tt.violations[i])
+				}
+			}
+
+			if tt.sentinel != nil && !errors.Is(err, tt.sentinel) {
+				t.Errorf("errors.Is(err, %v) = false", tt.sentinel)
+			}
+			if tt.sentinel == nil && apiErr.Unwrap() != nil {
+				t.Errorf("Unwrap = %v, want nil", apiErr.Unwrap())
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors for the kinds of failure callers usually branch on. An
+// *APIError matches the one for its status code with errors.Is.
+var (
+	ErrNotLoggedIn = errors.New("gohost: not logged in")
+
+	ErrBadRequest           = errors.New("gohost: bad request")
+	ErrUnauthorized         = errors.New("gohost: unauthorized")
+	ErrForbidden            = errors.New("gohost: forbidden")
+	ErrNotFound             = errors.New("gohost: not found")
+	ErrConflict             = errors.New("gohost: conflict")
+	ErrGone                 = errors.New("gohost: gone")
+	ErrTooLarge             = errors.New("gohost: request too large")
+	ErrUnsupportedMediaType = errors.New("gohost: unsupported media type")
+	ErrUnprocessable        = errors.New("gohost: unprocessable")
+	ErrServer               = errors.New("gohost: server error")
+)
+
+var statusErrors = map[int]error{
+	http.StatusBadRequest:            ErrBadRequest,
+	http.StatusUnauthorized:          ErrUnauthorized,
+	http.StatusForbidden:             ErrForbidden,
+	http.StatusNotFound:              ErrNotFound,
+	http.StatusConflict:              ErrConflict,
+	http.StatusGone:                  ErrGone,
+	http.StatusRequestEntityTooLarge: ErrTooLarge,
+	http.StatusUnsupportedMediaType:  ErrUnsupportedMediaType,
+	http.StatusUnprocessableEntity:   ErrUnprocessable,
+}
+
+// Violation is one failed validation rule, such as a gobit body that is too
+// long.
+type Violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Limit   int    `json:"limit,omitempty"`
+}
+
+// APIError is a non-2xx response.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Message is the server's explanation.
+	Message string
+	// Violations lists every failed rule when the request didn't validate.
+	Violations []Violation
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	for i, v := range e.Violations {
+		if i == 0 {
+			msg += ":"
+		} else {
+			msg += ";"
+		}
+		msg += " " + v.Message
+	}
+	return fmt.Sprintf("gohost: %s %s: %d %s", e.Method, e.Path, e.StatusCode, msg)
+}
+
+// Unwrap returns the sentinel error for the status code, if there is one.
+func (e *APIError) Unwrap() error {
+	if err, ok := statusErrors[e.StatusCode]; ok {
+		return err
+	}
+	if e.StatusCode >= 500 {
+		return ErrServer
+	}
+	return nil
+}
+
+// newAPIError reads an error response. Validation failures are JSON; other
+// errors are plain text.
+func newAPIError(method, path string, resp *http.Response) *APIError {
+	apiErr := &APIError{Method: method, Path: path, StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "application/json" {
+		var validation struct {
+			Error      string      `json:"error"`
+			Violations []Violation `json:"violations"`
+		}
+		if json.Unmarshal(body, &validation) == nil {
+			apiErr.Message = validation.Error
+			apiErr.Violations = validation.Violations
+			return apiErr
+		}
+	}
+	apiErr.Message = strings.TrimSpace(string(body))
+	return apiErr
+}
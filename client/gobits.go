@@ -0,0 +1,336 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ListOptions filters GET /api/gobits.
+type ListOptions struct {
+	// AuthorID limits the listing to one user's gobits, pinned ones first.
+	AuthorID uuid.UUID
+	// Newest lists newest first instead of oldest first.
+	Newest bool
+	// IncludeAuthor embeds each gobit's author.
+	IncludeAuthor bool
+}
+
+// FeedOptions pages through the home timeline.
+type FeedOptions struct {
+	// Before returns gobits published before this time; zero starts at the
+	// newest.
+	Before time.Time
+	// BeforeID breaks ties between gobits published at Before: set it to
+	// the ID of the last gobit seen so none are skipped.
+	BeforeID uuid.UUID
+	// Limit is the page size; zero uses the server's default.
+	Limit         int
+	IncludeAuthor bool
+}
+
+// SearchOptions pages through search results.
+type SearchOptions struct {
+	Limit         int
+	Offset        int
+	IncludeAuthor bool
+}
+
+// PageOptions pages through a cursor-paginated listing.
+type PageOptions struct {
+	// Cursor is the NextCursor of the previous page; empty for the first.
+	Cursor        string
+	Limit         int
+	IncludeAuthor bool
+}
+
+// BookmarkPage is one page of bookmarks. NextCursor is empty on the last
+// page.
+type BookmarkPage struct {
+	Bookmarks  []Bookmark `json:"bookmarks"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+func includeQuery(q url.Values, includeAuthor bool) url.Values {
+	if includeAuthor {
+		if q == nil {
+			q = url.Values{}
+		}
+		q.Set("include", "author")
+	}
+	return q
+}
+
+func gobitPath(id uuid.UUID, suffix string) string {
+	return "/api/gobits/" + id.String() + suffix
+}
+
+// CreateGobit posts a gobit, or saves a draft or scheduled one depending on
+// req.Status.
+func (c *Client) CreateGobit(ctx context.Context, req GobitRequest) (*Gobit, error) {
+	var gobit Gobit
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/gobits",
+		body:   req,
+		auth:   authAccess,
+	}, &gobit)
+	if err != nil {
+		return nil, err
+	}
+	return &gobit, nil
+}
+
+// GetGobit returns one gobit.
+func (c *Client) GetGobit(ctx context.Context, id uuid.UUID) (*Gobit, error) {
+	var gobit Gobit
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   gobitPath(id, ""),
+		auth:   authOptional,
+	}, &gobit)
+	if err != nil {
+		return nil, err
+	}
+	return &gobit, nil
+}
+
+// ListGobits lists published gobits, all at once.
+func (c *Client) ListGobits(ctx context.Context, opts ListOptions) ([]Gobit, error) {
+	q := url.Values{}
+	if opts.AuthorID != uuid.Nil {
+		q.Set("author_id", opts.AuthorID.String())
+	}
+	if opts.Newest {
+		q.Set("sort", "desc")
+	}
+	var gobits []Gobit
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/gobits",
+		query:  includeQuery(q, opts.IncludeAuthor),
+		auth:   authOptional,
+	}, &gobits)
+	return gobits, err
+}
+
+// DeleteGobit moves one of the user's gobits to the trash.
+func (c *Client) DeleteGobit(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   gobitPath(id, ""),
+		auth:   authAccess,
+	}, nil)
+}
+
+// RestoreGobit takes a gobit back out of the trash.
+func (c *Client) RestoreGobit(ctx context.Context, id uuid.UUID) (*Gobit, error) {
+	var gobit Gobit
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   gobitPath(id, "/restore"),
+		auth:   authAccess,
+	}, &gobit)
+	if err != nil {
+		return nil, err
+	}
+	return &gobit, nil
+}
+
+// Trash lists the user's deleted gobits that can still be restored.
+func (c *Client) Trash(ctx context.Context) ([]TrashedGobit, error) {
+	var gobits []TrashedGobit
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/gobits/trash",
+		auth:   authAccess,
+	}, &gobits)
+	return gobits, err
+}
+
+// Drafts lists the user's drafts and scheduled gobits.
+func (c *Client) Drafts(ctx context.Context) ([]Gobit, error) {
+	var gobits []Gobit
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/gobits/drafts",
+		auth:   authAccess,
+	}, &gobits)
+	return gobits, err
+}
+
+// PublishGobit publishes a draft now, or schedules it when publishAt is
+// set.
+func (c *Client) PublishGobit(ctx context.Context, id uuid.UUID, publishAt *time.Time) (*Gobit, error) {
+	var gobit Gobit
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   gobitPath(id, "/publish"),
+		body:   map[string]*time.Time{"publish_at": publishAt},
+		auth:   authAccess,
+	}, &gobit)
+	if err != nil {
+		return nil, err
+	}
+	return &gobit, nil
+}
+
+// Thread returns a gobit with its ancestors and replies.
+func (c *Client) Thread(ctx context.Context, id uuid.UUID, includeAuthor bool) (*Thread, error) {
+	var thread Thread
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   gobitPath(id, "/thread"),
+		query:  includeQuery(nil, includeAuthor),
+		auth:   authOptional,
+	}, &thread)
+	if err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// Repost reposts a gobit.
+func (c *Client) Repost(ctx context.Context, id uuid.UUID) (*Gobit, error) {
+	var gobit Gobit
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   gobitPath(id, "/repost"),
+		auth:   authAccess,
+	}, &gobit)
+	if err != nil {
+		return nil, err
+	}
+	return &gobit, nil
+}
+
+// DeleteRepost undoes a repost of the gobit.
+func (c *Client) DeleteRepost(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   gobitPath(id, "/repost"),
+		auth:   authAccess,
+	}, nil)
+}
+
+// React sets the user's reaction to a gobit, such as "like".
+func (c *Client) React(ctx context.Context, id uuid.UUID, kind string) error {
+	return c.do(ctx, request{
+		method: http.MethodPut,
+		path:   gobitPath(id, "/reaction"),
+		body:   map[string]string{"kind": kind},
+		auth:   authAccess,
+	}, nil)
+}
+
+// DeleteReaction removes the user's reaction to a gobit.
+func (c *Client) DeleteReaction(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   gobitPath(id, "/reaction"),
+		auth:   authAccess,
+	}, nil)
+}
+
+// Bookmark bookmarks a gobit.
+func (c *Client) Bookmark(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, request{
+		method: http.MethodPost,
+		path:   gobitPath(id, "/bookmark"),
+		auth:   authAccess,
+	}, nil)
+}
+
+// DeleteBookmark removes a bookmark.
+func (c *Client) DeleteBookmark(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   gobitPath(id, "/bookmark"),
+		auth:   authAccess,
+	}, nil)
+}
+
+// Vote votes in the poll on a gobit and returns the updated poll.
+func (c *Client) Vote(ctx context.Context, id uuid.UUID, optionIDs ...uuid.UUID) (*Poll, error) {
+	var poll Poll
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   gobitPath(id, "/poll/votes"),
+		body:   map[string][]uuid.UUID{"option_ids": optionIDs},
+		auth:   authAccess,
+	}, &poll)
+	if err != nil {
+		return nil, err
+	}
+	return &poll, nil
+}
+
+// Feed returns one page of the home timeline, newest first. See FeedAll to
+// iterate over every page.
+func (c *Client) Feed(ctx context.Context, opts FeedOptions) ([]Gobit, error) {
+	q := url.Values{}
+	if !opts.Before.IsZero() {
+		q.Set("before", opts.Before.Format(time.RFC3339Nano))
+		if opts.BeforeID != uuid.Nil {
+			q.Set("before_id", opts.BeforeID.String())
+		}
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	var gobits []Gobit
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/feed",
+		query:  includeQuery(q, opts.IncludeAuthor),
+		auth:   authAccess,
+	}, &gobits)
+	return gobits, err
+}
+
+// Search returns one page of public gobits matching query, best match
+// first. See SearchAll to iterate over every page.
+func (c *Client) Search(ctx context.Context, query string, opts SearchOptions) ([]Gobit, error) {
+	q := url.Values{"q": {query}}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		q.Set("offset", strconv.Itoa(opts.Offset))
+	}
+	var gobits []Gobit
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/gobits/search",
+		query:  includeQuery(q, opts.IncludeAuthor),
+		auth:   authOptional,
+	}, &gobits)
+	return gobits, err
+}
+
+// Bookmarks returns one page of the user's bookmarks, newest first. See
+// AllBookmarks to iterate over every page.
+func (c *Client) Bookmarks(ctx context.Context, opts PageOptions) (*BookmarkPage, error) {
+	q := url.Values{}
+	if opts.Cursor != "" {
+		q.Set("cursor", opts.Cursor)
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	var page BookmarkPage
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/bookmarks",
+		query:  includeQuery(q, opts.IncludeAuthor),
+		auth:   authAccess,
+	}, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"iter"
+)
+
+// The *All methods iterate over every page of a listing, fetching pages as
+// the loop needs them:
+//
+//	for gobit, err := range c.FeedAll(ctx, client.FeedOptions{}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(gobit.Body)
+//	}
+//
+// An error is yielded once, after which iteration stops. Breaking out of the
+// loop stops fetching.
+
+// FeedAll iterates over the home timeline from opts.Before, or the newest
+// gobit, back to the oldest.
+func (c *Client) FeedAll(ctx context.Context, opts FeedOptions) iter.Seq2[Gobit, error] {
+	return func(yield func(Gobit, error) bool) {
+		for {
+			page, err := c.Feed(ctx, opts)
+			if err != nil {
+				yield(Gobit{}, err)
+				return
+			}
+			for _, gobit := range page {
+				if !yield(gobit, nil) {
+					return
+				}
+			}
+			if lastPage(len(page), opts.Limit) {
+				return
+			}
+			last := page[len(page)-1]
+			if last.PublishedAt == nil {
+				return
+			}
+			opts.Before = *last.PublishedAt
+			opts.BeforeID = last.ID
+		}
+	}
+}
+
+// SearchAll iterates over every gobit matching query, starting at
+// opts.Offset.
+func (c *Client) SearchAll(ctx context.Context, query string, opts SearchOptions) iter.Seq2[Gobit, error] {
+	return func(yield func(Gobit, error) bool) {
+		for {
+			page, err := c.Search(ctx, query, opts)
+			if err != nil {
+				yield(Gobit{}, err)
+				return
+			}
+			for _, gobit := range page {
+				if !yield(gobit, nil) {
+					return
+				}
+			}
+			if lastPage(len(page), opts.Limit) {
+				return
+			}
+			opts.Offset += len(page)
+		}
+	}
+}
+
+// AllBookmarks iterates over the user's bookmarks, newest first.
+func (c *Client) AllBookmarks(ctx context.Context, opts PageOptions) iter.Seq2[Bookmark, error] {
+	return func(yield func(Bookmark, error) bool) {
+		for {
+			page, err := c.Bookmarks(ctx, opts)
+			if err != nil {
+				yield(Bookmark{}, err)
+				return
+			}
+			for _, bookmark := range page.Bookmarks {
+				if !yield(bookmark, nil) {
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				return
+			}
+			opts.Cursor = page.NextCursor
+		}
+	}
+}
+
+// lastPage reports whether a page of n items ends an offset- or
+// time-paginated listing. Without a known page size, only an empty page
+// does.
+func lastPage(n, limit int) bool {
+	return n == 0 || (limit > 0 && n < limit)
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testGobits returns n gobits, newest first. Every pair shares a
+// published_at, so paging by time alone would skip some.
+func testGobits(n int) []Gobit {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	gobits := make([]Gobit, n)
+	for i := range gobits {
+		publishedAt := start.Add(-time.Duration(i/2) * time.Minute)
+		gobits[i] = Gobit{
+			// IDs descend too, matching the server's tie-break
+			ID:          uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012x", 0xffff-i)),
+			Body:        "gobit " + strconv.Itoa(i),
+			PublishedAt: &publishedAt,
+		}
+	}
+	return gobits
+}
+
+// feedServer serves gobits newest first, paged by ?before= and ?before_id=
+// the way GET /api/feed is.
+func feedServer(t *testing.T, gobits []Gobit) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/feed" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+
+		var before time.Time
+		var beforeID uuid.UUID
+		if s := query.Get("before"); s != "" {
+			var err error
+			if before, err = time.Parse(time.RFC3339Nano, s); err != nil {
+				t.Errorf("before = %q: %v", s, err)
+			}
+			if beforeID, err = uuid.Parse(query.Get("before_id")); err != nil {
+				t.Errorf("before without a usable before_id: %v", err)
+			}
+		}
+
+		page := []Gobit{}
+		for _, gobit := range gobits {
+			if limit > 0 && len(page) == limit {
+				break
+			}
+			older := gobit.PublishedAt.Before(before) ||
+				(gobit.PublishedAt.Equal(before) && gobit.ID.String() < beforeID.String())
+			if before.IsZero() || older {
+				page = append(page, gobit)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}))
+}
+
+func TestFeedAllWalksEveryPage(t *testing.T) {
+	tests := []struct {
+		name   string
+		gobits int
+		limit  int
+	}{
+		{name: "pages split a tie", gobits: 7, limit: 3},
+		{name: "last page is full", gobits: 6, limit: 2},
+		{name: "one page", gobits: 2, limit: 5},
+		{name: "empty", gobits: 0, limit: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gobits := testGobits(tt.gobits)
+			ts := feedServer(t, gobits)
+			defer ts.Close()
+
+			c := New(ts.URL)
+			c.SetTokens(Tokens{AccessToken: testToken("access", time.Now().Add(time.Hour))})
+
+			var got []Gobit
+			for gobit, err := range c.FeedAll(context.Background(), FeedOptions{Limit: tt.limit}) {
+				if err != nil {
+					t.Fatalf("FeedAll: %v", err)
+				}
+				got = append(got, gobit)
+			}
+
+			if len(got) != len(gobits) {
+				t.Fatalf("got %d gobits, want %d", len(got), len(gobits))
+			}
+			for i := range gobits {
+				if got[i].ID != gobits[i].ID {
+					t.Errorf("gobit %d = %s, want %s", i, got[i].Body, gobits[i].Body)
+				}
+			}
+		})
+	}
+}
+
+func TestAllBookmarksFollowsCursors(t *testing.T) {
+	pages := map[string]BookmarkPage{
+		"":   {Bookmarks: []Bookmark{{Gobit: Gobit{Body: "a"}}, {Gobit: Gobit{Body: "b"}}}, NextCursor: "c1"},
+		"c1": {Bookmarks: []Bookmark{{Gobit: Gobit{Body: "c"}}}, NextCursor: "c2"},
+		// A page can be empty and still not be the last
+		"c2": {Bookmarks: []Bookmark{}, NextCursor: "c3"},
+		"c3": {Bookmarks: []Bookmark{{Gobit: Gobit{Body: "d"}}}},
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Query().Get("cursor")]
+		if !ok {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer ts.Close()
+
+	c := New(ts.URL)
+	c.SetTokens(Tokens{AccessToken: testToken("access", time.Now().Add(time.Hour))})
+
+	var got string
+	for bookmark, err := range c.AllBookmarks(context.Background(), PageOptions{}) {
+		if err != nil {
+			t.Fatalf("AllBookmarks: %v", err)
+		}
+		got += bookmark.Gobit.Body
+	}
+	if got != "abcd" {
+		t.Errorf("bookmarks = %q, want %q", got, "abcd")
+	}
+}
+
+func TestSearchAllStopsAtError(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("offset") != "" {
+			http.Error(w, "Failed to search gobits", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(testGobits(2))
+	}))
+	defer ts.Close()
+
+	var got, errs int
+	for _, err := range New(ts.URL).SearchAll(context.Background(), "gobit", SearchOptions{Limit: 2}) {
+		if err != nil {
+			errs++
+			continue
+		}
+		got++
+	}
+	if got != 2 || errs != 1 {
+		t.Errorf("got %d gobits and %d errors, want 2 and 1", got, errs)
+	}
+	if requests != 2 {
+		t.Errorf("made %d requests, want 2", requests)
+	}
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Gobit statuses.
+const (
+	StatusPublished = "published"
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+)
+
+// Gobit visibilities.
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityUnlisted  = "unlisted"
+	VisibilityPrivate   = "private"
+)
+
+// User is an account as its owner sees it.
+type User struct {
+	ID            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	IsGohostRed   bool      `json:"is_gohost_red"`
+	// PendingEmail is a new address waiting to be verified. Only UpdateUser
+	// sets it.
+	PendingEmail string `json:"pending_email,omitempty"`
+}
+
+// Profile is a user's public profile.
+type Profile struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	Website     string    `json:"website"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	IsGohostRed bool      `json:"is_gohost_red"`
+}
+
+// Author is the short form of a profile embedded in gobits.
+type Author struct {
+	ID          uuid.UUID `json:"id"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+}
+
+// Gobit is a post.
+type Gobit struct {
+	ID             uuid.UUID        `json:"id"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	Body           string           `json:"body"`
+	UserID         uuid.UUID        `json:"user_id"`
+	Status         string           `json:"status"`
+	Visibility     string           `json:"visibility"`
+	ReplyToID      *uuid.UUID       `json:"reply_to_id,omitempty"`
+	RepostOfID     *uuid.UUID       `json:"repost_of_id,omitempty"`
+	QuoteOfID      *uuid.UUID       `json:"quote_of_id,omitempty"`
+	RepostCount    int64            `json:"repost_count"`
+	Reactions      map[string]int64 `json:"reactions,omitempty"`
+	ViewerReaction string           `json:"viewer_reaction,omitempty"`
+	RepostOf       *Gobit           `json:"repost_of,omitempty"`
+	QuoteOf        *Gobit           `json:"quote_of,omitempty"`
+	PublishAt      *time.Time       `json:"publish_at,omitempty"`
+	PublishedAt    *time.Time       `json:"published_at,omitempty"`
+	PinnedAt       *time.Time       `json:"pinned_at,omitempty"`
+	Author         *Author          `json:"author,omitempty"`
+	Media          []Media          `json:"media,omitempty"`
+	Poll           *Poll            `json:"poll,omitempty"`
+	HiddenAt       *time.Time       `json:"hidden_at,omitempty"`
+	DeletedAt      *time.Time       `json:"deleted_at,omitempty"`
+}
+
+// TrashedGobit is a deleted gobit that can still be restored until PurgeAt.
+type TrashedGobit struct {
+	Gobit
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// Thread is a gobit with the chain of gobits it replies to and its replies.
+type Thread struct {
+	Ancestors []Gobit `json:"ancestors"`
+	Gobit     Gobit   `json:"gobit"`
+	Replies   []Gobit `json:"replies"`
+}
+
+// Media is an uploaded image.
+type Media struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+}
+
+// Poll is a poll attached to a gobit.
+type Poll struct {
+	ID             uuid.UUID    `json:"id"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	Closed         bool         `json:"closed"`
+	MultipleChoice bool         `json:"multiple_choice"`
+	VotersCount    int64        `json:"voters_count"`
+	Options        []PollOption `json:"options"`
+	ViewerVotes    []uuid.UUID  `json:"viewer_votes,omitempty"`
+}
+
+// PollOption is one choice in a poll.
+type PollOption struct {
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+	Votes int64     `json:"votes"`
+}
+
+// Bookmark is a bookmarked gobit.
+type Bookmark struct {
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+	Gobit        Gobit     `json:"gobit"`
+}
+
+// GobitRequest creates a gobit. Only Body is required.
+type GobitRequest struct {
+	Body       string       `json:"body"`
+	MediaIDs   []uuid.UUID  `json:"media_ids,omitempty"`
+	Status     string       `json:"status,omitempty"`
+	PublishAt  *time.Time   `json:"publish_at,omitempty"`
+	Visibility string       `json:"visibility,omitempty"`
+	ReplyToID  *uuid.UUID   `json:"reply_to_id,omitempty"`
+	QuoteOfID  *uuid.UUID   `json:"quote_of_id,omitempty"`
+	Poll       *PollRequest `json:"poll,omitempty"`
+}
+
+// PollRequest attaches a poll to a new gobit.
+type PollRequest struct {
+	Options        []string  `json:"options"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	MultipleChoice bool      `json:"multiple_choice,omitempty"`
+}
+
+// UserUpdate changes an account's email or password. CurrentPassword is
+// required for either.
+type UserUpdate struct {
+	Email           *string `json:"email,omitempty"`
+	Password        *string `json:"password,omitempty"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+// ProfileUpdate replaces the public profile fields.
+type ProfileUpdate struct {
+	Handle      string `json:"handle"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	Website     string `json:"website"`
+}
+
+// WebhookEvent is a payment provider event, such as "user.upgraded".
+type WebhookEvent struct {
+	Event string `json:"event"`
+	Data  struct {
+		UserID uuid.UUID `json:"user_id"`
+	} `json:"data"`
+}
+
+// Session is one signed-in session of the user.
+type Session struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Current marks the session the client's access token belongs to.
+	Current bool `json:"current"`
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// CreateUser signs up a new account. The server emails a verification
+// token to the address.
+func (c *Client) CreateUser(ctx context.Context, email, password string) (*User, error) {
+	var user User
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   map[string]string{"email": email, "password": password},
+	}, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// VerifyEmail confirms an email address with the token sent to it.
+func (c *Client) VerifyEmail(ctx context.Context, token string) (*User, error) {
+	var user User
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/users/verify",
+		body:   map[string]string{"token": token},
+	}, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser changes the logged-in account's email or password. A new email
+// is only used once it has been verified with VerifyEmail. Changing the
+// password ends every session, including this one, and starts a new session
+// that the client switches to.
+func (c *Client) UpdateUser(ctx context.Context, update UserUpdate) (*User, error) {
+	var resp struct {
+		User
+		RefreshToken string `json:"refresh_token"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPatch,
+		path:   "/api/users",
+		body:   update,
+		auth:   authAccess,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.RefreshToken != "" {
+		// The access token belongs to the revoked session; the next call
+		// refreshes it from the new one.
+		c.replaceTokens(Tokens{RefreshToken: resp.RefreshToken})
+	}
+	return &resp.User, nil
+}
+
+// DeleteAccount deactivates the logged-in account and schedules it for
+// deletion, returning when that will happen. Every session of the account
+// ends, so the client forgets its tokens.
+func (c *Client) DeleteAccount(ctx context.Context, currentPassword string) (time.Time, error) {
+	var resp struct {
+		DeletionScheduledFor time.Time `json:"deletion_scheduled_for"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   "/api/users",
+		body:   map[string]string{"current_password": currentPassword},
+		auth:   authAccess,
+	}, &resp)
+	if err != nil {
+		return time.Time{}, err
+	}
+	c.replaceTokens(Tokens{})
+	return resp.DeletionScheduledFor, nil
+}
+
+// GetProfile returns the public profile of the user with the given handle.
+func (c *Client) GetProfile(ctx context.Context, handle string) (*Profile, error) {
+	var profile Profile
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/users/" + url.PathEscape(handle),
+	}, &profile)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// UpdateProfile replaces the logged-in user's public profile.
+func (c *Client) UpdateProfile(ctx context.Context, update ProfileUpdate) (*Profile, error) {
+	var profile Profile
+	err := c.do(ctx, request{
+		method: http.MethodPut,
+		path:   "/api/users/profile",
+		body:   update,
+		auth:   authAccess,
+	}, &profile)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
@@ -0,0 +1,18 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// SendWebhook delivers a payment provider event to Gohost, authenticated
+// with WebhookKey. Events other than "user.upgraded" are accepted and
+// ignored.
+func (c *Client) SendWebhook(ctx context.Context, event WebhookEvent) error {
+	return c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/strip/webhooks",
+		body:   event,
+		auth:   authWebhook,
+	}, nil)
+}
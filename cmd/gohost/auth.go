@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/twomotive/gohost/client"
+)
+
+func runLogin(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("[-email address] [-password-stdin]")
+	email := fs.String("email", "", "account email (default: the last one used, or prompt)")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from the first line of stdin")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	if *email == "" {
+		*email = a.config.Email
+	}
+	if *email == "" {
+		line, err := prompt(stdin, "Email: ")
+		if err != nil {
+			return err
+		}
+		*email = line
+	}
+
+	// There is no portable way to turn off echo without another dependency,
+	// so scripts should pass the password on stdin or in GOHOST_PASSWORD.
+	password := os.Getenv("GOHOST_PASSWORD")
+	if *passwordStdin || password == "" {
+		message := "Password: "
+		if *passwordStdin {
+			message = ""
+		}
+		line, err := prompt(stdin, message)
+		if err != nil {
+			return err
+		}
+		password = line
+	}
+
+	user, err := a.client.Login(ctx, *email, password)
+	if err != nil {
+		return err
+	}
+	a.config.Email = user.Email
+	if err := a.config.save(a.configPath); err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+
+	t := &table{header: []string{"ID", "EMAIL", "VERIFIED", "GOHOST RED"}}
+	t.add(user.ID.String(), user.Email, yesNo(user.EmailVerified), yesNo(user.IsGohostRed))
+	return a.print(user, t)
+}
+
+func prompt(r *bufio.Reader, message string) (string, error) {
+	if message != "" {
+		fmt.Fprint(os.Stderr, message)
+	}
+	line, err := r.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("reading %s: %w", strings.TrimSuffix(strings.ToLower(message), ": "), err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func runLogout(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if a.client.Tokens().RefreshToken == "" {
+		return client.ErrNotLoggedIn
+	}
+	return a.client.Revoke(ctx)
+}
+
+func runSessionsList(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	sessions, err := a.client.Sessions(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"ID", "CREATED", "EXPIRES", "CURRENT"}}
+	for _, s := range sessions {
+		current := ""
+		if s.Current {
+			current = "*"
+		}
+		t.add(s.ID, formatTime(&s.CreatedAt), formatTime(&s.ExpiresAt), current)
+	}
+	return a.print(sessions, t)
+}
+
+func runSessionsRevoke(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("id...")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if err := a.needArgs(fs, 1); err != nil {
+		return err
+	}
+	for _, id := range fs.Args() {
+		if err := a.client.RevokeSession(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runTokenPrint(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	token, err := a.client.AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	return printToken(a, token)
+}
+
+func runTokenRefresh(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if err := a.client.Refresh(ctx); err != nil {
+		return err
+	}
+	return printToken(a, a.client.Tokens().AccessToken)
+}
+
+func printToken(a *app, token string) error {
+	if a.output == outputJSON {
+		return a.print(map[string]string{"access_token": token}, nil)
+	}
+	fmt.Println(token)
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
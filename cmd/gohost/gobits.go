@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/client"
+)
+
+func runGobitsPost(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("[flags] text... (or - to read the body from stdin)")
+	visibility := fs.String("visibility", "", "public, unlisted, followers or private (default public)")
+	replyTo := fs.String("reply-to", "", "`id` of the gobit to reply to")
+	quote := fs.String("quote", "", "`id` of the gobit to quote")
+	draft := fs.Bool("draft", false, "save as a draft instead of publishing")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if err := a.needArgs(fs, 1); err != nil {
+		return err
+	}
+
+	body := strings.Join(fs.Args(), " ")
+	if body == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("reading body: %w", err)
+		}
+		body = strings.TrimRight(string(data), "\n")
+	}
+
+	req := client.GobitRequest{Body: body, Visibility: *visibility}
+	if *draft {
+		req.Status = client.StatusDraft
+	}
+	var err error
+	if req.ReplyToID, err = optionalID("reply-to", *replyTo); err != nil {
+		return err
+	}
+	if req.QuoteOfID, err = optionalID("quote", *quote); err != nil {
+		return err
+	}
+
+	gobit, err := a.client.CreateGobit(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printGobits(gobit, []client.Gobit{*gobit})
+}
+
+func runGobitsList(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("[flags]")
+	author := fs.String("author", "", "only gobits by this user `id` or @handle")
+	newest := fs.Bool("newest", false, "newest first (with -author or no filter)")
+	feed := fs.Bool("feed", false, "your home feed of people you follow")
+	search := fs.String("search", "", "public gobits matching `query`")
+	limit := fs.Int("limit", 20, "maximum number of gobits, 0 for all")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if *feed && *search != "" {
+		fmt.Fprintf(os.Stderr, "%s: -feed and -search can't be combined\n", a.name)
+		return errUsage
+	}
+
+	var gobits []client.Gobit
+	switch {
+	case *feed:
+		for gobit, err := range a.client.FeedAll(ctx, client.FeedOptions{Limit: pageSize(*limit), IncludeAuthor: true}) {
+			if err != nil {
+				return err
+			}
+			if gobits = append(gobits, gobit); len(gobits) == *limit {
+				break
+			}
+		}
+	case *search != "":
+		for gobit, err := range a.client.SearchAll(ctx, *search, client.SearchOptions{Limit: pageSize(*limit), IncludeAuthor: true}) {
+			if err != nil {
+				return err
+			}
+			if gobits = append(gobits, gobit); len(gobits) == *limit {
+				break
+			}
+		}
+	default:
+		opts := client.ListOptions{Newest: *newest, IncludeAuthor: true}
+		if *author != "" {
+			id, err := a.resolveUser(ctx, *author)
+			if err != nil {
+				return err
+			}
+			opts.AuthorID = id
+		}
+		var err error
+		if gobits, err = a.client.ListGobits(ctx, opts); err != nil {
+			return err
+		}
+		if *limit > 0 && len(gobits) > *limit {
+			gobits = gobits[:*limit]
+		}
+	}
+
+	if gobits == nil {
+		gobits = []client.Gobit{}
+	}
+	return a.printGobits(gobits, gobits)
+}
+
+func runGobitsGet(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("id")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if err := a.needArgs(fs, 1); err != nil {
+		return err
+	}
+	id, err := parseID("gobit", fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	gobit, err := a.client.GetGobit(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.printGobits(gobit, []client.Gobit{*gobit})
+}
+
+func runGobitsDelete(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("id...")
+	if err := a.parse(fs, args); err != nil {
+		return err
+	}
+	if err := a.needArgs(fs, 1); err != nil {
+		return err
+	}
+	for _, arg := range fs.Args() {
+		id, err := parseID("gobit", arg)
+		if err != nil {
+			return err
+		}
+		if err := a.client.DeleteGobit(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printGobits prints v as JSON, or gobits as a table.
+func (a *app) printGobits(v any, gobits []client.Gobit) error {
+	t := &table{header: []string{"ID", "PUBLISHED", "AUTHOR", "STATUS", "BODY"}}
+	for _, gobit := range gobits {
+		author := gobit.UserID.String()
+		if gobit.Author != nil && gobit.Author.Handle != "" {
+			author = "@" + gobit.Author.Handle
+		}
+		body := gobit.Body
+		if gobit.RepostOf != nil {
+			body = "↻ " + gobit.RepostOf.Body
+		}
+		t.add(gobit.ID.String(), formatTime(gobit.PublishedAt), author, gobit.Status, oneLine(body, bodyWidth))
+	}
+	return a.print(v, t)
+}
+
+// resolveUser accepts a user ID or an @handle.
+func (a *app) resolveUser(ctx context.Context, user string) (uuid.UUID, error) {
+	if id, err := uuid.Parse(user); err == nil {
+		return id, nil
+	}
+	profile, err := a.client.GetProfile(ctx, strings.TrimPrefix(user, "@"))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return profile.ID, nil
+}
+
+// pageSize asks for pages no bigger than needed, within the server's
+// maximum of 100.
+func pageSize(limit int) int {
+	if limit <= 0 || limit > 100 {
+		return 100
+	}
+	return limit
+}
+
+func parseID(what, s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid %s id %q", what, s)
+	}
+	return id, nil
+}
+
+func optionalID(flagName, s string) (*uuid.UUID, error) {
+	if s == "" {
+		return nil, nil
+	}
+	id, err := parseID(flagName, s)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
@@ -0,0 +1,187 @@
+// Command gohost is a command-line client for the Gohost API.
+//
+// Usage:
+//
+//	gohost login [-email address] [-password-stdin]
+//	gohost logout
+//	gohost gobits post [-visibility v] [-reply-to id] [-quote id] [-draft] text...
+//	gohost gobits list [-author id|@handle] [-newest] [-feed] [-search query] [-limit n]
+//	gohost gobits get id
+//	gohost gobits delete id...
+//	gohost sessions list
+//	gohost sessions revoke id...
+//	gohost token print
+//	gohost token refresh
+//
+// Every command accepts -server, -config and -o (table or json). The
+// session's tokens are kept in the config file, by default
+// gohost/config.json under the user's config directory, and are refreshed
+// as needed.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/twomotive/gohost/client"
+)
+
+// errUsage reports a bad command line; the command has already printed why.
+var errUsage = errors.New("usage")
+
+// command is one subcommand. run gets the arguments after its name.
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, app *app, args []string) error
+	sub     []command
+}
+
+var commands = []command{
+	{name: "login", summary: "log in and save the session", run: runLogin},
+	{name: "logout", summary: "end the session and forget its tokens", run: runLogout},
+	{name: "gobits", summary: "post, list and delete gobits", sub: []command{
+		{name: "post", summary: "post a gobit", run: runGobitsPost},
+		{name: "list", summary: "list gobits, your feed or search results", run: runGobitsList},
+		{name: "get", summary: "show one gobit", run: runGobitsGet},
+		{name: "delete", summary: "move gobits to the trash", run: runGobitsDelete},
+	}},
+	{name: "sessions", summary: "list and sign out your sessions", sub: []command{
+		{name: "list", summary: "list signed-in sessions", run: runSessionsList},
+		{name: "revoke", summary: "sign sessions out", run: runSessionsRevoke},
+	}},
+	{name: "token", summary: "print or refresh the access token", sub: []command{
+		{name: "print", summary: "print a valid access token, for use with curl", run: runTokenPrint},
+		{name: "refresh", summary: "get a new access token now", run: runTokenRefresh},
+	}},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := dispatch(ctx, commands, os.Args[1:], "gohost")
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+	// A failed login is a wrong password, not an expired session
+	if errors.Is(err, client.ErrNotLoggedIn) || (errors.Is(err, client.ErrUnauthorized) && os.Args[1] != "login") {
+		err = fmt.Errorf("%w (run 'gohost login')", err)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func dispatch(ctx context.Context, cmds []command, args []string, path string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" {
+		printUsage(os.Stderr, cmds, path)
+		return errUsage
+	}
+	for _, cmd := range cmds {
+		if cmd.name != args[0] {
+			continue
+		}
+		if cmd.sub != nil {
+			return dispatch(ctx, cmd.sub, args[1:], path+" "+cmd.name)
+		}
+		return cmd.run(ctx, &app{name: path + " " + cmd.name}, args[1:])
+	}
+	fmt.Fprintf(os.Stderr, "%s: unknown command %q\n", path, args[0])
+	printUsage(os.Stderr, cmds, path)
+	return errUsage
+}
+
+func printUsage(w io.Writer, cmds []command, path string) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", path)
+	for _, cmd := range cmds {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for a command's flags.\n", path)
+}
+
+// app is the state shared by a command: its flags, the config file and a
+// client for the server.
+type app struct {
+	name       string
+	server     string
+	configPath string
+	output     string
+
+	config *config
+	client *client.Client
+}
+
+// flags returns a flag set for the command with the flags every command
+// accepts.
+func (a *app) flags(usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(a.name, flag.ContinueOnError)
+	fs.StringVar(&a.server, "server", os.Getenv("GOHOST_URL"), "server `URL` (default from the config file, or "+defaultServer+")")
+	fs.StringVar(&a.configPath, "config", os.Getenv("GOHOST_CONFIG"), "config file `path`")
+	fs.StringVar(&a.output, "o", outputTable, "output format: table or json")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s\n\nFlags:\n", a.name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args and loads the config and client.
+func (a *app) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if a.output != outputTable && a.output != outputJSON {
+		fmt.Fprintf(os.Stderr, "%s: -o must be table or json\n", a.name)
+		return errUsage
+	}
+
+	if a.configPath == "" {
+		path, err := defaultConfigPath()
+		if err != nil {
+			return err
+		}
+		a.configPath = path
+	}
+	cfg, err := loadConfig(a.configPath)
+	if err != nil {
+		return err
+	}
+	a.config = cfg
+
+	if a.server == "" {
+		a.server = cfg.Server
+	}
+	if a.server == "" {
+		a.server = defaultServer
+	}
+
+	a.client = client.New(a.server)
+	// Tokens belong to the server they were issued by
+	if cfg.Server == "" || cfg.Server == a.client.BaseURL {
+		a.client.SetTokens(cfg.Tokens)
+	}
+	a.client.OnTokens = func(t client.Tokens) {
+		a.config.Server = a.client.BaseURL
+		a.config.Tokens = t
+		if err := a.config.save(a.configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: saving session: %v\n", a.name, err)
+		}
+	}
+	return nil
+}
+
+// needArgs checks the number of positional arguments.
+func (a *app) needArgs(fs *flag.FlagSet, min int) error {
+	if fs.NArg() < min {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
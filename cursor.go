@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor builds the opaque cursor handed to clients for keyset
+// pagination over rows ordered by time, with the ID breaking ties.
+func encodeCursor(at time.Time, id uuid.UUID) string {
+	raw := at.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor reverses encodeCursor.
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errInvalidCursor
+	}
+
+	atStr, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, errInvalidCursor
+	}
+	at, err := time.Parse(time.RFC3339Nano, atStr)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errInvalidCursor
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errInvalidCursor
+	}
+	return at, id, nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	gobitDraft     = "draft"
+	gobitScheduled = "scheduled"
+	gobitPublished = "published"
+
+	schedulerInterval  = 15 * time.Second
+	schedulerBatchSize = 100
+)
+
+type publishRequest struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// gobitSchedule works out the status and publish time a gobit is being
+// created or published with. Leaving status out publishes now, or schedules
+// the gobit if publish_at is given.
+func gobitSchedule(status string, publishAt *time.Time) (string, sql.NullTime, []violation) {
+	if status == "" {
+		status = gobitPublished
+		if publishAt != nil {
+			status = gobitScheduled
+		}
+	}
+
+	switch status {
+	case gobitScheduled:
+		if publishAt == nil || !publishAt.After(time.Now()) {
+			return "", sql.NullTime{}, []violation{{
+				Field:   "publish_at",
+				Code:    "invalid",
+				Message: "publish_at must be a time in the future",
+			}}
+		}
+		return status, sql.NullTime{Time: *publishAt, Valid: true}, nil
+	case gobitDraft, gobitPublished:
+		if publishAt != nil {
+			return "", sql.NullTime{}, []violation{{
+				Field:   "publish_at",
+				Code:    "invalid",
+				Message: "publish_at only applies to scheduled gobits",
+			}}
+		}
+		return status, sql.NullTime{}, nil
+	default:
+		return "", sql.NullTime{}, []violation{{
+			Field:   "status",
+			Code:    "invalid",
+			Message: "status must be one of draft, scheduled or published",
+		}}
+	}
+}
+
+// listDrafts returns the caller's drafts and scheduled gobits, soonest first.
+func (cfg *apiConfig) listDrafts(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for draft listing: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for draft listing: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	gobits, err := cfg.db.ListUnpublishedGobits(r.Context(), userID)
+	if err != nil {
+		log.Printf("cannot list drafts for user %s: %v", userID, err)
+		http.Error(w, "Failed to list drafts", http.StatusInternalServerError)
+		return
+	}
+
+	responseGobits, err := cfg.gobitResponses(r, gobits)
+	if err != nil {
+		log.Printf("cannot build drafts response for user %s: %v", userID, err)
+		http.Error(w, "Failed to list drafts", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(responseGobits)
+	if err != nil {
+		log.Printf("Error marshalling drafts response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// publishGobit publishes a draft or scheduled gobit now, or (re)schedules it
+// when the body gives a publish_at.
+func (cfg *apiConfig) publishGobit(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for publish: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for publish: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	gobitID, err := uuid.Parse(r.PathValue("gobitID"))
+	if err != nil {
+		http.Error(w, "Invalid gobit ID format", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional; without one the gobit goes out immediately
+	var req publishRequest
+	if r.ContentLength != 0 {
+		if r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Printf("JSON publish decode error: %v", err)
+			http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	author, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting author %s for publish: %v", userID, err)
+		http.Error(w, "Unauthorized: Unknown user", http.StatusUnauthorized)
+		return
+	}
+	if message, locked := accountLockout(author); locked {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
+
+	status, publishAt, violations := gobitSchedule("", req.PublishAt)
+	if len(violations) > 0 {
+		respondWithViolations(w, violations)
+		return
+	}
+
+	gobit, err := cfg.db.PublishGobit(r.Context(), database.PublishGobitParams{
+		ID:        gobitID,
+		UserID:    userID,
+		Status:    status,
+		PublishAt: publishAt,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "draft not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error publishing gobit %s: %v", gobitID, err)
+			http.Error(w, "Failed to publish gobit", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if gobit.Status == gobitPublished {
+		cfg.afterPublish(r.Context(), gobit)
+	}
+
+	data, err := json.Marshal(toCreatedGobit(gobit))
+	if err != nil {
+		log.Printf("Error marshalling publish response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// afterPublish runs once a gobit goes out, whether straight away, on demand
+// or from the schedule. Failures are logged; the gobit stays published.
+func (cfg *apiConfig) afterPublish(ctx context.Context, gobit database.Gobit) {
+	if err := cfg.notifyPublished(ctx, gobit); err != nil {
+		log.Printf("Error sending notifications for gobit %s: %v", gobit.ID, err)
+	}
+	if err := cfg.publishGobitEvent(ctx, eventGobitCreated, gobit); err != nil {
+		log.Printf("Error streaming gobit %s: %v", gobit.ID, err)
+	}
+	if err := cfg.federatePublished(ctx, gobit); err != nil {
+		log.Printf("Error federating gobit %s: %v", gobit.ID, err)
+	}
+}
+
+// publishDueGobits publishes every scheduled gobit whose time has come. It
+// runs on every instance; PublishDueGobits hands each gobit to only one.
+func (cfg *apiConfig) publishDueGobits(ctx context.Context) error {
+	for {
+		gobits, err := cfg.db.PublishDueGobits(ctx, schedulerBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, gobit := range gobits {
+			log.Printf("Published scheduled gobit %s", gobit.ID)
+			cfg.afterPublish(ctx, gobit)
+		}
+		if len(gobits) < schedulerBatchSize {
+			return nil
+		}
+	}
+}
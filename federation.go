@@ -0,0 +1,401 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/activitypub"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	defaultRemoteTimelineLimit = 20
+	maxRemoteTimelineLimit     = 100
+)
+
+type remoteFollowRequest struct {
+	// Account is user@host, or the URL of the actor
+	Account string `json:"account"`
+}
+
+// remoteActorSummary is a remote account as embedded in responses.
+type remoteActorSummary struct {
+	ID          uuid.UUID `json:"id"`
+	URI         string    `json:"uri"`
+	Handle      string    `json:"handle"`
+	DisplayName string    `json:"display_name"`
+}
+
+type remoteFollowResponse struct {
+	Actor     remoteActorSummary `json:"actor"`
+	CreatedAt time.Time          `json:"created_at"`
+	// Accepted is false until the remote server confirms the follow
+	Accepted bool `json:"accepted"`
+}
+
+type remoteNoteResponse struct {
+	ID          uuid.UUID          `json:"id"`
+	URI         string             `json:"uri"`
+	URL         string             `json:"url"`
+	Content     string             `json:"content"`
+	InReplyToID *uuid.UUID         `json:"in_reply_to_id,omitempty"`
+	PublishedAt time.Time          `json:"published_at"`
+	Author      remoteActorSummary `json:"author"`
+}
+
+type remoteTimelinePage struct {
+	Notes []remoteNoteResponse `json:"notes"`
+	// NextCursor is passed back as ?cursor= for the next page; it is left
+	// out on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// followRemote follows an account on another server. The follow stays
+// pending until that server accepts it.
+func (cfg *apiConfig) followRemote(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for remote follow: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for remote follow: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	if !cfg.federationEnabled() {
+		http.Error(w, "Federation is not enabled on this server", http.StatusNotFound)
+		return
+	}
+
+	user, ok, err := cfg.federatedUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting user %s for remote follow: %v", userID, err)
+		http.Error(w, "Failed to follow account", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "Set a handle before following accounts on other servers", http.StatusForbidden)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req remoteFollowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Account = strings.TrimSpace(req.Account)
+	if req.Account == "" {
+		respondWithViolations(w, []violation{{
+			Field:   "account",
+			Code:    "required",
+			Message: "account is required",
+		}})
+		return
+	}
+
+	actorURL := req.Account
+	if !strings.HasPrefix(actorURL, "https://") && !strings.HasPrefix(actorURL, "http://") {
+		// Other servers are reached the same way clients reach this one, so
+		// two local instances can federate over plain http
+		public, _ := url.Parse(cfg.publicURL)
+		actorURL, err = activitypub.LookupWebFinger(r.Context(), public.Scheme, req.Account)
+		if err != nil {
+			log.Printf("Error looking up %s: %v", req.Account, err)
+			http.Error(w, "Could not find that account", http.StatusUnprocessableEntity)
+			return
+		}
+	}
+	if strings.HasPrefix(actorURL, cfg.publicURL+"/") {
+		http.Error(w, "That account is on this server; follow it with /api/follows", http.StatusBadRequest)
+		return
+	}
+
+	actor, err := cfg.fetchRemoteActor(r.Context(), actorURL)
+	if err != nil {
+		log.Printf("Error fetching remote actor %s: %v", actorURL, err)
+		http.Error(w, "Could not find that account", http.StatusUnprocessableEntity)
+		return
+	}
+
+	local := cfg.actorURL(user.ID)
+	following, err := cfg.db.CreateRemoteFollowing(r.Context(), database.CreateRemoteFollowingParams{
+		UserID:      user.ID,
+		ActorID:     actor.ID,
+		ActivityUri: local + "#follows/" + uuid.NewString(),
+	})
+	if err != nil {
+		log.Printf("cannot follow remote actor %s for %s: %v", actor.Uri, user.ID, err)
+		http.Error(w, "Failed to follow account", http.StatusInternalServerError)
+		return
+	}
+
+	// Following again while still pending sends the same Follow again
+	if !following.AcceptedAt.Valid {
+		object, _ := json.Marshal(actor.Uri)
+		err = cfg.deliver(r.Context(), user.ID, actor.InboxUrl, activitypub.Activity{
+			ID:     following.ActivityUri,
+			Type:   "Follow",
+			Actor:  local,
+			Object: object,
+		})
+		if err != nil {
+			log.Printf("Error queueing follow of %s by %s: %v", actor.Uri, user.ID, err)
+			http.Error(w, "Failed to follow account", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	data, err := json.Marshal(remoteFollowResponse{
+		Actor:     toRemoteActorSummary(actor),
+		CreatedAt: following.CreatedAt,
+		Accepted:  following.AcceptedAt.Valid,
+	})
+	if err != nil {
+		log.Printf("Error marshalling remote follow response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(data)
+}
+
+// unfollowRemote stops following a remote account and tells its server.
+func (cfg *apiConfig) unfollowRemote(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for remote unfollow: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for remote unfollow: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	actorID, err := uuid.Parse(r.PathValue("actorID"))
+	if err != nil {
+		http.Error(w, "Invalid actor ID format", http.StatusBadRequest)
+		return
+	}
+
+	following, err := cfg.db.DeleteRemoteFollowing(r.Context(), database.DeleteRemoteFollowingParams{
+		UserID:  userID,
+		ActorID: actorID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "follow not found", http.StatusNotFound)
+		} else {
+			log.Printf("cannot unfollow remote actor %s for %s: %v", actorID, userID, err)
+			http.Error(w, "Failed to unfollow account", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	actor, err := cfg.db.GetRemoteActor(r.Context(), actorID)
+	if err != nil {
+		log.Printf("Error getting remote actor %s: %v", actorID, err)
+		http.Error(w, "Failed to unfollow account", http.StatusInternalServerError)
+		return
+	}
+
+	local := cfg.actorURL(userID)
+	object, _ := json.Marshal(actor.Uri)
+	follow, err := json.Marshal(activitypub.Activity{
+		ID:     following.ActivityUri,
+		Type:   "Follow",
+		Actor:  local,
+		Object: object,
+	})
+	if err == nil {
+		err = cfg.deliver(r.Context(), userID, actor.InboxUrl, activitypub.Activity{
+			ID:     local + "#undo/" + uuid.NewString(),
+			Type:   "Undo",
+			Actor:  local,
+			Object: follow,
+		})
+	}
+	if err != nil {
+		log.Printf("Error queueing unfollow of %s by %s: %v", actor.Uri, userID, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listRemoteFollowing returns the remote accounts the caller follows,
+// including follows still waiting to be accepted.
+func (cfg *apiConfig) listRemoteFollowing(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for remote following list: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for remote following list: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	rows, err := cfg.db.ListRemoteFollowing(r.Context(), userID)
+	if err != nil {
+		log.Printf("cannot list remote follows for %s: %v", userID, err)
+		http.Error(w, "Failed to list follows", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]remoteFollowResponse, 0, len(rows))
+	for _, row := range rows {
+		response = append(response, remoteFollowResponse{
+			Actor: remoteActorSummary{
+				ID:          row.ID,
+				URI:         row.Uri,
+				Handle:      row.Handle,
+				DisplayName: row.DisplayName,
+			},
+			CreatedAt: row.CreatedAt,
+			Accepted:  row.AcceptedAt.Valid,
+		})
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("Error marshalling remote follows response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// getRemoteTimeline pages through the notes of the remote accounts the
+// caller follows, newest first.
+func (cfg *apiConfig) getRemoteTimeline(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for remote timeline: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for remote timeline: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	params := database.ListRemoteTimelineParams{
+		UserID: userID,
+		Limit:  defaultRemoteTimelineLimit,
+	}
+	query := r.URL.Query()
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxRemoteTimelineLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		params.Limit = int32(limit)
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		publishedAt, noteID, err := decodeCursor(cursor)
+		if err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		params.BeforePublishedAt = sql.NullTime{Time: publishedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: noteID, Valid: true}
+	}
+
+	// Fetch one extra to find out whether there is another page
+	pageSize := params.Limit
+	params.Limit++
+	notes, err := cfg.db.ListRemoteTimeline(r.Context(), params)
+	if err != nil {
+		log.Printf("cannot list remote timeline for %s: %v", userID, err)
+		http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
+		return
+	}
+
+	page := remoteTimelinePage{Notes: []remoteNoteResponse{}}
+	if len(notes) > int(pageSize) {
+		notes = notes[:pageSize]
+		last := notes[len(notes)-1]
+		page.NextCursor = encodeCursor(last.PublishedAt, last.ID)
+	}
+	for _, note := range notes {
+		response := remoteNoteResponse{
+			ID:          note.ID,
+			URI:         note.Uri,
+			URL:         note.Url,
+			Content:     note.Content,
+			PublishedAt: note.PublishedAt,
+			Author: remoteActorSummary{
+				ID:          note.ActorID,
+				URI:         note.ActorUri,
+				Handle:      note.ActorHandle,
+				DisplayName: note.ActorDisplayName,
+			},
+		}
+		if note.InReplyToID.Valid {
+			response.InReplyToID = &note.InReplyToID.UUID
+		}
+		page.Notes = append(page.Notes, response)
+	}
+
+	data, err := json.Marshal(page)
+	if err != nil {
+		log.Printf("Error marshalling remote timeline response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func toRemoteActorSummary(actor database.RemoteActor) remoteActorSummary {
+	return remoteActorSummary{
+		ID:          actor.ID,
+		URI:         actor.Uri,
+		Handle:      actor.Handle,
+		DisplayName: actor.DisplayName,
+	}
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	feedRSS  = "rss"
+	feedAtom = "atom"
+	feedJSON = "json"
+
+	// feedItemLimit is how many of the newest gobits a feed carries
+	feedItemLimit = 20
+	// Item titles are the start of the gobit's body
+	feedTitleLength = 80
+)
+
+// feedContentTypes maps each format to the media type it is served as.
+var feedContentTypes = map[string]string{
+	feedRSS:  "application/rss+xml; charset=utf-8",
+	feedAtom: "application/atom+xml; charset=utf-8",
+	feedJSON: "application/feed+json; charset=utf-8",
+}
+
+// feedMediaTypes maps the media types a client may ask for to a format.
+var feedMediaTypes = map[string]string{
+	"application/rss+xml":   feedRSS,
+	"application/xml":       feedRSS,
+	"text/xml":              feedRSS,
+	"application/atom+xml":  feedAtom,
+	"application/feed+json": feedJSON,
+	"application/json":      feedJSON,
+}
+
+// feed is a format-neutral feed, rendered by renderFeed.
+type feed struct {
+	Title       string
+	Description string
+	HomeURL     string
+	FeedURL     string
+	Updated     time.Time
+	Items       []feedItem
+}
+
+type feedItem struct {
+	ID        uuid.UUID
+	URL       string
+	Title     string
+	Content   string
+	Author    string
+	Published time.Time
+	Updated   time.Time
+}
+
+// negotiateFeedFormat picks a format from ?format= or, failing that, the
+// Accept header, defaulting to RSS. ok is false if the client accepts none
+// of them.
+func negotiateFeedFormat(r *http.Request) (string, bool) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		_, ok := feedContentTypes[format]
+		return format, ok
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return feedRSS, true
+	}
+
+	type candidate struct {
+		format string
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qs, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		format, ok := feedMediaTypes[mediaType]
+		if !ok && (mediaType == "*/*" || mediaType == "application/*") {
+			format, ok = feedRSS, true
+		}
+		if ok {
+			candidates = append(candidates, candidate{format: format, q: q})
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	// Highest quality first; ties go to the type listed first
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].format, true
+}
+
+// baseURL is the scheme and host clients reach the server on, from
+// PUBLIC_URL when it is set.
+func (cfg *apiConfig) baseURL(r *http.Request) string {
+	if cfg.publicURL != "" {
+		return cfg.publicURL
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// feedItemTitle shortens a gobit's body to a title.
+func feedItemTitle(body string) string {
+	title := strings.Join(strings.Fields(body), " ")
+	if utf8.RuneCountInString(title) <= feedTitleLength {
+		return title
+	}
+	runes := []rune(title)
+	return strings.TrimSpace(string(runes[:feedTitleLength-1])) + "…"
+}
+
+// authorName is how an author is credited in a feed.
+func authorName(author authorSummary) string {
+	if author.DisplayName != "" {
+		return author.DisplayName
+	}
+	if author.Handle != "" {
+		return "@" + author.Handle
+	}
+	return author.ID.String()
+}
+
+// buildFeed turns gobits into a feed, crediting each to its author.
+func (cfg *apiConfig) buildFeed(r *http.Request, title, description, homeURL string, gobits []database.Gobit) (feed, error) {
+	base := cfg.baseURL(r)
+	result := feed{
+		Title:       title,
+		Description: description,
+		HomeURL:     homeURL,
+		FeedURL:     base + r.URL.Path,
+		Items:       []feedItem{},
+	}
+	if len(gobits) == 0 {
+		return result, nil
+	}
+
+	authors, err := cfg.gobitAuthors(r.Context(), gobits)
+	if err != nil {
+		return feed{}, err
+	}
+
+	for _, gobit := range gobits {
+		published := gobit.CreatedAt
+		if gobit.PublishedAt.Valid {
+			published = gobit.PublishedAt.Time
+		}
+		item := feedItem{
+			ID:        gobit.ID,
+			URL:       base + "/api/gobits/" + gobit.ID.String(),
+			Title:     feedItemTitle(gobit.Body),
+			Content:   gobit.Body,
+			Author:    authorName(authors[gobit.UserID]),
+			Published: published.UTC(),
+			Updated:   gobit.UpdatedAt.UTC(),
+		}
+		if item.Updated.After(result.Updated) {
+			result.Updated = item.Updated
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	Author      string  `xml:"dc:creator"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomDocument struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Link      atomLink    `xml:"link"`
+	Author    atomAuthor  `xml:"author"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type jsonFeedDocument struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// renderFeed encodes the feed in the given format.
+func renderFeed(f feed, format string) ([]byte, error) {
+	switch format {
+	case feedAtom:
+		doc := atomDocument{
+			ID:      f.FeedURL,
+			Title:   f.Title,
+			Updated: f.Updated.Format(time.RFC3339),
+			Links: []atomLink{
+				{Rel: "self", Href: f.FeedURL},
+				{Rel: "alternate", Href: f.HomeURL},
+			},
+		}
+		for _, item := range f.Items {
+			doc.Entries = append(doc.Entries, atomEntry{
+				ID:        "urn:uuid:" + item.ID.String(),
+				Title:     item.Title,
+				Updated:   item.Updated.Format(time.RFC3339),
+				Published: item.Published.Format(time.RFC3339),
+				Link:      atomLink{Rel: "alternate", Href: item.URL},
+				Author:    atomAuthor{Name: item.Author},
+				Content:   atomContent{Type: "text", Value: item.Content},
+			})
+		}
+		return marshalXMLDocument(doc)
+
+	case feedJSON:
+		doc := jsonFeedDocument{
+			Version:     "https://jsonfeed.org/version/1.1",
+			Title:       f.Title,
+			HomePageURL: f.HomeURL,
+			FeedURL:     f.FeedURL,
+			Description: f.Description,
+			Items:       []jsonFeedItem{},
+		}
+		for _, item := range f.Items {
+			doc.Items = append(doc.Items, jsonFeedItem{
+				ID:            item.ID.String(),
+				URL:           item.URL,
+				Title:         item.Title,
+				ContentText:   item.Content,
+				DatePublished: item.Published.Format(time.RFC3339),
+				DateModified:  item.Updated.Format(time.RFC3339),
+				Authors:       []jsonFeedAuthor{{Name: item.Author}},
+			})
+		}
+		return json.Marshal(doc)
+
+	default:
+		doc := rssDocument{
+			Version: "2.0",
+			Channel: rssChannel{
+				Title:       f.Title,
+				Link:        f.HomeURL,
+				Description: f.Description,
+			},
+		}
+		if !f.Updated.IsZero() {
+			doc.Channel.LastBuildDate = f.Updated.Format(time.RFC1123Z)
+		}
+		for _, item := range f.Items {
+			doc.Channel.Items = append(doc.Channel.Items, rssItem{
+				Title:       item.Title,
+				Link:        item.URL,
+				Description: item.Content,
+				Author:      item.Author,
+				GUID:        rssGUID{IsPermaLink: false, Value: "urn:uuid:" + item.ID.String()},
+				PubDate:     item.Published.Format(time.RFC1123Z),
+			})
+		}
+		data, err := marshalXMLDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		// dc:creator needs its namespace declared on the root element
+		return []byte(strings.Replace(string(data), `<rss version="2.0">`,
+			`<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">`, 1)), nil
+	}
+}
+
+func marshalXMLDocument(doc any) ([]byte, error) {
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// respondWithFeed negotiates the format and writes the feed, answering 304
+// when the client's copy is still current.
+func respondWithFeed(w http.ResponseWriter, r *http.Request, f feed) {
+	w.Header().Set("Vary", "Accept")
+
+	format, ok := negotiateFeedFormat(r)
+	if !ok {
+		http.Error(w, "Supported formats are RSS (application/rss+xml), Atom (application/atom+xml) and JSON Feed (application/feed+json)", http.StatusNotAcceptable)
+		return
+	}
+
+	data, err := renderFeed(f, format)
+	if err != nil {
+		log.Printf("Error rendering %s feed: %v", format, err)
+		http.Error(w, "Failed to render feed", http.StatusInternalServerError)
+		return
+	}
+
+	// The ETag covers the rendered feed, so it also changes when a gobit
+	// drops out of it
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	if !f.Updated.IsZero() {
+		w.Header().Set("Last-Modified", f.Updated.Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, f.Updated) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", feedContentTypes[format])
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// notModified applies If-None-Match, or If-Modified-Since when there is no
+// If-None-Match.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err == nil && !lastModified.Truncate(time.Second).After(since) {
+			return true
+		}
+	}
+	return false
+}
+
+// getPublicFeed syndicates the newest public gobits from everyone.
+func (cfg *apiConfig) getPublicFeed(w http.ResponseWriter, r *http.Request) {
+	gobits, err := cfg.db.ListPublicGobits(r.Context(), database.ListPublicGobitsParams{
+		Limit: feedItemLimit,
+	})
+	if err != nil {
+		log.Printf("Error listing gobits for public feed: %v", err)
+		http.Error(w, "Failed to get feed", http.StatusInternalServerError)
+		return
+	}
+
+	f, err := cfg.buildFeed(r, "Gohost", "The latest public gobits on Gohost", cfg.baseURL(r)+"/api/gobits", gobits)
+	if err != nil {
+		log.Printf("Error building public feed: %v", err)
+		http.Error(w, "Failed to get feed", http.StatusInternalServerError)
+		return
+	}
+	respondWithFeed(w, r, f)
+}
+
+// getAuthorFeed syndicates an author's newest public gobits.
+func (cfg *apiConfig) getAuthorFeed(w http.ResponseWriter, r *http.Request) {
+	handle := normalizeHandle(r.PathValue("handle"))
+	if handle == "" {
+		http.Error(w, "handle is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := cfg.db.GetUserByHandle(r.Context(), handle)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "user not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error getting user by handle %s for feed: %v", handle, err)
+			http.Error(w, "Failed to get feed", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	gobits, err := cfg.db.ListPublicGobits(r.Context(), database.ListPublicGobitsParams{
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		Limit:  feedItemLimit,
+	})
+	if err != nil {
+		log.Printf("Error listing gobits for %s's feed: %v", handle, err)
+		http.Error(w, "Failed to get feed", http.StatusInternalServerError)
+		return
+	}
+
+	name := authorName(toAuthorSummary(user))
+	description := user.Bio
+	if description == "" {
+		description = "Public gobits by " + name + " on Gohost"
+	}
+	f, err := cfg.buildFeed(r, name+" (@"+handle+")", description, cfg.baseURL(r)+"/api/users/"+handle, gobits)
+	if err != nil {
+		log.Printf("Error building %s's feed: %v", handle, err)
+		http.Error(w, "Failed to get feed", http.StatusInternalServerError)
+		return
+	}
+	respondWithFeed(w, r, f)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+func (cfg *apiConfig) createFollow(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.decodeRelationTarget(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := cfg.db.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: userID,
+		FolloweeID: targetID,
+	})
+	if err != nil {
+		log.Printf("cannot follow user %s for %s: %v", targetID, userID, err)
+		http.Error(w, "Failed to follow user", http.StatusInternalServerError)
+		return
+	}
+	if created == 0 {
+		// Either a block stopped the follow or the caller already follows
+		blocked, err := cfg.blockedBetween(r.Context(), userID, targetID)
+		if err != nil {
+			log.Printf("Error checking blocks between %s and %s: %v", userID, targetID, err)
+			http.Error(w, "Failed to follow user", http.StatusInternalServerError)
+			return
+		}
+		if blocked {
+			http.Error(w, "You cannot follow this user", http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	err = cfg.notify(r.Context(), targetID, userID, notificationFollow, uuid.NullUUID{})
+	if err != nil {
+		log.Printf("Error notifying %s of follow by %s: %v", targetID, userID, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) deleteFollow(w http.ResponseWriter, r *http.Request) {
+	userID, targetID, ok := cfg.decodeRelationTarget(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: userID,
+		FolloweeID: targetID,
+	})
+	if err != nil {
+		log.Printf("cannot unfollow user %s for %s: %v", targetID, userID, err)
+		http.Error(w, "Failed to unfollow user", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "follow not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listFollowing returns the users the caller follows.
+func (cfg *apiConfig) listFollowing(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for follow listing: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for follow listing: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	follows, err := cfg.db.ListFollowing(r.Context(), userID)
+	if err != nil {
+		log.Printf("cannot list follows for user %s: %v", userID, err)
+		http.Error(w, "Failed to list follows", http.StatusInternalServerError)
+		return
+	}
+
+	responseFollows := make([]userRelationResponse, len(follows))
+	for i, follow := range follows {
+		responseFollows[i] = userRelationResponse{
+			UserID:    follow.FolloweeID,
+			CreatedAt: follow.CreatedAt,
+		}
+	}
+
+	data, err := json.Marshal(responseFollows)
+	if err != nil {
+		log.Printf("Error marshalling follows response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// listFollowers returns the users following the caller.
+func (cfg *apiConfig) listFollowers(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for follower listing: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for follower listing: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	follows, err := cfg.db.ListFollowers(r.Context(), userID)
+	if err != nil {
+		log.Printf("cannot list followers for user %s: %v", userID, err)
+		http.Error(w, "Failed to list followers", http.StatusInternalServerError)
+		return
+	}
+
+	responseFollows := make([]userRelationResponse, len(follows))
+	for i, follow := range follows {
+		responseFollows[i] = userRelationResponse{
+			UserID:    follow.FollowerID,
+			CreatedAt: follow.CreatedAt,
+		}
+	}
+
+	data, err := json.Marshal(responseFollows)
+	if err != nil {
+		log.Printf("Error marshalling followers response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -12,3 +12,7 @@ require (
 )
 
 require github.com/golang-jwt/jwt/v5 v5.2.2
+
+require golang.org/x/text v0.24.0
+
+require github.com/gorilla/websocket v1.5.3
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"sort" // Import the sort package
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,17 +14,96 @@ import (
 	"github.com/twomotive/gohost/internal/database" // Ensure database import is present
 )
 
+const (
+	defaultGobitsPageLimit = 20
+	maxGobitsPageLimit     = 100
+)
+
 type gobitRequest struct {
-	Body   string    `json:"body"`
-	UserID uuid.UUID `json:"user_id"`
+	Body      string      `json:"body"`
+	UserID    uuid.UUID   `json:"user_id"`
+	MediaIDs  []uuid.UUID `json:"media_ids"`
+	Status    string      `json:"status"`
+	PublishAt *time.Time  `json:"publish_at"`
+	// Visibility defaults to public
+	Visibility string       `json:"visibility"`
+	ReplyToID  *uuid.UUID   `json:"reply_to_id"`
+	QuoteOfID  *uuid.UUID   `json:"quote_of_id"`
+	Poll       *pollRequest `json:"poll"`
 }
 
 type createdGobit struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Body        string     `json:"body"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Status      string     `json:"status"`
+	Visibility  string     `json:"visibility"`
+	ReplyToID   *uuid.UUID `json:"reply_to_id,omitempty"`
+	RepostOfID  *uuid.UUID `json:"repost_of_id,omitempty"`
+	QuoteOfID   *uuid.UUID `json:"quote_of_id,omitempty"`
+	RepostCount int64      `json:"repost_count"`
+	// Reactions counts each kind of reaction; ViewerReaction is the signed-in
+	// viewer's own
+	Reactions      map[string]int64 `json:"reactions,omitempty"`
+	ViewerReaction string           `json:"viewer_reaction,omitempty"`
+	// RepostOf and QuoteOf embed the original gobit when the viewer can see
+	// it
+	RepostOf *createdGobit `json:"repost_of,omitempty"`
+	QuoteOf  *createdGobit `json:"quote_of,omitempty"`
+	// PublishAt is when a scheduled gobit will go out; PublishedAt is when
+	// it did.
+	PublishAt   *time.Time      `json:"publish_at,omitempty"`
+	PublishedAt *time.Time      `json:"published_at,omitempty"`
+	PinnedAt    *time.Time      `json:"pinned_at,omitempty"`
+	Author      *authorSummary  `json:"author,omitempty"`
+	Media       []mediaResponse `json:"media,omitempty"`
+	Poll        *pollResponse   `json:"poll,omitempty"`
+	HiddenAt    *time.Time      `json:"hidden_at,omitempty"`
+	DeletedAt   *time.Time      `json:"deleted_at,omitempty"`
+}
+
+func toCreatedGobit(gobit database.Gobit) createdGobit {
+	responseGobit := createdGobit{
+		ID:         gobit.ID,
+		CreatedAt:  gobit.CreatedAt,
+		UpdatedAt:  gobit.UpdatedAt,
+		Body:       gobit.Body,
+		UserID:     gobit.UserID,
+		Status:     gobit.Status,
+		Visibility: gobit.Visibility,
+	}
+	if gobit.ReplyToID.Valid {
+		responseGobit.ReplyToID = &gobit.ReplyToID.UUID
+	}
+	if gobit.RepostOfID.Valid {
+		responseGobit.RepostOfID = &gobit.RepostOfID.UUID
+	}
+	if gobit.QuoteOfID.Valid {
+		responseGobit.QuoteOfID = &gobit.QuoteOfID.UUID
+	}
+	if gobit.PublishAt.Valid {
+		responseGobit.PublishAt = &gobit.PublishAt.Time
+	}
+	if gobit.PublishedAt.Valid {
+		responseGobit.PublishedAt = &gobit.PublishedAt.Time
+	}
+	if gobit.PinnedAt.Valid {
+		responseGobit.PinnedAt = &gobit.PinnedAt.Time
+	}
+	return responseGobit
+}
+
+// setRemoval fills in when the gobit was hidden or deleted. Only moderators
+// and the author's trash see these.
+func (g *createdGobit) setRemoval(gobit database.Gobit) {
+	if gobit.HiddenAt.Valid {
+		g.HiddenAt = &gobit.HiddenAt.Time
+	}
+	if gobit.DeletedAt.Valid {
+		g.DeletedAt = &gobit.DeletedAt.Time
+	}
 }
 
 func (cfg *apiConfig) createGoBits(w http.ResponseWriter, r *http.Request) {
@@ -61,30 +141,151 @@ func (cfg *apiConfig) createGoBits(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Body == "" {
-		http.Error(w, "Body cannot be empty", http.StatusBadRequest)
+	author, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Unauthorized: Unknown user", http.StatusUnauthorized)
+		} else {
+			log.Printf("Error getting author %s for gobit creation: %v", userID, err)
+			http.Error(w, "Failed to create gobit", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if message, locked := accountLockout(author); locked {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
+
+	validated, violations := cfg.validateGobitBody(req.Body, author.IsGohostRed.Valid && author.IsGohostRed.Bool)
+	if len(violations) > 0 {
+		respondWithViolations(w, violations)
+		return
+	}
+
+	status, publishAt, violations := gobitSchedule(req.Status, req.PublishAt)
+	if len(violations) > 0 {
+		respondWithViolations(w, violations)
+		return
+	}
+
+	if req.Poll != nil {
+		poll, flagged, violations := cfg.validatePoll(*req.Poll, publishAt)
+		if len(violations) > 0 {
+			respondWithViolations(w, violations)
+			return
+		}
+		req.Poll = &poll
+		validated.Flagged = validated.Flagged || flagged
+	}
+
+	visibility, violations := gobitVisibility(req.Visibility)
+	if len(violations) > 0 {
+		respondWithViolations(w, violations)
+		return
+	}
+
+	// Replies and quotes can only point at gobits the author is able to read
+	replyToID, ok := cfg.referencedGobit(w, r, userID, req.ReplyToID, "reply_to_id")
+	if !ok {
+		return
+	}
+	quoteOfID, ok := cfg.referencedGobit(w, r, userID, req.QuoteOfID, "quote_of_id")
+	if !ok {
+		return
+	}
+
+	if len(req.MediaIDs) > maxMediaPerGobit {
+		respondWithViolations(w, []violation{{
+			Field:   "media_ids",
+			Code:    "too_many",
+			Message: "Too many media attachments",
+			Limit:   maxMediaPerGobit,
+		}})
+		return
+	}
+	seenMedia := make(map[uuid.UUID]bool, len(req.MediaIDs))
+	for _, mediaID := range req.MediaIDs {
+		if seenMedia[mediaID] {
+			respondWithViolations(w, []violation{{
+				Field:   "media_ids",
+				Code:    "duplicate",
+				Message: "The same media can only be attached once",
+			}})
+			return
+		}
+		seenMedia[mediaID] = true
+	}
+	if !cfg.ownedMedia(w, r, userID, req.MediaIDs) {
 		return
 	}
 
 	params := database.CreateGobitParams{
-		Body:   req.Body,
-		UserID: userID,
+		Body:       validated.Cleaned,
+		UserID:     userID,
+		Status:     status,
+		PublishAt:  publishAt,
+		Visibility: visibility,
+		ReplyToID:  replyToID,
+		QuoteOfID:  quoteOfID,
 	}
 
-	gobit, err := cfg.db.CreateGobit(r.Context(), params)
+	// The gobit appears with its media and poll or not at all
+	var gobit database.Gobit
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		gobit, err = q.CreateGobit(r.Context(), params)
+		if err != nil {
+			return err
+		}
+
+		for i, mediaID := range req.MediaIDs {
+			err = q.AttachMediaToGobit(r.Context(), database.AttachMediaToGobitParams{
+				GobitID:  gobit.ID,
+				MediaID:  mediaID,
+				Position: int32(i),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if req.Poll != nil {
+			return createPoll(r.Context(), q, gobit.ID, *req.Poll)
+		}
+		return nil
+	})
 	if err != nil {
 		log.Printf("cannot create gobit !!: %v", err)
 		http.Error(w, "Failed to create gobit", http.StatusInternalServerError)
 		return
 	}
 
-	responseGobit := createdGobit{
-		ID:        gobit.ID,
-		CreatedAt: gobit.CreatedAt,
-		UpdatedAt: gobit.UpdatedAt,
-		Body:      gobit.Body,
-		UserID:    gobit.UserID,
+	// Streams, notifications and federation only hear about it once it is
+	// committed
+	if gobit.Status == gobitPublished {
+		cfg.afterPublish(r.Context(), gobit)
+	}
+
+	// Flagged content is published but queued for a moderator to review
+	if validated.Flagged {
+		_, err = cfg.db.CreateReport(r.Context(), database.CreateReportParams{
+			TargetType: reportTargetGobit,
+			TargetID:   gobit.ID,
+			Reason:     "Flagged automatically by moderation rules",
+		})
+		if err != nil {
+			log.Printf("Error queueing flagged gobit %s for review: %v", gobit.ID, err)
+		}
+	}
+
+	responseGobits, err := cfg.gobitResponses(r, []database.Gobit{gobit})
+	if err != nil {
+		log.Printf("cannot build response for gobit %s: %v", gobit.ID, err)
+		http.Error(w, "Failed to create gobit", http.StatusInternalServerError)
+		return
 	}
+	responseGobit := responseGobits[0]
 
 	data, err := json.Marshal(responseGobit)
 	if err != nil {
@@ -98,6 +299,142 @@ func (cfg *apiConfig) createGoBits(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
+// referencedGobit resolves the gobit a new gobit replies to or quotes. The
+// author must be able to read it, and a repost stands in for its original.
+// On failure it writes the error response and returns false.
+func (cfg *apiConfig) referencedGobit(w http.ResponseWriter, r *http.Request, userID uuid.UUID, gobitID *uuid.UUID, field string) (uuid.NullUUID, bool) {
+	if gobitID == nil {
+		return uuid.NullUUID{}, true
+	}
+
+	gobits, err := cfg.db.GetVisibleGobitsByIDs(r.Context(), database.GetVisibleGobitsByIDsParams{
+		Ids:      []uuid.UUID{*gobitID},
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		log.Printf("Error getting gobit %s for %s: %v", *gobitID, field, err)
+		http.Error(w, "Failed to create gobit", http.StatusInternalServerError)
+		return uuid.NullUUID{}, false
+	}
+	if len(gobits) == 0 {
+		respondWithViolations(w, []violation{{
+			Field:   field,
+			Code:    "not_found",
+			Message: "The referenced gobit does not exist",
+		}})
+		return uuid.NullUUID{}, false
+	}
+
+	if gobits[0].RepostOfID.Valid {
+		return gobits[0].RepostOfID, true
+	}
+	return uuid.NullUUID{UUID: gobits[0].ID, Valid: true}, true
+}
+
+// gobitResponses turns a listing into its response. It attaches media, polls,
+// reactions, repost counts, the gobits being reposted or quoted and, when asked for with
+// ?include=author, author summaries. Reposts of gobits the viewer can't see,
+// including deleted ones, are left out.
+func (cfg *apiConfig) gobitResponses(r *http.Request, gobits []database.Gobit) ([]createdGobit, error) {
+	var originalIDs []uuid.UUID
+	for _, dbGobit := range gobits {
+		if dbGobit.RepostOfID.Valid {
+			originalIDs = append(originalIDs, dbGobit.RepostOfID.UUID)
+		}
+		if dbGobit.QuoteOfID.Valid {
+			originalIDs = append(originalIDs, dbGobit.QuoteOfID.UUID)
+		}
+	}
+
+	originals := make(map[uuid.UUID]database.Gobit)
+	if len(originalIDs) > 0 {
+		visible, err := cfg.db.GetVisibleGobitsByIDs(r.Context(), database.GetVisibleGobitsByIDsParams{
+			Ids:      originalIDs,
+			ViewerID: cfg.optionalViewer(r),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, original := range visible {
+			originals[original.ID] = original
+		}
+	}
+
+	listed := make([]database.Gobit, 0, len(gobits))
+	for _, dbGobit := range gobits {
+		if dbGobit.RepostOfID.Valid {
+			if _, ok := originals[dbGobit.RepostOfID.UUID]; !ok {
+				continue
+			}
+		}
+		listed = append(listed, dbGobit)
+	}
+
+	// Media, counts and authors are looked up once for everything shown
+	shown := append([]database.Gobit{}, listed...)
+	for _, original := range originals {
+		shown = append(shown, original)
+	}
+
+	var authors map[uuid.UUID]authorSummary
+	if includesAuthor(r) && len(shown) > 0 {
+		var err error
+		authors, err = cfg.gobitAuthors(r.Context(), shown)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gobitIDs := make([]uuid.UUID, len(shown))
+	for i, dbGobit := range shown {
+		gobitIDs[i] = dbGobit.ID
+	}
+	attached, err := cfg.gobitMedia(r.Context(), gobitIDs)
+	if err != nil {
+		return nil, err
+	}
+	repostCounts, err := cfg.repostCounts(r.Context(), gobitIDs)
+	if err != nil {
+		return nil, err
+	}
+	viewerID := cfg.optionalViewer(r)
+	polls, err := cfg.gobitPolls(r.Context(), gobitIDs, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	reactions, viewerReactions, err := cfg.gobitReactions(r.Context(), gobitIDs, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	toResponse := func(dbGobit database.Gobit) createdGobit {
+		responseGobit := toCreatedGobit(dbGobit)
+		responseGobit.Media = attached[dbGobit.ID]
+		responseGobit.RepostCount = repostCounts[dbGobit.ID]
+		responseGobit.Poll = polls[dbGobit.ID]
+		responseGobit.Reactions = reactions[dbGobit.ID]
+		responseGobit.ViewerReaction = viewerReactions[dbGobit.ID]
+		if author, ok := authors[dbGobit.UserID]; ok {
+			responseGobit.Author = &author
+		}
+		return responseGobit
+	}
+
+	responseGobits := make([]createdGobit, len(listed))
+	for i, dbGobit := range listed {
+		responseGobits[i] = toResponse(dbGobit)
+		if original, ok := originals[dbGobit.RepostOfID.UUID]; ok {
+			repostOf := toResponse(original)
+			responseGobits[i].RepostOf = &repostOf
+		}
+		if quoted, ok := originals[dbGobit.QuoteOfID.UUID]; ok {
+			quoteOf := toResponse(quoted)
+			responseGobits[i].QuoteOf = &quoteOf
+		}
+	}
+	return responseGobits, nil
+}
+
 func (cfg *apiConfig) getAllGoBits(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -106,6 +443,8 @@ func (cfg *apiConfig) getAllGoBits(w http.ResponseWriter, r *http.Request) {
 
 	authorIDStr := r.URL.Query().Get("author_id")
 	sortParam := r.URL.Query().Get("sort") // Get the sort parameter
+	// Listings depend on who is looking: shadowbans, blocks and mutes
+	viewerID := cfg.optionalViewer(r)
 	var gobits []database.Gobit
 	var err error
 
@@ -117,10 +456,13 @@ func (cfg *apiConfig) getAllGoBits(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Invalid author_id format", http.StatusBadRequest)
 			return
 		}
-		gobits, err = cfg.db.GetGobitsByAuthor(r.Context(), authorID)
+		gobits, err = cfg.db.GetGobitsByAuthor(r.Context(), database.GetGobitsByAuthorParams{
+			UserID:   authorID,
+			ViewerID: viewerID,
+		})
 	} else {
 		// If author_id is not provided, get all gobits
-		gobits, err = cfg.db.GetAllGobits(r.Context())
+		gobits, err = cfg.db.GetAllGobits(r.Context(), viewerID)
 	}
 
 	if err != nil {
@@ -131,22 +473,24 @@ func (cfg *apiConfig) getAllGoBits(w http.ResponseWriter, r *http.Request) {
 
 	// Sort the gobits based on the sort parameter if it's "desc"
 	if sortParam == "desc" {
-		sort.Slice(gobits, func(i, j int) bool {
+		sort.SliceStable(gobits, func(i, j int) bool {
+			// Pinned gobits stay on top of an author listing, in the order
+			// the query returned them
+			if authorIDStr != "" && (gobits[i].PinnedAt.Valid || gobits[j].PinnedAt.Valid) {
+				return gobits[i].PinnedAt.Valid && !gobits[j].PinnedAt.Valid
+			}
 			// For descending order, check if i's time is after j's time
-			return gobits[i].CreatedAt.After(gobits[j].CreatedAt)
+			return gobits[i].PublishedAt.Time.After(gobits[j].PublishedAt.Time)
 		})
 	}
-	// Default is ascending, which is already handled by the SQL query.
+	// Default is ascending, which is already handled by the SQL query,
+	// pinned gobits included.
 
-	responseGobits := make([]createdGobit, len(gobits))
-	for i, dbGobit := range gobits {
-		responseGobits[i] = createdGobit{
-			ID:        dbGobit.ID,
-			CreatedAt: dbGobit.CreatedAt,
-			UpdatedAt: dbGobit.UpdatedAt,
-			Body:      dbGobit.Body,
-			UserID:    dbGobit.UserID,
-		}
+	responseGobits, err := cfg.gobitResponses(r, gobits)
+	if err != nil {
+		log.Printf("cannot build gobits response: %v", err)
+		http.Error(w, "Failed to get gobits", http.StatusInternalServerError)
+		return
 	}
 
 	data, err := json.Marshal(responseGobits)
@@ -162,6 +506,75 @@ func (cfg *apiConfig) getAllGoBits(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// listGobitsV2 is the version 2 listing: published gobits newest first, a
+// page at a time, optionally by one author. Pinned gobits stay in order;
+// pinned_at marks them.
+func (cfg *apiConfig) listGobitsV2(w http.ResponseWriter, r *http.Request) {
+	params := database.ListGobitsPageParams{
+		ViewerID: cfg.optionalViewer(r),
+		Limit:    defaultGobitsPageLimit,
+	}
+	query := r.URL.Query()
+	if authorIDStr := query.Get("author_id"); authorIDStr != "" {
+		authorID, err := uuid.Parse(authorIDStr)
+		if err != nil {
+			http.Error(w, "Invalid author_id format", http.StatusBadRequest)
+			return
+		}
+		params.AuthorID = uuid.NullUUID{UUID: authorID, Valid: true}
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxGobitsPageLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		params.Limit = int32(limit)
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		publishedAt, gobitID, err := decodeCursor(cursor)
+		if err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		params.BeforePublishedAt = sql.NullTime{Time: publishedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: gobitID, Valid: true}
+	}
+
+	// Fetch one extra to find out whether there is another page
+	pageSize := params.Limit
+	params.Limit++
+	gobits, err := cfg.db.ListGobitsPage(r.Context(), params)
+	if err != nil {
+		log.Printf("cannot list gobits: %v", err)
+		http.Error(w, "Failed to get gobits", http.StatusInternalServerError)
+		return
+	}
+
+	pagination := v2Pagination{Limit: int(pageSize)}
+	if len(gobits) > int(pageSize) {
+		gobits = gobits[:pageSize]
+		last := gobits[len(gobits)-1]
+		pagination.NextCursor = encodeCursor(last.PublishedAt.Time, last.ID)
+	}
+
+	responseGobits, err := cfg.gobitResponses(r, gobits)
+	if err != nil {
+		log.Printf("cannot build gobits response: %v", err)
+		http.Error(w, "Failed to get gobits", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(responseGobits)
+	if err != nil {
+		log.Printf("Error marshalling gobits response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithV2(w, http.StatusOK, v2Response{Data: data, Pagination: &pagination})
+}
+
 func (cfg *apiConfig) getGoBitByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -192,12 +605,84 @@ func (cfg *apiConfig) getGoBitByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseGobit := createdGobit{
-		ID:        dbGobit.ID,
-		CreatedAt: dbGobit.CreatedAt,
-		UpdatedAt: dbGobit.UpdatedAt,
-		Body:      dbGobit.Body,
-		UserID:    dbGobit.UserID,
+	viewerID := cfg.optionalViewer(r)
+
+	// Drafts and scheduled gobits are only visible to their author
+	if dbGobit.Status != gobitPublished && (!viewerID.Valid || viewerID.UUID != dbGobit.UserID) {
+		http.Error(w, "gobit not found", http.StatusNotFound)
+		return
+	}
+
+	// Moderators can see everything else, including removed gobits
+	isModerator, err := cfg.viewerIsModerator(r.Context(), viewerID)
+	if err != nil {
+		log.Printf("Error checking viewer role for gobit %s: %v", gobitID, err)
+		http.Error(w, "Failed to get gobit", http.StatusInternalServerError)
+		return
+	}
+
+	// To everyone else, gobits hidden by a moderator or deleted are treated
+	// as if they don't exist
+	if (dbGobit.HiddenAt.Valid || dbGobit.DeletedAt.Valid) && !isModerator {
+		http.Error(w, "gobit not found", http.StatusNotFound)
+		return
+	}
+
+	author, err := cfg.db.GetUserByID(r.Context(), dbGobit.UserID)
+	if err != nil {
+		log.Printf("Error getting author of gobit %s: %v", gobitID, err)
+		http.Error(w, "Failed to get gobit", http.StatusInternalServerError)
+		return
+	}
+
+	// So are a shadowbanned author's gobits, except to the author, gobits
+	// from someone the viewer has blocked or been blocked by, and gobits
+	// whose visibility doesn't include the viewer
+	if !isModerator && (!viewerID.Valid || viewerID.UUID != dbGobit.UserID) {
+		if author.AccountStatus == accountShadowbanned {
+			http.Error(w, "gobit not found", http.StatusNotFound)
+			return
+		}
+
+		if viewerID.Valid {
+			blocked, err := cfg.blockedBetween(r.Context(), viewerID.UUID, dbGobit.UserID)
+			if err != nil {
+				log.Printf("Error checking blocks for gobit %s: %v", gobitID, err)
+				http.Error(w, "Failed to get gobit", http.StatusInternalServerError)
+				return
+			}
+			if blocked {
+				http.Error(w, "gobit not found", http.StatusNotFound)
+				return
+			}
+		}
+
+		visible, err := cfg.gobitVisibleTo(r.Context(), dbGobit, viewerID)
+		if err != nil {
+			log.Printf("Error checking visibility of gobit %s: %v", gobitID, err)
+			http.Error(w, "Failed to get gobit", http.StatusInternalServerError)
+			return
+		}
+		if !visible {
+			http.Error(w, "gobit not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	responseGobits, err := cfg.gobitResponses(r, []database.Gobit{dbGobit})
+	if err != nil {
+		log.Printf("Error building response for gobit %s: %v", gobitID, err)
+		http.Error(w, "Failed to get gobit", http.StatusInternalServerError)
+		return
+	}
+	// A repost goes with its original
+	if len(responseGobits) == 0 {
+		http.Error(w, "gobit not found", http.StatusNotFound)
+		return
+	}
+	responseGobit := responseGobits[0]
+	if isModerator {
+		responseGobit.setRemoval(dbGobit)
 	}
 
 	data, err := json.Marshal(responseGobit)
@@ -266,8 +751,8 @@ func (cfg *apiConfig) deleteGobit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// User is the author, proceed with deletion
-	err = cfg.db.DeleteGobit(r.Context(), database.DeleteGobitParams{
+	// User is the author, move it to their trash
+	deleted, err := cfg.db.DeleteGobit(r.Context(), database.DeleteGobitParams{
 		ID:     gobitID,
 		UserID: userID,
 	})
@@ -277,6 +762,11 @@ func (cfg *apiConfig) deleteGobit(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to delete gobit", http.StatusInternalServerError)
 		return
 	}
+	if deleted == 0 {
+		http.Error(w, "gobit not found", http.StatusNotFound)
+		return
+	}
+	cfg.gobitDeleted(r.Context(), dbGobit)
 
 	w.WriteHeader(http.StatusNoContent) // 204 No Content for successful deletion
 }
@@ -0,0 +1,220 @@
+// Package activitypub holds the pieces of ActivityPub that don't depend on
+// Gohost's data: the JSON shapes of actors, notes and activities, HTTP
+// Signatures, and fetching from and delivering to other servers.
+package activitypub
+
+import (
+	"encoding/json"
+	"html"
+	"regexp"
+	"strings"
+)
+
+const (
+	// ContentType is the media type ActivityPub documents are served as.
+	ContentType = "application/activity+json"
+	// AcceptHeader asks remote servers for ActivityPub rather than HTML.
+	AcceptHeader = `application/activity+json, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+
+	// Public addresses an object to everyone.
+	Public = "https://www.w3.org/ns/activitystreams#Public"
+)
+
+// Context is the JSON-LD context of every document Gohost serves.
+var Context = []string{
+	"https://www.w3.org/ns/activitystreams",
+	"https://w3id.org/security/v1",
+}
+
+// Actor is a person's actor document.
+type Actor struct {
+	Context           any        `json:"@context,omitempty"`
+	ID                string     `json:"id"`
+	Type              string     `json:"type"`
+	PreferredUsername string     `json:"preferredUsername"`
+	Name              string     `json:"name,omitempty"`
+	Summary           string     `json:"summary,omitempty"`
+	URL               string     `json:"url,omitempty"`
+	Inbox             string     `json:"inbox"`
+	Outbox            string     `json:"outbox,omitempty"`
+	Followers         string     `json:"followers,omitempty"`
+	Endpoints         *Endpoints `json:"endpoints,omitempty"`
+	Icon              *Image     `json:"icon,omitempty"`
+	PublicKey         PublicKey  `json:"publicKey"`
+}
+
+// Endpoints lists an actor's server-wide endpoints.
+type Endpoints struct {
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+// Image is an avatar or other picture.
+type Image struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// PublicKey is the key an actor signs its requests with.
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+// Note is a post.
+type Note struct {
+	Context      any      `json:"@context,omitempty"`
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	AttributedTo string   `json:"attributedTo"`
+	Content      string   `json:"content"`
+	URL          string   `json:"url,omitempty"`
+	InReplyTo    string   `json:"inReplyTo,omitempty"`
+	Published    string   `json:"published,omitempty"`
+	Updated      string   `json:"updated,omitempty"`
+	To           Audience `json:"to,omitempty"`
+	CC           Audience `json:"cc,omitempty"`
+	Likes        *Count   `json:"likes,omitempty"`
+}
+
+// Count is a collection given only by its size.
+type Count struct {
+	Type       string `json:"type"`
+	TotalItems int64  `json:"totalItems"`
+}
+
+// Activity is something an actor did. Object is kept raw because it may be
+// an embedded object or just its id.
+type Activity struct {
+	Context   any             `json:"@context,omitempty"`
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Actor     string          `json:"actor"`
+	Object    json.RawMessage `json:"object"`
+	Published string          `json:"published,omitempty"`
+	To        Audience        `json:"to,omitempty"`
+	CC        Audience        `json:"cc,omitempty"`
+}
+
+// ObjectID returns the id of the activity's object, whether the object is
+// embedded or referenced.
+func (a Activity) ObjectID() string {
+	var id string
+	if json.Unmarshal(a.Object, &id) == nil {
+		return id
+	}
+	var obj struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(a.Object, &obj)
+	return obj.ID
+}
+
+// ObjectType returns the type of an embedded object, or "" for a reference.
+func (a Activity) ObjectType() string {
+	var obj struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(a.Object, &obj) != nil {
+		return ""
+	}
+	return obj.Type
+}
+
+// Audience is a to or cc field, which may be a single id or a list of them.
+type Audience []string
+
+// UnmarshalJSON accepts either form.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if json.Unmarshal(data, &one) == nil {
+		*a = Audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// Contains reports whether id is one of the recipients.
+func (a Audience) Contains(id string) bool {
+	for _, recipient := range a {
+		if recipient == id {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderedCollection is a collection such as an outbox, with its items on
+// separate pages.
+type OrderedCollection struct {
+	Context    any    `json:"@context,omitempty"`
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	TotalItems int64  `json:"totalItems"`
+	First      string `json:"first,omitempty"`
+}
+
+// OrderedCollectionPage is one page of an OrderedCollection.
+type OrderedCollectionPage struct {
+	Context      any    `json:"@context,omitempty"`
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	PartOf       string `json:"partOf"`
+	Next         string `json:"next,omitempty"`
+	OrderedItems []any  `json:"orderedItems"`
+}
+
+// WebFinger is a JSON Resource Descriptor answering a WebFinger lookup.
+type WebFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases,omitempty"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+// WebFingerLink is one link in a WebFinger response.
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href,omitempty"`
+}
+
+// ActorURL returns the ActivityPub actor the descriptor links to.
+func (w WebFinger) ActorURL() string {
+	for _, link := range w.Links {
+		if link.Rel == "self" && (link.Type == ContentType || strings.HasPrefix(link.Type, "application/ld+json")) {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// HTMLContent turns plain text into the HTML that notes carry.
+func HTMLContent(text string) string {
+	var b strings.Builder
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>"))
+		b.WriteString("</p>")
+	}
+	return b.String()
+}
+
+var (
+	lineBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>`)
+	paragraphPattern = regexp.MustCompile(`(?i)</p>\s*<p[^>]*>`)
+	tagPattern       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// PlainText strips the markup from a remote note's HTML content, keeping
+// line and paragraph breaks.
+func PlainText(content string) string {
+	content = lineBreakPattern.ReplaceAllString(content, "\n")
+	content = paragraphPattern.ReplaceAllString(content, "\n\n")
+	content = tagPattern.ReplaceAllString(content, "")
+	return strings.TrimSpace(html.UnescapeString(content))
+}
@@ -0,0 +1,190 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// MaxDocumentBytes caps the size of documents read from other servers, and
+// of activities posted to our inboxes.
+const MaxDocumentBytes = 1 << 20
+
+// Documents and inboxes are found at URLs other servers hand us, so requests
+// only go out over https and never reach our own network.
+var httpClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: refuseInternal,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		ForceAttemptHTTP2:   true,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("activitypub: too many redirects")
+		}
+		return checkURL(req.URL)
+	},
+}
+
+// development allows plain http and internal addresses.
+var development atomic.Bool
+
+// SetDevelopment lets requests go out over plain http and reach loopback and
+// private addresses, so servers on one machine can federate with each other.
+func SetDevelopment(on bool) {
+	development.Store(on)
+}
+
+// cgnat is the shared address space carriers put between their customers.
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// refuseInternal stops connections to loopback, private, link-local and
+// other addresses that aren't on the public internet. It runs on the
+// resolved address, so a hostname pointing inward is caught too.
+func refuseInternal(network, address string, c syscall.RawConn) error {
+	if development.Load() {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || cgnat.Contains(ip) {
+		return fmt.Errorf("activitypub: refusing to connect to internal address %s", ip)
+	}
+	return nil
+}
+
+// checkURL accepts https URLs, and http ones in development.
+func checkURL(u *url.URL) error {
+	if u.Host == "" || (u.Scheme != "https" && (u.Scheme != "http" || !development.Load())) {
+		return fmt.Errorf("activitypub: %q is not an https URL", u.String())
+	}
+	return nil
+}
+
+// StatusError is a non-2xx response from another server.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("activitypub: %s returned %d", e.URL, e.StatusCode)
+}
+
+// Permanent reports whether retrying the request is pointless: the server
+// understood it and refused.
+func (e *StatusError) Permanent() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500 &&
+		e.StatusCode != http.StatusRequestTimeout && e.StatusCode != http.StatusTooManyRequests
+}
+
+// Fetch GETs an ActivityPub document and decodes it into v.
+func Fetch(ctx context.Context, rawURL string, v any) error {
+	return get(ctx, rawURL, AcceptHeader, v)
+}
+
+// LookupWebFinger resolves an account written user@host to its actor URL.
+// scheme is the scheme to reach the host's WebFinger endpoint with.
+func LookupWebFinger(ctx context.Context, scheme, account string) (string, error) {
+	account = strings.TrimPrefix(strings.TrimPrefix(account, "acct:"), "@")
+	user, host, ok := strings.Cut(account, "@")
+	if !ok || user == "" || host == "" || strings.ContainsAny(host, "/?#") {
+		return "", fmt.Errorf("activitypub: %q is not an account of the form user@host", account)
+	}
+
+	lookup := url.URL{
+		Scheme:   scheme,
+		Host:     host,
+		Path:     "/.well-known/webfinger",
+		RawQuery: url.Values{"resource": {"acct:" + account}}.Encode(),
+	}
+	var descriptor WebFinger
+	if err := get(ctx, lookup.String(), "application/jrd+json, application/json", &descriptor); err != nil {
+		return "", err
+	}
+	actorURL := descriptor.ActorURL()
+	if actorURL == "" {
+		return "", errors.New("activitypub: WebFinger response has no ActivityPub actor")
+	}
+	return actorURL, nil
+}
+
+// Deliver POSTs an activity to an inbox, signed with the sending actor's key.
+func Deliver(ctx context.Context, inbox string, activity []byte, keyID string, key *rsa.PrivateKey) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(activity))
+	if err != nil {
+		return err
+	}
+	if err := checkURL(req.URL); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("Accept", AcceptHeader)
+	if err := Sign(req, activity, keyID, key); err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, MaxDocumentBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{URL: inbox, StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+func get(ctx context.Context, rawURL, accept string, v any) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("activitypub: %q is not an https URL", rawURL)
+	}
+	if err := checkURL(u); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", accept)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{URL: rawURL, StatusCode: resp.StatusCode}
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, MaxDocumentBytes)).Decode(v)
+}
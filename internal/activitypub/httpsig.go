@@ -0,0 +1,231 @@
+package activitypub
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// KeyBits is the size of the RSA keys actors sign with.
+const KeyBits = 2048
+
+// maxClockSkew is how far a signed request's Date may be from our clock.
+const maxClockSkew = time.Hour
+
+var (
+	ErrMissingSignature = errors.New("activitypub: request is not signed")
+	ErrInvalidSignature = errors.New("activitypub: invalid signature")
+)
+
+// GenerateKey creates a key pair and returns it PEM encoded.
+func GenerateKey() (publicPEM, privatePEM string, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, KeyBits)
+	if err != nil {
+		return "", "", err
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+	priv, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	publicPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+	privatePEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: priv}))
+	return publicPEM, privatePEM, nil
+}
+
+// ParsePrivateKey decodes a key from GenerateKey.
+func ParsePrivateKey(privatePEM string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return nil, errors.New("activitypub: no PEM data in private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("activitypub: private key is not RSA")
+	}
+	return rsaKey, nil
+}
+
+// ParsePublicKey decodes a remote actor's publicKeyPem, in either PKIX or
+// PKCS #1 form.
+func ParsePublicKey(publicPEM string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicPEM))
+	if block == nil {
+		return nil, errors.New("activitypub: no PEM data in public key")
+	}
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("activitypub: public key is not RSA")
+	}
+	return rsaKey, nil
+}
+
+// Digest is the value of the Digest header for body.
+func Digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Sign adds Date, Digest (when there is a body) and Signature headers to req,
+// following the draft-cavage HTTP Signatures scheme the fediverse uses.
+func Sign(req *http.Request, body []byte, keyID string, key *rsa.PrivateKey) error {
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		req.Header.Set("Digest", Digest(body))
+		headers = append(headers, "digest")
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(req, req.Host, headers)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(signature)))
+	return nil
+}
+
+// KeyLookup finds the public key for a keyId. refresh asks for a fresh copy
+// rather than a cached one, for when the actor may have rotated its key.
+type KeyLookup func(ctx context.Context, keyID string, refresh bool) (*rsa.PublicKey, error)
+
+// Verify checks the request's signature over body and returns the keyId it
+// was signed with. Signed requests with a body must cover its Digest.
+func Verify(req *http.Request, body []byte, lookup KeyLookup) (string, error) {
+	header := req.Header.Get("Signature")
+	if header == "" {
+		return "", ErrMissingSignature
+	}
+	params := parseSignatureHeader(header)
+	keyID := params["keyId"]
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if keyID == "" || err != nil || len(signature) == 0 {
+		return "", ErrInvalidSignature
+	}
+	switch params["algorithm"] {
+	case "", "rsa-sha256", "hs2019":
+	default:
+		return "", fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, params["algorithm"])
+	}
+
+	headers := strings.Fields(strings.ToLower(params["headers"]))
+	if len(headers) == 0 {
+		headers = []string{"date"}
+	}
+	for _, required := range []string{"(request-target)", "host", "date"} {
+		if !contains(headers, required) {
+			return "", fmt.Errorf("%w: %s is not signed", ErrInvalidSignature, required)
+		}
+	}
+
+	date, err := http.ParseTime(req.Header.Get("Date"))
+	if err != nil {
+		return "", fmt.Errorf("%w: bad Date header", ErrInvalidSignature)
+	}
+	if skew := time.Since(date); skew > maxClockSkew || skew < -maxClockSkew {
+		return "", fmt.Errorf("%w: Date is too far from now", ErrInvalidSignature)
+	}
+
+	if len(body) > 0 {
+		if !contains(headers, "digest") {
+			return "", fmt.Errorf("%w: digest is not signed", ErrInvalidSignature)
+		}
+		if req.Header.Get("Digest") != Digest(body) {
+			return "", fmt.Errorf("%w: digest does not match body", ErrInvalidSignature)
+		}
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(req, req.Host, headers)))
+	for _, refresh := range []bool{false, true} {
+		key, err := lookup(req.Context(), keyID, refresh)
+		if err != nil {
+			return "", err
+		}
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature) == nil {
+			return keyID, nil
+		}
+	}
+	return "", ErrInvalidSignature
+}
+
+// signingString builds the text that is signed, one "name: value" line per
+// signed header.
+func signingString(req *http.Request, host string, headers []string) string {
+	lines := make([]string, 0, len(headers))
+	for _, name := range headers {
+		var value string
+		switch name {
+		case "(request-target)":
+			value = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			value = host
+		default:
+			value = strings.Join(req.Header.Values(name), ", ")
+		}
+		lines = append(lines, name+": "+value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseSignatureHeader splits key="value" pairs. Values may contain commas
+// but not quotes.
+func parseSignatureHeader(header string) map[string]string {
+	params := make(map[string]string)
+	for header != "" {
+		name, rest, ok := strings.Cut(header, "=")
+		if !ok {
+			break
+		}
+		name = strings.TrimSpace(name)
+		rest = strings.TrimSpace(rest)
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				break
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		params[name] = value
+		header = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+	return params
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -8,11 +10,28 @@ import (
 	"github.com/google/uuid"
 )
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+// Claims are what an access token says about its bearer.
+type Claims struct {
+	UserID uuid.UUID
+	// SessionID identifies the refresh token the access token was issued
+	// for. It is empty for tokens issued before sessions were recorded.
+	SessionID string
+	ExpiresAt time.Time
+}
+
+// SessionID derives the ID an access token carries for its session from the
+// session's refresh token, without revealing the refresh token.
+func SessionID(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
+
+func MakeJWT(userID uuid.UUID, sessionID string, tokenSecret string, expiresIn time.Duration) (string, error) {
 	signingKey := []byte(tokenSecret)
 
 	// Create the claims
 	claims := &jwt.RegisteredClaims{
+		ID:        sessionID,
 		Issuer:    "gohost",
 		IssuedAt:  jwt.NewNumericDate(time.Now()),
 		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
@@ -30,6 +49,16 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 }
 
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	claims, err := ParseJWT(tokenString, tokenSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return claims.UserID, nil
+}
+
+// ParseJWT validates an access token like ValidateJWT and returns all of its
+// claims.
+func ParseJWT(tokenString, tokenSecret string) (Claims, error) {
 	signingKey := []byte(tokenSecret)
 	claims := &jwt.RegisteredClaims{}
 
@@ -42,17 +71,21 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 	})
 
 	if err != nil {
-		return uuid.Nil, err
+		return Claims{}, err
 	}
 
 	if !token.Valid {
-		return uuid.Nil, errors.New("invalid token")
+		return Claims{}, errors.New("invalid token")
 	}
 
 	userID, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return uuid.Nil, err
+		return Claims{}, err
 	}
 
-	return userID, nil
+	parsed := Claims{UserID: userID, SessionID: claims.ID}
+	if claims.ExpiresAt != nil {
+		parsed.ExpiresAt = claims.ExpiresAt.Time
+	}
+	return parsed, nil
 }
@@ -7,29 +7,98 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countPublicGobits = `-- name: CountPublicGobits :one
+SELECT COUNT(*) FROM gobits
+WHERE user_id = $1 AND visibility = 'public' AND status = 'published'
+  AND hidden_at IS NULL AND deleted_at IS NULL AND repost_of_id IS NULL
+`
+
+func (q *Queries) CountPublicGobits(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPublicGobits, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRepostsForGobits = `-- name: CountRepostsForGobits :many
+SELECT repost_of_id, COUNT(*) AS repost_count FROM gobits
+WHERE repost_of_id = ANY($1::uuid[])
+  AND hidden_at IS NULL AND deleted_at IS NULL
+GROUP BY repost_of_id
+`
+
+type CountRepostsForGobitsRow struct {
+	RepostOfID  uuid.NullUUID
+	RepostCount int64
+}
+
+func (q *Queries) CountRepostsForGobits(ctx context.Context, gobitIds []uuid.UUID) ([]CountRepostsForGobitsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countRepostsForGobits, pq.Array(gobitIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRepostsForGobitsRow
+	for rows.Next() {
+		var i CountRepostsForGobitsRow
+		if err := rows.Scan(&i.RepostOfID, &i.RepostCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createGobit = `-- name: CreateGobit :one
-INSERT INTO gobits (id, created_at, updated_at, body, user_id)
+INSERT INTO gobits (id, created_at, updated_at, body, user_id, status, publish_at, published_at, visibility, reply_to_id, quote_of_id)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    CASE WHEN $3 = 'published' THEN NOW() END,
+    $5,
+    $6,
+    $7
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, hidden_at, deleted_at, deleted_by, status, publish_at, published_at, visibility, reply_to_id, repost_of_id, quote_of_id, pinned_at
 `
 
 type CreateGobitParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body       string
+	UserID     uuid.UUID
+	Status     string
+	PublishAt  sql.NullTime
+	Visibility string
+	ReplyToID  uuid.NullUUID
+	QuoteOfID  uuid.NullUUID
 }
 
 func (q *Queries) CreateGobit(ctx context.Context, arg CreateGobitParams) (Gobit, error) {
-	row := q.db.QueryRowContext(ctx, createGobit, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createGobit,
+		arg.Body,
+		arg.UserID,
+		arg.Status,
+		arg.PublishAt,
+		arg.Visibility,
+		arg.ReplyToID,
+		arg.QuoteOfID,
+	)
 	var i Gobit
 	err := row.Scan(
 		&i.ID,
@@ -37,13 +106,63 @@ func (q *Queries) CreateGobit(ctx context.Context, arg CreateGobitParams) (Gobit
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.HiddenAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.Status,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Visibility,
+		&i.ReplyToID,
+		&i.RepostOfID,
+		&i.QuoteOfID,
+		&i.PinnedAt,
 	)
 	return i, err
 }
 
-const deleteGobit = `-- name: DeleteGobit :exec
-DELETE FROM gobits
-WHERE id = $1 AND user_id = $2
+const createRepost = `-- name: CreateRepost :one
+INSERT INTO gobits (id, created_at, updated_at, body, user_id, status, published_at, visibility, repost_of_id)
+VALUES (gen_random_uuid(), NOW(), NOW(), '', $1, 'published', NOW(), $2, $3)
+RETURNING id, created_at, updated_at, body, user_id, hidden_at, deleted_at, deleted_by, status, publish_at, published_at, visibility, reply_to_id, repost_of_id, quote_of_id, pinned_at
+`
+
+type CreateRepostParams struct {
+	UserID     uuid.UUID
+	Visibility string
+	RepostOfID uuid.NullUUID
+}
+
+// A repost takes the visibility of the gobit it reposts, so an unlisted
+// gobit stays off public timelines.
+func (q *Queries) CreateRepost(ctx context.Context, arg CreateRepostParams) (Gobit, error) {
+	row := q.db.QueryRowContext(ctx, createRepost, arg.UserID, arg.Visibility, arg.RepostOfID)
+	var i Gobit
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.HiddenAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.Status,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Visibility,
+		&i.ReplyToID,
+		&i.RepostOfID,
+		&i.QuoteOfID,
+		&i.PinnedAt,
+	)
+	return i, err
+}
+
+const deleteGobit = `-- name: DeleteGobit :execrows
+UPDATE gobits
+SET deleted_at = NOW(), deleted_by = user_id, pinned_at = NULL, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 `
 
 type DeleteGobitParams struct {
@@ -51,18 +170,195 @@ type DeleteGobitParams struct {
 	UserID uuid.UUID
 }
 
-func (q *Queries) DeleteGobit(ctx context.Context, arg DeleteGobitParams) error {
-	_, err := q.db.ExecContext(ctx, deleteGobit, arg.ID, arg.UserID)
-	return err
+// Moves the gobit to its author's trash, from where it can be restored
+// until it is purged.
+func (q *Queries) DeleteGobit(ctx context.Context, arg DeleteGobitParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteGobit, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteGobitByID = `-- name: DeleteGobitByID :execrows
+UPDATE gobits
+SET deleted_at = NOW(), deleted_by = $2, pinned_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+type DeleteGobitByIDParams struct {
+	ID        uuid.UUID
+	DeletedBy uuid.NullUUID
+}
+
+// Removes a gobit on behalf of a moderator. The author can't restore it.
+func (q *Queries) DeleteGobitByID(ctx context.Context, arg DeleteGobitByIDParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteGobitByID, arg.ID, arg.DeletedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRepost = `-- name: DeleteRepost :many
+DELETE FROM gobits
+WHERE user_id = $1 AND repost_of_id = $2
+RETURNING id, created_at, updated_at, body, user_id, hidden_at, deleted_at, deleted_by, status, publish_at, published_at, visibility, reply_to_id, repost_of_id, quote_of_id, pinned_at
+`
+
+type DeleteRepostParams struct {
+	UserID     uuid.UUID
+	RepostOfID uuid.NullUUID
+}
+
+func (q *Queries) DeleteRepost(ctx context.Context, arg DeleteRepostParams) ([]Gobit, error) {
+	rows, err := q.db.QueryContext(ctx, deleteRepost, arg.UserID, arg.RepostOfID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gobit
+	for rows.Next() {
+		var i Gobit
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.PinnedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getAllGobits = `-- name: GetAllGobits :many
-SELECT id, created_at, updated_at, body, user_id FROM gobits
-ORDER BY created_at ASC
+SELECT gobits.id, gobits.created_at, gobits.updated_at, gobits.body, gobits.user_id, gobits.hidden_at, gobits.deleted_at, gobits.deleted_by, gobits.status, gobits.publish_at, gobits.published_at, gobits.visibility, gobits.reply_to_id, gobits.repost_of_id, gobits.quote_of_id, gobits.pinned_at FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.status = 'published'
+  AND (users.account_status <> 'shadowbanned' OR gobits.user_id = $1)
+  AND NOT EXISTS (
+      SELECT 1 FROM user_blocks
+      WHERE (user_blocks.blocker_id = $1 AND user_blocks.blocked_id = gobits.user_id)
+         OR (user_blocks.blocker_id = gobits.user_id AND user_blocks.blocked_id = $1)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM user_mutes
+      WHERE user_mutes.muter_id = $1 AND user_mutes.muted_id = gobits.user_id
+  )
+  -- Unlisted gobits stay off the timeline; private ones are for the author
+  AND (gobits.user_id = $1
+       OR gobits.visibility = 'public'
+       OR (gobits.visibility = 'followers' AND EXISTS (
+           SELECT 1 FROM follows
+           WHERE follows.follower_id = $1 AND follows.followee_id = gobits.user_id
+       )))
+ORDER BY gobits.published_at ASC
+`
+
+func (q *Queries) GetAllGobits(ctx context.Context, viewerID uuid.NullUUID) ([]Gobit, error) {
+	rows, err := q.db.QueryContext(ctx, getAllGobits, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gobit
+	for rows.Next() {
+		var i Gobit
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.PinnedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeed = `-- name: GetFeed :many
+SELECT gobits.id, gobits.created_at, gobits.updated_at, gobits.body, gobits.user_id, gobits.hidden_at, gobits.deleted_at, gobits.deleted_by, gobits.status, gobits.publish_at, gobits.published_at, gobits.visibility, gobits.reply_to_id, gobits.repost_of_id, gobits.quote_of_id, gobits.pinned_at FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE (gobits.user_id = $1 OR gobits.user_id IN (
+          SELECT follows.followee_id FROM follows WHERE follows.follower_id = $1
+      ))
+  AND (gobits.user_id = $1 OR gobits.visibility <> 'private')
+  AND gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.status = 'published'
+  AND (users.account_status <> 'shadowbanned' OR gobits.user_id = $1)
+  AND NOT EXISTS (
+      SELECT 1 FROM user_blocks
+      WHERE (user_blocks.blocker_id = $1 AND user_blocks.blocked_id = gobits.user_id)
+         OR (user_blocks.blocker_id = gobits.user_id AND user_blocks.blocked_id = $1)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM user_mutes
+      WHERE user_mutes.muter_id = $1 AND user_mutes.muted_id = gobits.user_id
+  )
+  AND ($2::timestamp IS NULL
+       OR (gobits.published_at, gobits.id) < ($2::timestamp, $3::uuid))
+ORDER BY gobits.published_at DESC, gobits.id DESC
+LIMIT $4
 `
 
-func (q *Queries) GetAllGobits(ctx context.Context) ([]Gobit, error) {
-	rows, err := q.db.QueryContext(ctx, getAllGobits)
+type GetFeedParams struct {
+	ViewerID uuid.UUID
+	Before   sql.NullTime
+	BeforeID uuid.NullUUID
+	Limit    int32
+}
+
+// The viewer's home feed: their own gobits and those of everyone they
+// follow, newest first. Pass the published_at and id of the last gobit as
+// before and before_id to page back; before alone skips anything published
+// at that exact time.
+func (q *Queries) GetFeed(ctx context.Context, arg GetFeedParams) ([]Gobit, error) {
+	rows, err := q.db.QueryContext(ctx, getFeed,
+		arg.ViewerID,
+		arg.Before,
+		arg.BeforeID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -76,6 +372,17 @@ func (q *Queries) GetAllGobits(ctx context.Context) ([]Gobit, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.PinnedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -91,7 +398,7 @@ func (q *Queries) GetAllGobits(ctx context.Context) ([]Gobit, error) {
 }
 
 const getGobit = `-- name: GetGobit :one
-SELECT id, created_at, updated_at, body, user_id FROM gobits WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, hidden_at, deleted_at, deleted_by, status, publish_at, published_at, visibility, reply_to_id, repost_of_id, quote_of_id, pinned_at FROM gobits WHERE id = $1
 `
 
 func (q *Queries) GetGobit(ctx context.Context, id uuid.UUID) (Gobit, error) {
@@ -103,18 +410,518 @@ func (q *Queries) GetGobit(ctx context.Context, id uuid.UUID) (Gobit, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.HiddenAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.Status,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Visibility,
+		&i.ReplyToID,
+		&i.RepostOfID,
+		&i.QuoteOfID,
+		&i.PinnedAt,
 	)
 	return i, err
 }
 
 const getGobitsByAuthor = `-- name: GetGobitsByAuthor :many
-SELECT id, created_at, updated_at, body, user_id FROM gobits
+SELECT gobits.id, gobits.created_at, gobits.updated_at, gobits.body, gobits.user_id, gobits.hidden_at, gobits.deleted_at, gobits.deleted_by, gobits.status, gobits.publish_at, gobits.published_at, gobits.visibility, gobits.reply_to_id, gobits.repost_of_id, gobits.quote_of_id, gobits.pinned_at FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE gobits.user_id = $1 AND gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.status = 'published'
+  AND (users.account_status <> 'shadowbanned' OR gobits.user_id = $2)
+  AND NOT EXISTS (
+      SELECT 1 FROM user_blocks
+      WHERE (user_blocks.blocker_id = $2 AND user_blocks.blocked_id = gobits.user_id)
+         OR (user_blocks.blocker_id = gobits.user_id AND user_blocks.blocked_id = $2)
+  )
+  AND (gobits.user_id = $2
+       OR gobits.visibility IN ('public', 'unlisted')
+       OR (gobits.visibility = 'followers' AND EXISTS (
+           SELECT 1 FROM follows
+           WHERE follows.follower_id = $2 AND follows.followee_id = gobits.user_id
+       )))
+-- Pinned gobits come first, most recently pinned at the top
+ORDER BY gobits.pinned_at DESC NULLS LAST, gobits.published_at ASC
+`
+
+type GetGobitsByAuthorParams struct {
+	UserID   uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetGobitsByAuthor(ctx context.Context, arg GetGobitsByAuthorParams) ([]Gobit, error) {
+	rows, err := q.db.QueryContext(ctx, getGobitsByAuthor, arg.UserID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gobit
+	for rows.Next() {
+		var i Gobit
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.PinnedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVisibleGobitsByIDs = `-- name: GetVisibleGobitsByIDs :many
+SELECT gobits.id, gobits.created_at, gobits.updated_at, gobits.body, gobits.user_id, gobits.hidden_at, gobits.deleted_at, gobits.deleted_by, gobits.status, gobits.publish_at, gobits.published_at, gobits.visibility, gobits.reply_to_id, gobits.repost_of_id, gobits.quote_of_id, gobits.pinned_at FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE gobits.id = ANY($1::uuid[])
+  AND gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.status = 'published'
+  AND (users.account_status <> 'shadowbanned' OR gobits.user_id = $2)
+  AND NOT EXISTS (
+      SELECT 1 FROM user_blocks
+      WHERE (user_blocks.blocker_id = $2 AND user_blocks.blocked_id = gobits.user_id)
+         OR (user_blocks.blocker_id = gobits.user_id AND user_blocks.blocked_id = $2)
+  )
+  AND (gobits.user_id = $2
+       OR gobits.visibility IN ('public', 'unlisted')
+       OR (gobits.visibility = 'followers' AND EXISTS (
+           SELECT 1 FROM follows
+           WHERE follows.follower_id = $2 AND follows.followee_id = gobits.user_id
+       )))
+ORDER BY gobits.published_at ASC
+`
+
+type GetVisibleGobitsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+// Loads the given gobits, dropping any the viewer isn't allowed to open.
+func (q *Queries) GetVisibleGobitsByIDs(ctx context.Context, arg GetVisibleGobitsByIDsParams) ([]Gobit, error) {
+	rows, err := q.db.QueryContext(ctx, getVisibleGobitsByIDs, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gobit
+	for rows.Next() {
+		var i Gobit
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.PinnedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hideGobit = `-- name: HideGobit :execrows
+UPDATE gobits
+SET hidden_at = NOW(), updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) HideGobit(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, hideGobit, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listGobitsByUser = `-- name: ListGobitsByUser :many
+SELECT id, created_at, updated_at, body, user_id, hidden_at, deleted_at, deleted_by, status, publish_at, published_at, visibility, reply_to_id, repost_of_id, quote_of_id, pinned_at FROM gobits
 WHERE user_id = $1
 ORDER BY created_at ASC
 `
 
-func (q *Queries) GetGobitsByAuthor(ctx context.Context, userID uuid.UUID) ([]Gobit, error) {
-	rows, err := q.db.QueryContext(ctx, getGobitsByAuthor, userID)
+func (q *Queries) ListGobitsByUser(ctx context.Context, userID uuid.UUID) ([]Gobit, error) {
+	rows, err := q.db.QueryContext(ctx, listGobitsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gobit
+	for rows.Next() {
+		var i Gobit
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.PinnedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGobitsPage = `-- name: ListGobitsPage :many
+SELECT gobits.id, gobits.created_at, gobits.updated_at, gobits.body, gobits.user_id, gobits.hidden_at, gobits.deleted_at, gobits.deleted_by, gobits.status, gobits.publish_at, gobits.published_at, gobits.visibility, gobits.reply_to_id, gobits.repost_of_id, gobits.quote_of_id, gobits.pinned_at FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE ($1::uuid IS NULL OR gobits.user_id = $1)
+  AND gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.status = 'published'
+  AND (users.account_status <> 'shadowbanned' OR gobits.user_id = $2)
+  AND NOT EXISTS (
+      SELECT 1 FROM user_blocks
+      WHERE (user_blocks.blocker_id = $2 AND user_blocks.blocked_id = gobits.user_id)
+         OR (user_blocks.blocker_id = gobits.user_id AND user_blocks.blocked_id = $2)
+  )
+  -- Muted users stay reachable on their own listing
+  AND ($1::uuid IS NOT NULL OR NOT EXISTS (
+      SELECT 1 FROM user_mutes
+      WHERE user_mutes.muter_id = $2 AND user_mutes.muted_id = gobits.user_id
+  ))
+  AND (gobits.user_id = $2
+       OR gobits.visibility = 'public'
+       OR (gobits.visibility = 'unlisted' AND $1::uuid IS NOT NULL)
+       OR (gobits.visibility = 'followers' AND EXISTS (
+           SELECT 1 FROM follows
+           WHERE follows.follower_id = $2 AND follows.followee_id = gobits.user_id
+       )))
+  AND ($3::timestamp IS NULL
+       OR (gobits.published_at, gobits.id) < ($3::timestamp, $4::uuid))
+ORDER BY gobits.published_at DESC, gobits.id DESC
+LIMIT $5
+`
+
+type ListGobitsPageParams struct {
+	AuthorID          uuid.NullUUID
+	ViewerID          uuid.NullUUID
+	BeforePublishedAt sql.NullTime
+	BeforeID          uuid.NullUUID
+	Limit             int32
+}
+
+// Pages through published gobits newest first, everyone's or one author's,
+// with the same visibility rules as GetAllGobits and GetGobitsByAuthor.
+// Pass the published_at and id of the last gobit seen to get the next page.
+func (q *Queries) ListGobitsPage(ctx context.Context, arg ListGobitsPageParams) ([]Gobit, error) {
+	rows, err := q.db.QueryContext(ctx, listGobitsPage,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.BeforePublishedAt,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gobit
+	for rows.Next() {
+		var i Gobit
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.PinnedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPublicGobits = `-- name: ListPublicGobits :many
+SELECT gobits.id, gobits.created_at, gobits.updated_at, gobits.body, gobits.user_id, gobits.hidden_at, gobits.deleted_at, gobits.deleted_by, gobits.status, gobits.publish_at, gobits.published_at, gobits.visibility, gobits.reply_to_id, gobits.repost_of_id, gobits.quote_of_id, gobits.pinned_at FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE gobits.visibility = 'public' AND gobits.status = 'published'
+  AND gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.repost_of_id IS NULL
+  AND users.account_status <> 'shadowbanned'
+  AND ($1::uuid IS NULL OR gobits.user_id = $1)
+  AND ($2::timestamp IS NULL
+       OR (gobits.published_at, gobits.id) < ($2::timestamp, $3::uuid))
+ORDER BY gobits.published_at DESC, gobits.id DESC
+LIMIT $4
+`
+
+type ListPublicGobitsParams struct {
+	UserID            uuid.NullUUID
+	BeforePublishedAt sql.NullTime
+	BeforeID          uuid.NullUUID
+	Limit             int32
+}
+
+// The newest public gobits for syndication, from everyone or from one
+// author. Reposts are left out. Pass the published_at and id of the last
+// gobit seen to get the next page.
+func (q *Queries) ListPublicGobits(ctx context.Context, arg ListPublicGobitsParams) ([]Gobit, error) {
+	rows, err := q.db.QueryContext(ctx, listPublicGobits,
+		arg.UserID,
+		arg.BeforePublishedAt,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gobit
+	for rows.Next() {
+		var i Gobit
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.PinnedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRemovedGobits = `-- name: ListRemovedGobits :many
+SELECT id, created_at, updated_at, body, user_id, hidden_at, deleted_at, deleted_by, status, publish_at, published_at, visibility, reply_to_id, repost_of_id, quote_of_id, pinned_at FROM gobits
+WHERE deleted_at IS NOT NULL OR hidden_at IS NOT NULL
+ORDER BY COALESCE(deleted_at, hidden_at) DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListRemovedGobitsParams struct {
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) ListRemovedGobits(ctx context.Context, arg ListRemovedGobitsParams) ([]Gobit, error) {
+	rows, err := q.db.QueryContext(ctx, listRemovedGobits, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gobit
+	for rows.Next() {
+		var i Gobit
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.PinnedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadGobitIDs = `-- name: ListThreadGobitIDs :many
+WITH RECURSIVE ancestors AS (
+    SELECT gobits.id, gobits.reply_to_id FROM gobits WHERE gobits.id = $1
+    UNION
+    SELECT gobits.id, gobits.reply_to_id FROM gobits
+    JOIN ancestors ON gobits.id = ancestors.reply_to_id
+), replies AS (
+    SELECT gobits.id FROM gobits WHERE gobits.id = $1
+    UNION
+    SELECT gobits.id FROM gobits
+    JOIN replies ON gobits.reply_to_id = replies.id
+)
+SELECT ancestors.id FROM ancestors
+UNION
+SELECT replies.id FROM replies
+`
+
+// Returns the gobit, the chain of gobits it replies to and every reply below
+// it, however deep. Visibility is applied afterwards by GetVisibleGobitsByIDs.
+func (q *Queries) ListThreadGobitIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listThreadGobitIDs, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrashedGobits = `-- name: ListTrashedGobits :many
+SELECT id, created_at, updated_at, body, user_id, hidden_at, deleted_at, deleted_by, status, publish_at, published_at, visibility, reply_to_id, repost_of_id, quote_of_id, pinned_at FROM gobits
+WHERE user_id = $1 AND deleted_by = user_id AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+`
+
+func (q *Queries) ListTrashedGobits(ctx context.Context, userID uuid.UUID) ([]Gobit, error) {
+	rows, err := q.db.QueryContext(ctx, listTrashedGobits, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gobit
+	for rows.Next() {
+		var i Gobit
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.PinnedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnpublishedGobits = `-- name: ListUnpublishedGobits :many
+SELECT id, created_at, updated_at, body, user_id, hidden_at, deleted_at, deleted_by, status, publish_at, published_at, visibility, reply_to_id, repost_of_id, quote_of_id, pinned_at FROM gobits
+WHERE user_id = $1 AND status <> 'published' AND deleted_at IS NULL
+ORDER BY COALESCE(publish_at, created_at) ASC
+`
+
+func (q *Queries) ListUnpublishedGobits(ctx context.Context, userID uuid.UUID) ([]Gobit, error) {
+	rows, err := q.db.QueryContext(ctx, listUnpublishedGobits, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -128,6 +935,17 @@ func (q *Queries) GetGobitsByAuthor(ctx context.Context, userID uuid.UUID) ([]Go
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.PinnedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -141,3 +959,288 @@ func (q *Queries) GetGobitsByAuthor(ctx context.Context, userID uuid.UUID) ([]Go
 	}
 	return items, nil
 }
+
+const pinGobit = `-- name: PinGobit :execrows
+UPDATE gobits
+SET pinned_at = NOW()
+WHERE id = $1 AND user_id = $2 AND pinned_at IS NULL
+  AND (
+      SELECT COUNT(*) FROM gobits AS pinned
+      WHERE pinned.user_id = $2
+        AND pinned.pinned_at IS NOT NULL AND pinned.deleted_at IS NULL
+  ) < $3::int
+`
+
+type PinGobitParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	MaxPinned int32
+}
+
+// Pins the gobit unless its author already has max_pinned gobits pinned.
+// Run it with the author's row locked so two pins cannot both squeeze in.
+func (q *Queries) PinGobit(ctx context.Context, arg PinGobitParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, pinGobit, arg.ID, arg.UserID, arg.MaxPinned)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const publishDueGobits = `-- name: PublishDueGobits :many
+UPDATE gobits
+SET status = 'published', published_at = NOW(), updated_at = NOW()
+WHERE status = 'scheduled' AND id IN (
+    SELECT id FROM gobits
+    WHERE status = 'scheduled' AND publish_at <= NOW() AND deleted_at IS NULL
+      -- Hold posts from locked-out authors until they are allowed back
+      AND user_id NOT IN (
+          SELECT users.id FROM users
+          WHERE users.account_status = 'banned'
+             OR (users.account_status = 'suspended' AND (users.suspended_until IS NULL OR users.suspended_until > NOW()))
+      )
+    ORDER BY publish_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, body, user_id, hidden_at, deleted_at, deleted_by, status, publish_at, published_at, visibility, reply_to_id, repost_of_id, quote_of_id, pinned_at
+`
+
+// Publishes scheduled gobits whose time has come. Rows locked by another
+// instance are skipped, and the status check means each gobit is returned
+// by exactly one call.
+func (q *Queries) PublishDueGobits(ctx context.Context, limit int32) ([]Gobit, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueGobits, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gobit
+	for rows.Next() {
+		var i Gobit
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.PinnedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishGobit = `-- name: PublishGobit :one
+UPDATE gobits
+SET status = $3,
+    publish_at = $4,
+    published_at = CASE WHEN $3 = 'published' THEN NOW() END,
+    updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND status <> 'published' AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, hidden_at, deleted_at, deleted_by, status, publish_at, published_at, visibility, reply_to_id, repost_of_id, quote_of_id, pinned_at
+`
+
+type PublishGobitParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Status    string
+	PublishAt sql.NullTime
+}
+
+func (q *Queries) PublishGobit(ctx context.Context, arg PublishGobitParams) (Gobit, error) {
+	row := q.db.QueryRowContext(ctx, publishGobit,
+		arg.ID,
+		arg.UserID,
+		arg.Status,
+		arg.PublishAt,
+	)
+	var i Gobit
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.HiddenAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.Status,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Visibility,
+		&i.ReplyToID,
+		&i.RepostOfID,
+		&i.QuoteOfID,
+		&i.PinnedAt,
+	)
+	return i, err
+}
+
+const purgeDeletedGobits = `-- name: PurgeDeletedGobits :execrows
+DELETE FROM gobits
+WHERE deleted_at < $1 AND deleted_by = user_id
+`
+
+// Only gobits their authors deleted are purged; those a moderator removed
+// are kept as the record of the action.
+func (q *Queries) PurgeDeletedGobits(ctx context.Context, deletedAt sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedGobits, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreGobit = `-- name: RestoreGobit :one
+UPDATE gobits
+SET deleted_at = NULL, deleted_by = NULL, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_by = user_id AND deleted_at IS NOT NULL
+RETURNING id, created_at, updated_at, body, user_id, hidden_at, deleted_at, deleted_by, status, publish_at, published_at, visibility, reply_to_id, repost_of_id, quote_of_id, pinned_at
+`
+
+type RestoreGobitParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RestoreGobit(ctx context.Context, arg RestoreGobitParams) (Gobit, error) {
+	row := q.db.QueryRowContext(ctx, restoreGobit, arg.ID, arg.UserID)
+	var i Gobit
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.HiddenAt,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.Status,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Visibility,
+		&i.ReplyToID,
+		&i.RepostOfID,
+		&i.QuoteOfID,
+		&i.PinnedAt,
+	)
+	return i, err
+}
+
+const searchGobits = `-- name: SearchGobits :many
+SELECT gobits.id, gobits.created_at, gobits.updated_at, gobits.body, gobits.user_id, gobits.hidden_at, gobits.deleted_at, gobits.deleted_by, gobits.status, gobits.publish_at, gobits.published_at, gobits.visibility, gobits.reply_to_id, gobits.repost_of_id, gobits.quote_of_id, gobits.pinned_at FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE gobits.body ILIKE $1
+  AND gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.status = 'published'
+  AND (users.account_status <> 'shadowbanned' OR gobits.user_id = $2)
+  AND NOT EXISTS (
+      SELECT 1 FROM user_blocks
+      WHERE (user_blocks.blocker_id = $2 AND user_blocks.blocked_id = gobits.user_id)
+         OR (user_blocks.blocker_id = gobits.user_id AND user_blocks.blocked_id = $2)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM user_mutes
+      WHERE user_mutes.muter_id = $2 AND user_mutes.muted_id = gobits.user_id
+  )
+  AND (gobits.user_id = $2
+       OR gobits.visibility = 'public'
+       OR (gobits.visibility = 'followers' AND EXISTS (
+           SELECT 1 FROM follows
+           WHERE follows.follower_id = $2 AND follows.followee_id = gobits.user_id
+       )))
+ORDER BY gobits.published_at DESC
+LIMIT $3 OFFSET $4
+`
+
+type SearchGobitsParams struct {
+	Pattern  string
+	ViewerID uuid.NullUUID
+	Limit    int32
+	Offset   int32
+}
+
+// Matches gobit bodies against a LIKE pattern. Results follow the same rules
+// as the public timeline, newest first.
+func (q *Queries) SearchGobits(ctx context.Context, arg SearchGobitsParams) ([]Gobit, error) {
+	rows, err := q.db.QueryContext(ctx, searchGobits,
+		arg.Pattern,
+		arg.ViewerID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gobit
+	for rows.Next() {
+		var i Gobit
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.HiddenAt,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Visibility,
+			&i.ReplyToID,
+			&i.RepostOfID,
+			&i.QuoteOfID,
+			&i.PinnedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const unpinGobit = `-- name: UnpinGobit :execrows
+UPDATE gobits
+SET pinned_at = NULL
+WHERE id = $1 AND user_id = $2 AND pinned_at IS NOT NULL
+`
+
+type UnpinGobitParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) UnpinGobit(ctx context.Context, arg UnpinGobitParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unpinGobit, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
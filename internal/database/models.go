@@ -6,17 +6,226 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AccountStatusEvent struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UserID         uuid.UUID
+	ActorID        uuid.NullUUID
+	OldStatus      string
+	NewStatus      string
+	SuspendedUntil sql.NullTime
+	Reason         string
+}
+
+type ActorKey struct {
+	UserID        uuid.UUID
+	CreatedAt     time.Time
+	PublicKeyPem  string
+	PrivateKeyPem string
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	GobitID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Conversation struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatedBy uuid.NullUUID
+	IsGroup   bool
+}
+
+type ConversationMember struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type DataExport struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	Status     string
+	StorageKey sql.NullString
+	SizeBytes  sql.NullInt64
+	ExpiresAt  sql.NullTime
+}
+
+type EmailVerification struct {
+	Token     string
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Email     string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
 type Gobit struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Body        string
+	UserID      uuid.UUID
+	HiddenAt    sql.NullTime
+	DeletedAt   sql.NullTime
+	DeletedBy   uuid.NullUUID
+	Status      string
+	PublishAt   sql.NullTime
+	PublishedAt sql.NullTime
+	Visibility  string
+	ReplyToID   uuid.NullUUID
+	RepostOfID  uuid.NullUUID
+	QuoteOfID   uuid.NullUUID
+	PinnedAt    sql.NullTime
+}
+
+type GobitEvent struct {
+	ID         int64
+	CreatedAt  time.Time
+	Type       string
+	GobitID    uuid.UUID
+	UserID     uuid.UUID
+	Visibility string
+}
+
+type GobitMedium struct {
+	GobitID  uuid.UUID
+	MediaID  uuid.UUID
+	Position int32
+}
+
+type Job struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Kind        string
+	Payload     json.RawMessage
+	Status      string
+	RunAt       time.Time
+	Attempts    int32
+	MaxAttempts int32
+	LockedAt    sql.NullTime
+	LastError   sql.NullString
+	CompletedAt sql.NullTime
+}
+
+type MediaFile struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UserID               uuid.UUID
+	ContentType          string
+	SizeBytes            int64
+	Width                int32
+	Height               int32
+	StorageKey           string
+	ThumbnailContentType string
+	ThumbnailKey         string
+}
+
+type Message struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+	DeletedAt      sql.NullTime
+}
+
+type ModerationAction struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	ModeratorID uuid.NullUUID
+	ReportID    uuid.NullUUID
+	Action      string
+	TargetType  string
+	TargetID    uuid.UUID
+	Reason      string
+}
+
+type ModerationRule struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Kind      string
+	Pattern   string
+	Action    string
+}
+
+type Notification struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
 	UserID    uuid.UUID
+	Type      string
+	GobitID   uuid.NullUUID
+	GroupKey  string
+	ReadAt    sql.NullTime
+	ReportID  uuid.NullUUID
+}
+
+type NotificationActor struct {
+	NotificationID uuid.UUID
+	ActorID        uuid.UUID
+	CreatedAt      time.Time
+}
+
+type NotificationPreference struct {
+	UserID    uuid.UUID
+	Type      string
+	Enabled   bool
+	UpdatedAt time.Time
+}
+
+type Poll struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	GobitID        uuid.UUID
+	MultipleChoice bool
+	ExpiresAt      time.Time
+	ClosedAt       sql.NullTime
+}
+
+type PollOption struct {
+	ID       uuid.UUID
+	PollID   uuid.UUID
+	Position int32
+	Title    string
+}
+
+type PollVote struct {
+	PollID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type PollVoteOption struct {
+	PollID   uuid.UUID
+	UserID   uuid.UUID
+	OptionID uuid.UUID
+}
+
+type Reaction struct {
+	UserID    uuid.UUID
+	GobitID   uuid.UUID
+	Kind      string
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,11 +237,101 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
-type User struct {
+type RemoteActor struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsGohostRed    sql.NullBool
+	Uri            string
+	Handle         string
+	DisplayName    string
+	InboxUrl       string
+	SharedInboxUrl sql.NullString
+	FollowersUrl   sql.NullString
+	PublicKeyID    string
+	PublicKeyPem   string
+}
+
+type RemoteFollower struct {
+	UserID      uuid.UUID
+	ActorID     uuid.UUID
+	CreatedAt   time.Time
+	ActivityUri string
+}
+
+type RemoteFollowing struct {
+	UserID      uuid.UUID
+	ActorID     uuid.UUID
+	CreatedAt   time.Time
+	ActivityUri string
+	AcceptedAt  sql.NullTime
+}
+
+type RemoteLike struct {
+	ActorID     uuid.UUID
+	GobitID     uuid.UUID
+	CreatedAt   time.Time
+	ActivityUri string
+}
+
+type RemoteNote struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	Uri         string
+	ActorID     uuid.UUID
+	Url         string
+	Content     string
+	InReplyToID uuid.NullUUID
+	PublishedAt time.Time
+}
+
+type Report struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	ReporterID uuid.NullUUID
+	TargetType string
+	TargetID   uuid.UUID
+	Reason     string
+	Status     string
+	AssigneeID uuid.NullUUID
+	Outcome    sql.NullString
+	ResolvedAt sql.NullTime
+}
+
+type SubscriptionEvent struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Event     string
+}
+
+type User struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Email                string
+	HashedPassword       string
+	IsGohostRed          sql.NullBool
+	Role                 string
+	SuspendedUntil       sql.NullTime
+	AccountStatus        string
+	Handle               sql.NullString
+	DisplayName          string
+	Bio                  string
+	Website              string
+	AvatarMediaID        uuid.NullUUID
+	EmailVerifiedAt      sql.NullTime
+	DeletionScheduledFor sql.NullTime
+}
+
+type UserBlock struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type UserMute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
 }
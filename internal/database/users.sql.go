@@ -7,10 +7,43 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const cancelUserDeletion = `-- name: CancelUserDeletion :one
+UPDATE users
+SET deletion_scheduled_for = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, cancelUserDeletion, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsGohostRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.AccountStatus,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at, email, hashed_password)
 VALUES (
@@ -20,7 +53,7 @@ VALUES (
     $1,
     $2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_gohost_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for
 `
 
 type CreateUserParams struct {
@@ -38,12 +71,35 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsGohostRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.AccountStatus,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
 	)
 	return i, err
 }
 
+const deleteUserIfDue = `-- name: DeleteUserIfDue :execrows
+DELETE FROM users
+WHERE id = $1 AND deletion_scheduled_for IS NOT NULL AND deletion_scheduled_for <= NOW()
+`
+
+func (q *Queries) DeleteUserIfDue(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserIfDue, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_gohost_red FROM users WHERE email = $1
+SELECT id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -56,10 +112,193 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsGohostRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.AccountStatus,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
 	)
 	return i, err
 }
 
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for FROM users WHERE handle = $1::text
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsGohostRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.AccountStatus,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsGohostRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.AccountStatus,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+	)
+	return i, err
+}
+
+const getUserByIDForUpdate = `-- name: GetUserByIDForUpdate :one
+SELECT id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for FROM users WHERE id = $1 FOR UPDATE
+`
+
+// Locks the user's row until the end of the transaction.
+func (q *Queries) GetUserByIDForUpdate(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByIDForUpdate, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsGohostRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.AccountStatus,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+	)
+	return i, err
+}
+
+const getUsersByHandles = `-- name: GetUsersByHandles :many
+SELECT id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for FROM users WHERE handle = ANY($1::text[])
+`
+
+func (q *Queries) GetUsersByHandles(ctx context.Context, handles []string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByHandles, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsGohostRed,
+			&i.Role,
+			&i.SuspendedUntil,
+			&i.AccountStatus,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.Website,
+			&i.AvatarMediaID,
+			&i.EmailVerifiedAt,
+			&i.DeletionScheduledFor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersByIDs = `-- name: GetUsersByIDs :many
+SELECT id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for FROM users WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsGohostRed,
+			&i.Role,
+			&i.SuspendedUntil,
+			&i.AccountStatus,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.Website,
+			&i.AvatarMediaID,
+			&i.EmailVerifiedAt,
+			&i.DeletionScheduledFor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const resetUsers = `-- name: ResetUsers :exec
 DELETE FROM users
 `
@@ -69,21 +308,93 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 	return err
 }
 
-const updateUser = `-- name: UpdateUser :one
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :one
+UPDATE users
+SET deletion_scheduled_for = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for
+`
+
+type ScheduleUserDeletionParams struct {
+	ID                   uuid.UUID
+	DeletionScheduledFor sql.NullTime
+}
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, scheduleUserDeletion, arg.ID, arg.DeletionScheduledFor)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsGohostRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.AccountStatus,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+	)
+	return i, err
+}
+
+const setUserAccountStatus = `-- name: SetUserAccountStatus :one
 UPDATE users
-SET email = $2, hashed_password = $3, updated_at = NOW()
+SET account_status = $2, suspended_until = $3, updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_gohost_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for
 `
 
-type UpdateUserParams struct {
+type SetUserAccountStatusParams struct {
 	ID             uuid.UUID
-	Email          string
-	HashedPassword string
+	AccountStatus  string
+	SuspendedUntil sql.NullTime
+}
+
+func (q *Queries) SetUserAccountStatus(ctx context.Context, arg SetUserAccountStatusParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserAccountStatus, arg.ID, arg.AccountStatus, arg.SuspendedUntil)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsGohostRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.AccountStatus,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+	)
+	return i, err
+}
+
+const setUserAvatar = `-- name: SetUserAvatar :one
+UPDATE users
+SET avatar_media_id = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for
+`
+
+type SetUserAvatarParams struct {
+	ID            uuid.UUID
+	AvatarMediaID uuid.NullUUID
 }
 
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUser, arg.ID, arg.Email, arg.HashedPassword)
+func (q *Queries) SetUserAvatar(ctx context.Context, arg SetUserAvatarParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserAvatar, arg.ID, arg.AvatarMediaID)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -92,6 +403,53 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsGohostRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.AccountStatus,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+	)
+	return i, err
+}
+
+const setVerifiedUserEmail = `-- name: SetVerifiedUserEmail :one
+UPDATE users
+SET email = $2, email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for
+`
+
+type SetVerifiedUserEmailParams struct {
+	ID    uuid.UUID
+	Email string
+}
+
+// Switches the user to an address they have just verified.
+func (q *Queries) SetVerifiedUserEmail(ctx context.Context, arg SetVerifiedUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setVerifiedUserEmail, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsGohostRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.AccountStatus,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
 	)
 	return i, err
 }
@@ -100,7 +458,7 @@ const updateUserMembership = `-- name: UpdateUserMembership :one
 UPDATE users
 SET is_gohost_red = true
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_gohost_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for
 `
 
 func (q *Queries) UpdateUserMembership(ctx context.Context, id uuid.UUID) (User, error) {
@@ -113,6 +471,97 @@ func (q *Queries) UpdateUserMembership(ctx context.Context, id uuid.UUID) (User,
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsGohostRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.AccountStatus,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for
+`
+
+type UpdateUserPasswordParams struct {
+	ID             uuid.UUID
+	HashedPassword string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.ID, arg.HashedPassword)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsGohostRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.AccountStatus,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = $2, display_name = $3, bio = $4, website = $5, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_gohost_red, role, suspended_until, account_status, handle, display_name, bio, website, avatar_media_id, email_verified_at, deletion_scheduled_for
+`
+
+type UpdateUserProfileParams struct {
+	ID          uuid.UUID
+	Handle      sql.NullString
+	DisplayName string
+	Bio         string
+	Website     string
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.ID,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.Website,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsGohostRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.AccountStatus,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.AvatarMediaID,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledFor,
 	)
 	return i, err
 }
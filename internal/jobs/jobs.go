@@ -0,0 +1,201 @@
+// Package jobs runs background work from a queue stored in Postgres. Any
+// number of server instances can poll the same queue; each job is claimed by
+// one worker at a time.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	// DefaultMaxAttempts is how many times a job runs before it is marked failed.
+	DefaultMaxAttempts = 5
+
+	defaultPollInterval = 5 * time.Second
+	// A running job whose worker hasn't finished within staleAfter is assumed
+	// lost, e.g. because the instance crashed, and is handed out again.
+	staleAfter = 10 * time.Minute
+)
+
+// Handler does the work for one kind of job. Returning an error schedules a
+// retry with backoff until the job runs out of attempts. Handlers may run
+// more than once for the same job, so they should be idempotent.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// lastAttemptKey marks the context a job's final attempt runs with.
+type lastAttemptKey struct{}
+
+// LastAttempt reports whether the job being handled with ctx is on its last
+// attempt, so a handler can record a failure that won't be retried.
+func LastAttempt(ctx context.Context) bool {
+	last, _ := ctx.Value(lastAttemptKey{}).(bool)
+	return last
+}
+
+// Queue enqueues jobs and runs the handlers registered for them.
+type Queue struct {
+	db           *database.Queries
+	pollInterval time.Duration
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	periodic []periodicTask
+}
+
+type periodicTask struct {
+	name     string
+	interval time.Duration
+	fn       func(ctx context.Context) error
+}
+
+// NewQueue returns a Queue backed by db.
+func NewQueue(db *database.Queries) *Queue {
+	return &Queue{
+		db:           db,
+		pollInterval: defaultPollInterval,
+		handlers:     make(map[string]Handler),
+	}
+}
+
+// Handle registers the handler for jobs of the given kind.
+func (q *Queue) Handle(kind string, h Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = h
+}
+
+// Every runs fn roughly once per interval on each instance. It is meant for
+// sweeps whose queries are safe to run concurrently, such as deleting rows
+// that are past a cutoff.
+func (q *Queue) Every(name string, interval time.Duration, fn func(ctx context.Context) error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.periodic = append(q.periodic, periodicTask{name: name, interval: interval, fn: fn})
+}
+
+// Enqueue schedules a job to run at runAt, or as soon as possible if runAt
+// is in the past. payload is marshalled to JSON.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any, runAt time.Time) (database.Job, error) {
+	return q.EnqueueIn(ctx, q.db, kind, payload, runAt)
+}
+
+// EnqueueIn is Enqueue through db, which can belong to a transaction so the
+// job only exists if the transaction commits.
+func (q *Queue) EnqueueIn(ctx context.Context, db *database.Queries, kind string, payload any, runAt time.Time) (database.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return database.Job{}, fmt.Errorf("marshal %s payload: %w", kind, err)
+	}
+	return db.EnqueueJob(ctx, database.EnqueueJobParams{
+		Kind:        kind,
+		Payload:     data,
+		RunAt:       runAt,
+		MaxAttempts: DefaultMaxAttempts,
+	})
+}
+
+// Run processes jobs until ctx is cancelled.
+func (q *Queue) Run(ctx context.Context) {
+	q.mu.RLock()
+	for _, task := range q.periodic {
+		go q.runPeriodic(ctx, task)
+	}
+	q.mu.RUnlock()
+
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain everything that is due before waiting again
+		for q.runNext(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *Queue) runPeriodic(ctx context.Context, task periodicTask) {
+	ticker := time.NewTicker(task.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := task.fn(ctx); err != nil {
+			log.Printf("Periodic task %s failed: %v", task.name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext claims and runs a single job, reporting whether there was one.
+func (q *Queue) runNext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	job, err := q.db.ClaimJob(ctx, time.Now().Add(-staleAfter))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error claiming job: %v", err)
+		}
+		return false
+	}
+
+	q.mu.RLock()
+	handler, ok := q.handlers[job.Kind]
+	q.mu.RUnlock()
+
+	if !ok {
+		err = fmt.Errorf("no handler for job kind %q", job.Kind)
+	} else {
+		err = handler(context.WithValue(ctx, lastAttemptKey{}, job.Attempts >= job.MaxAttempts), job.Payload)
+	}
+
+	if err == nil {
+		if err := q.db.CompleteJob(ctx, job.ID); err != nil {
+			log.Printf("Error completing job %s: %v", job.ID, err)
+		}
+		return true
+	}
+
+	lastError := sql.NullString{String: err.Error(), Valid: true}
+	if !ok || job.Attempts >= job.MaxAttempts {
+		log.Printf("Job %s (%s) failed permanently after %d attempts: %v", job.ID, job.Kind, job.Attempts, err)
+		if err := q.db.FailJob(ctx, database.FailJobParams{ID: job.ID, LastError: lastError}); err != nil {
+			log.Printf("Error failing job %s: %v", job.ID, err)
+		}
+		return true
+	}
+
+	log.Printf("Job %s (%s) attempt %d failed, retrying: %v", job.ID, job.Kind, job.Attempts, err)
+	err = q.db.RetryJob(ctx, database.RetryJobParams{
+		ID:        job.ID,
+		RunAt:     time.Now().Add(backoff(job.Attempts)),
+		LastError: lastError,
+	})
+	if err != nil {
+		log.Printf("Error rescheduling job %s: %v", job.ID, err)
+	}
+	return true
+}
+
+// backoff grows quadratically: 30s, 2m, 4m30s, 8m...
+func backoff(attempts int32) time.Duration {
+	return time.Duration(attempts*attempts) * 30 * time.Second
+}
@@ -0,0 +1,34 @@
+// Package mailer sends transactional email such as address verification.
+package mailer
+
+import (
+	"context"
+	"log"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Log is a Mailer that writes messages to the standard logger instead of
+// sending them. It is only meant for development: tokens end up in the logs.
+type Log struct{}
+
+// NewLog returns a Mailer that logs every message.
+func NewLog() *Log {
+	return &Log{}
+}
+
+// Send logs msg.
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	log.Printf("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
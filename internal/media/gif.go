@@ -0,0 +1,49 @@
+package media
+
+// gifFrameCount counts the frames in a GIF by walking its blocks, without
+// decoding any pixels. It stops counting once there are more than
+// MaxGIFFrames. A malformed file returns the frames found so far; decoding
+// it reports the error.
+func gifFrameCount(data []byte) int {
+	// Header and logical screen descriptor
+	if len(data) < 13 {
+		return 0
+	}
+	i := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		i += 3 << (flags&0x07 + 1)
+	}
+
+	frames := 0
+	for i < len(data) && frames <= MaxGIFFrames {
+		switch data[i] {
+		case 0x21:
+			// Extension: label, then data sub-blocks
+			i += 2
+		case 0x2C:
+			// Image descriptor, optional local color table, LZW code size,
+			// then the image data sub-blocks
+			if i+10 > len(data) {
+				return frames
+			}
+			frames++
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			i++
+		default:
+			// Trailer, or something that isn't a GIF block
+			return frames
+		}
+
+		// Skip the sub-blocks, each prefixed with its length, up to the
+		// empty one that ends them
+		for i < len(data) && data[i] != 0 {
+			i += int(data[i]) + 1
+		}
+		i++
+	}
+	return frames
+}
@@ -0,0 +1,134 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+var (
+	// ErrUnsupportedType is returned for anything other than JPEG, PNG or GIF.
+	ErrUnsupportedType = errors.New("media: unsupported file type")
+	// ErrTooLarge is returned for images with more than MaxPixels pixels,
+	// counting every frame of an animated GIF, or with more than
+	// MaxGIFFrames frames.
+	ErrTooLarge = errors.New("media: image dimensions too large")
+)
+
+const (
+	// MaxPixels guards against decompression bombs: small files that decode
+	// to enormous images.
+	MaxPixels = 40_000_000
+	// MaxGIFFrames is the most frames an animated GIF may have.
+	MaxGIFFrames = 300
+	// ThumbnailSize is the longest side of a generated thumbnail.
+	ThumbnailSize = 320
+
+	jpegQuality = 90
+)
+
+// Image is an uploaded image re-encoded without its metadata, along with a
+// thumbnail.
+type Image struct {
+	ContentType string
+	Ext         string
+	Data        []byte
+	Width       int
+	Height      int
+
+	ThumbnailContentType string
+	ThumbnailExt         string
+	Thumbnail            []byte
+}
+
+// Process sniffs the content type of data, rejects anything that isn't a
+// supported image and re-encodes it. Re-encoding drops EXIF and other
+// metadata (GPS location, camera details); JPEG orientation is applied to
+// the pixels first so photos keep the right way up.
+func Process(data []byte) (*Image, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+	if contentType == "image/gif" {
+		// Every frame is decoded at once, so they all count
+		frames := gifFrameCount(data)
+		if frames > MaxGIFFrames || config.Width*config.Height*frames > MaxPixels {
+			return nil, ErrTooLarge
+		}
+	}
+
+	var src image.Image
+	var out bytes.Buffer
+	result := &Image{ContentType: contentType}
+
+	switch contentType {
+	case "image/jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		src = applyOrientation(src, exifOrientation(data))
+		err = jpeg.Encode(&out, src, &jpeg.Options{Quality: jpegQuality})
+		result.Ext = ".jpg"
+	case "image/png":
+		src, err = png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		err = png.Encode(&out, src)
+		result.Ext = ".png"
+	case "image/gif":
+		// Keep every frame so animations survive; comments and application
+		// extensions are not written back out
+		var g *gif.GIF
+		g, err = gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		src = g.Image[0]
+		err = gif.EncodeAll(&out, g)
+		result.Ext = ".gif"
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	result.Data = out.Bytes()
+	result.Width = bounds.Dx()
+	result.Height = bounds.Dy()
+
+	var thumb bytes.Buffer
+	small := thumbnail(src, ThumbnailSize)
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&thumb, small, &jpeg.Options{Quality: jpegQuality})
+		result.ThumbnailContentType = "image/jpeg"
+		result.ThumbnailExt = ".jpg"
+	} else {
+		// PNG keeps transparency that JPEG would lose
+		err = png.Encode(&thumb, small)
+		result.ThumbnailContentType = "image/png"
+		result.ThumbnailExt = ".png"
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Thumbnail = thumb.Bytes()
+
+	return result, nil
+}
@@ -0,0 +1,226 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		err         error
+		contentType string
+		width       int
+		height      int
+		frames      int
+		stripped    []string
+	}{
+		{
+			name:        "jpeg with exif",
+			data:        jpegWithExif(t, 4, 2, 6),
+			contentType: "image/jpeg",
+			// Orientation 6 is applied, turning the image on its side
+			width:    2,
+			height:   4,
+			stripped: []string{"Exif", "GPSSecretPlace"},
+		},
+		{
+			name:        "png with text chunk",
+			data:        pngWithText(t, 3, 5, "GPSSecretPlace"),
+			contentType: "image/png",
+			width:       3,
+			height:      5,
+			stripped:    []string{"GPSSecretPlace"},
+		},
+		{
+			name:        "animated gif",
+			data:        animatedGIF(t, 8, 8, 3, 8, 8),
+			contentType: "image/gif",
+			width:       8,
+			height:      8,
+			frames:      3,
+		},
+		{
+			name: "not an image",
+			data: []byte("<html><body>hello</body></html>"),
+			err:  ErrUnsupportedType,
+		},
+		{
+			name: "oversize dimensions",
+			data: pngHeader(10_000, 10_000),
+			err:  ErrTooLarge,
+		},
+		{
+			name: "too many gif frames",
+			data: animatedGIF(t, 2, 2, MaxGIFFrames+1, 2, 2),
+			err:  ErrTooLarge,
+		},
+		{
+			name: "gif frame bomb",
+			// Tiny frames on a large canvas: each decoded frame is small,
+			// but 50 frames of 1000x1000 is over the pixel budget
+			data: animatedGIF(t, 1000, 1000, 50, 1, 1),
+			err:  ErrTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Process(tt.data)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if img.ContentType != tt.contentType {
+				t.Errorf("ContentType = %q, want %q", img.ContentType, tt.contentType)
+			}
+			if img.Width != tt.width || img.Height != tt.height {
+				t.Errorf("size = %dx%d, want %dx%d", img.Width, img.Height, tt.width, tt.height)
+			}
+			for _, s := range tt.stripped {
+				if bytes.Contains(img.Data, []byte(s)) {
+					t.Errorf("output still contains %q", s)
+				}
+			}
+			if tt.frames > 0 {
+				g, err := gif.DecodeAll(bytes.NewReader(img.Data))
+				if err != nil {
+					t.Fatalf("decoding output: %v", err)
+				}
+				if len(g.Image) != tt.frames {
+					t.Errorf("frames = %d, want %d", len(g.Image), tt.frames)
+				}
+			}
+			if len(img.Thumbnail) == 0 {
+				t.Error("no thumbnail")
+			}
+		})
+	}
+}
+
+func TestGIFFrameCount(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		frames int
+	}{
+		{name: "one frame", data: animatedGIF(t, 4, 4, 1, 4, 4), frames: 1},
+		{name: "several frames", data: animatedGIF(t, 4, 4, 7, 2, 2), frames: 7},
+		{name: "stops past the limit", data: animatedGIF(t, 1, 1, MaxGIFFrames+10, 1, 1), frames: MaxGIFFrames + 1},
+		{name: "truncated", data: []byte("GIF89a"), frames: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gifFrameCount(tt.data); got != tt.frames {
+				t.Errorf("gifFrameCount = %d, want %d", got, tt.frames)
+			}
+		})
+	}
+}
+
+// testImage is a w by h image with a gradient, so it survives re-encoding
+// with some detail.
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 40), G: uint8(y * 40), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// jpegWithExif encodes a w by h JPEG with an EXIF segment holding the given
+// orientation and a string standing in for GPS data.
+func jpegWithExif(t *testing.T, w, h, orientation int) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, testImage(w, h), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Big-endian TIFF header and an IFD0 with just the orientation tag
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	tiff = append(tiff, "GPSSecretPlace"...)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	data := encoded.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+// pngWithText encodes a w by h PNG with a tEXt chunk after the header.
+func pngWithText(t *testing.T, w, h int, text string) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, testImage(w, h)); err != nil {
+		t.Fatal(err)
+	}
+	data := encoded.Bytes()
+
+	// Signature (8) and IHDR (4 length + 4 type + 13 data + 4 CRC)
+	end := 8 + 25
+	out := append([]byte{}, data[:end]...)
+	out = append(out, pngChunk("tEXt", []byte("Comment\x00"+text))...)
+	return append(out, data[end:]...)
+}
+
+// pngHeader is just the start of a PNG claiming to be w by h, which is all
+// Process reads before rejecting it.
+func pngHeader(w, h int) []byte {
+	ihdr := binary.BigEndian.AppendUint32(nil, uint32(w))
+	ihdr = binary.BigEndian.AppendUint32(ihdr, uint32(h))
+	ihdr = append(ihdr, 8, 2, 0, 0, 0) // 8-bit RGB
+	return append([]byte("\x89PNG\r\n\x1a\n"), pngChunk("IHDR", ihdr)...)
+}
+
+func pngChunk(kind string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// animatedGIF encodes a w by h GIF with the given number of fw by fh frames.
+func animatedGIF(t *testing.T, w, h, frames, fw, fh int) []byte {
+	t.Helper()
+	g := &gif.GIF{Config: image.Config{Width: w, Height: h, ColorModel: color.Palette(palette.Plan9)}}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, fw, fh), palette.Plan9)
+		frame.SetColorIndex(0, 0, uint8(i))
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10)
+	}
+
+	var out bytes.Buffer
+	if err := gif.EncodeAll(&out, g); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,250 @@
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// mask replaces every masked match in the cleaned text.
+const mask = "****"
+
+// maxSpacedGap is how many separator characters may sit between the letters
+// of a spaced-out word ("k e r f u f f l e", "k.e.r.f") for it to still match.
+const maxSpacedGap = 3
+
+// Match is a single rule hit.
+type Match struct {
+	Rule Rule   `json:"rule"`
+	Text string `json:"text"`
+}
+
+// Result is the outcome of checking text against the engine's rules.
+type Result struct {
+	// Cleaned is the input with every mask match replaced by "****".
+	Cleaned string
+	// Rejected is set when any matching rule has the reject action.
+	Rejected bool
+	// Flagged is set when any matching rule has the flag action.
+	Flagged bool
+	Matches []Match
+}
+
+type compiledRule struct {
+	Rule
+	word string
+	re   *regexp.Regexp
+}
+
+// Engine checks text against a set of moderation rules. It is safe for
+// concurrent use, and its rules can be replaced while it is serving.
+type Engine struct {
+	mu    sync.RWMutex
+	rules []compiledRule
+}
+
+// NewEngine returns an engine loaded with rules.
+func NewEngine(rules []Rule) (*Engine, error) {
+	e := &Engine{}
+	if err := e.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// SetRules replaces the engine's rules. If any rule is invalid the existing
+// rules are kept.
+func (e *Engine) SetRules(rules []Rule) error {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		if err := ValidateRule(rule); err != nil {
+			return fmt.Errorf("rule %q: %w", rule.Pattern, err)
+		}
+
+		c := compiledRule{Rule: rule}
+		switch rule.Kind {
+		case KindWord:
+			c.word = normalizeWord(rule.Pattern)
+		case KindRegex:
+			c.re, _ = compileRegex(rule.Pattern)
+		}
+		compiled = append(compiled, c)
+	}
+
+	e.mu.Lock()
+	e.rules = compiled
+	e.mu.Unlock()
+	return nil
+}
+
+// Rules returns a copy of the engine's current rules.
+func (e *Engine) Rules() []Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	rules := make([]Rule, len(e.rules))
+	for i, c := range e.rules {
+		rules[i] = c.Rule
+	}
+	return rules
+}
+
+// Check matches text against every rule. Word rules match whole words after
+// normalization, ignoring case, accents and surrounding punctuation, and
+// also catch words spelled out with separators between the letters. Regex
+// rules run against the normalized (lower-cased, accent-free) text and
+// ignore case.
+func (e *Engine) Check(text string) Result {
+	e.mu.RLock()
+	rules := e.rules
+	e.mu.RUnlock()
+
+	n := normalize(text)
+	tokens := n.tokens()
+	runs := spacedRuns(tokens)
+
+	var normText string
+	var runeAt map[int]int
+	result := Result{Cleaned: text}
+	var masked []span
+
+	for _, rule := range rules {
+		var spans []span
+		switch rule.Kind {
+		case KindWord:
+			spans = matchWord(n, tokens, runs, rule.word)
+		case KindRegex:
+			if runeAt == nil {
+				normText, runeAt = n.byteIndex()
+			}
+			for _, loc := range rule.re.FindAllStringIndex(normText, -1) {
+				if loc[0] == loc[1] {
+					continue
+				}
+				spans = append(spans, n.original(runeAt[loc[0]], runeAt[loc[1]]))
+			}
+		}
+
+		for _, s := range spans {
+			result.Matches = append(result.Matches, Match{Rule: rule.Rule, Text: text[s.start:s.end]})
+			switch rule.Action {
+			case ActionMask:
+				masked = append(masked, s)
+			case ActionReject:
+				result.Rejected = true
+			case ActionFlag:
+				result.Flagged = true
+			}
+		}
+	}
+
+	if len(masked) > 0 {
+		result.Cleaned = applyMask(text, masked)
+	}
+
+	return result
+}
+
+// matchWord finds word either as a whole token or spelled out across a run
+// of single-character tokens.
+func matchWord(n normalized, tokens []token, runs [][]token, word string) []span {
+	var spans []span
+	for _, t := range tokens {
+		if t.text == word {
+			spans = append(spans, n.original(t.start, t.end))
+		}
+	}
+
+	length := utf8.RuneCountInString(word)
+	if length < 2 {
+		return spans
+	}
+
+	for _, run := range runs {
+		if len(run) < length {
+			continue
+		}
+
+		var b strings.Builder
+		for _, t := range run {
+			b.WriteString(t.text)
+		}
+		letters := []rune(b.String())
+
+		for i := 0; i+length <= len(letters); i++ {
+			if string(letters[i:i+length]) == word {
+				spans = append(spans, n.original(run[i].start, run[i+length-1].end))
+				i += length - 1
+			}
+		}
+	}
+
+	return spans
+}
+
+// spacedRuns groups consecutive single-character tokens that are separated
+// by at most maxSpacedGap characters.
+func spacedRuns(tokens []token) [][]token {
+	var runs [][]token
+	var current []token
+	for _, t := range tokens {
+		if t.end-t.start != 1 {
+			if len(current) > 1 {
+				runs = append(runs, current)
+			}
+			current = nil
+			continue
+		}
+		if len(current) > 0 && t.start-current[len(current)-1].end > maxSpacedGap {
+			if len(current) > 1 {
+				runs = append(runs, current)
+			}
+			current = nil
+		}
+		current = append(current, t)
+	}
+	if len(current) > 1 {
+		runs = append(runs, current)
+	}
+	return runs
+}
+
+// byteIndex returns the normalized text as a string and a map from each
+// rune boundary's byte offset to its rune index.
+func (n normalized) byteIndex() (string, map[int]int) {
+	s := string(n.runes)
+	runeAt := make(map[int]int, len(n.runes)+1)
+	i := 0
+	for offset := range s {
+		runeAt[offset] = i
+		i++
+	}
+	runeAt[len(s)] = i
+	return s, runeAt
+}
+
+// applyMask replaces each span of text with the mask, merging overlaps.
+func applyMask(text string, spans []span) string {
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			if s.end > last {
+				last = s.end
+			}
+			continue
+		}
+		b.WriteString(text[last:s.start])
+		b.WriteString(mask)
+		last = s.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package moderation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckNormalizesText(t *testing.T) {
+	engine, err := NewEngine([]Rule{
+		{Kind: KindWord, Pattern: "kerfuffle", Action: ActionMask},
+		{Kind: KindWord, Pattern: "café", Action: ActionFlag},
+		{Kind: KindRegex, Pattern: `fo+rnax`, Action: ActionReject},
+		{Kind: KindRegex, Pattern: `Sharbert\d`, Action: ActionReject},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		cleaned  string
+		rejected bool
+		flagged  bool
+	}{
+		{name: "plain", text: "what a kerfuffle", cleaned: "what a ****"},
+		{name: "upper case", text: "KERFUFFLE!", cleaned: "****!"},
+		{name: "accents", text: "kérfüfflé time", cleaned: "**** time"},
+		{name: "full-width", text: "ｋｅｒｆｕｆｆｌｅ", cleaned: "****"},
+		{name: "zero-width space", text: "kerf​uffle", cleaned: "****"},
+		{name: "punctuation", text: "(kerfuffle).", cleaned: "(****)."},
+		{name: "inside a longer word", text: "kerfuffles", cleaned: "kerfuffles"},
+		{name: "accented pattern matches plain text", text: "cafe", cleaned: "cafe", flagged: true},
+		{name: "ligature", text: "ﬁne", cleaned: "ﬁne"},
+		{name: "regex on folded text", text: "FÓÓRNAX", cleaned: "FÓÓRNAX", rejected: true},
+		{name: "upper-case regex pattern", text: "sharbert7", cleaned: "sharbert7", rejected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := engine.Check(tt.text)
+			if result.Cleaned != tt.cleaned {
+				t.Errorf("Cleaned = %q, want %q", result.Cleaned, tt.cleaned)
+			}
+			if result.Rejected != tt.rejected {
+				t.Errorf("Rejected = %v, want %v", result.Rejected, tt.rejected)
+			}
+			if result.Flagged != tt.flagged {
+				t.Errorf("Flagged = %v, want %v", result.Flagged, tt.flagged)
+			}
+		})
+	}
+}
+
+func TestCheckSpacedOutWords(t *testing.T) {
+	engine, err := NewEngine([]Rule{{Kind: KindWord, Pattern: "kerfuffle", Action: ActionMask}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		cleaned string
+	}{
+		{name: "spaces", text: "a k e r f u f f l e here", cleaned: "a **** here"},
+		{name: "dots", text: "k.e.r.f.u.f.f.l.e", cleaned: "****"},
+		{name: "mixed separators", text: "k-e r_f.u f-f l.e!", cleaned: "****!"},
+		{name: "gap too wide", text: "k    e r f u f f l e", cleaned: "k    e r f u f f l e"},
+		{name: "too few letters", text: "k e r f", cleaned: "k e r f"},
+		{name: "inside a longer run", text: "x k e r f u f f l e y", cleaned: "x **** y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := engine.Check(tt.text).Cleaned; got != tt.cleaned {
+				t.Errorf("Cleaned = %q, want %q", got, tt.cleaned)
+			}
+		})
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		err  string
+	}{
+		{name: "word", rule: Rule{Kind: KindWord, Pattern: "kerfuffle", Action: ActionMask}},
+		{name: "word with punctuation", rule: Rule{Kind: KindWord, Pattern: "kerf-uffle", Action: ActionMask}, err: "regex rule"},
+		{name: "apostrophe", rule: Rule{Kind: KindWord, Pattern: "can't", Action: ActionMask}, err: "regex rule"},
+		{name: "accents and case are fine", rule: Rule{Kind: KindWord, Pattern: "Kérfuffle", Action: ActionMask}},
+		{name: "regex", rule: Rule{Kind: KindRegex, Pattern: `bad\s+phrase`, Action: ActionReject}},
+		{name: "phrase as word", rule: Rule{Kind: KindWord, Pattern: "bad phrase", Action: ActionMask}, err: "single word"},
+		{name: "surrounding spaces are fine", rule: Rule{Kind: KindWord, Pattern: " kerfuffle ", Action: ActionFlag}},
+		{name: "empty pattern", rule: Rule{Kind: KindWord, Pattern: "  ", Action: ActionMask}, err: "empty"},
+		{name: "no letters", rule: Rule{Kind: KindWord, Pattern: "!!", Action: ActionMask}, err: "letters or digits"},
+		{name: "bad regex", rule: Rule{Kind: KindRegex, Pattern: "(", Action: ActionMask}, err: "invalid regex"},
+		{name: "unknown kind", rule: Rule{Kind: "glob", Pattern: "x*", Action: ActionMask}, err: "unknown kind"},
+		{name: "unknown action", rule: Rule{Kind: KindWord, Pattern: "x", Action: "ban"}, err: "unknown action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRule(tt.rule)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.err != "" && err == nil:
+				t.Errorf("expected an error containing %q", tt.err)
+			case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+				t.Errorf("error %q does not contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSetRulesKeepsRulesOnError(t *testing.T) {
+	engine, err := NewEngine(DefaultRules())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.SetRules([]Rule{{Kind: KindWord, Pattern: "two words", Action: ActionMask}}); err == nil {
+		t.Fatal("expected an error for a phrase word rule")
+	}
+	if got := len(engine.Rules()); got != len(DefaultRules()) {
+		t.Errorf("have %d rules, want the %d defaults", got, len(DefaultRules()))
+	}
+}
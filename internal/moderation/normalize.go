@@ -0,0 +1,102 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// span is a byte range in the original, un-normalized text.
+type span struct {
+	start, end int
+}
+
+// normalized is text folded for matching. Every rune keeps the byte span it
+// came from so matches can be mapped back onto the original text.
+type normalized struct {
+	runes []rune
+	spans []span
+}
+
+// token is a run of letters or digits in normalized text, as rune indexes.
+type token struct {
+	text       string
+	start, end int
+}
+
+// normalize folds s to lower case, decomposes compatibility characters
+// (full-width letters, ligatures), strips accents and drops invisible
+// formatting characters such as zero-width spaces.
+func normalize(s string) normalized {
+	var n normalized
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		for _, folded := range foldRune(r) {
+			n.runes = append(n.runes, folded)
+			n.spans = append(n.spans, span{start: i, end: i + size})
+		}
+		i += size
+	}
+	return n
+}
+
+func foldRune(r rune) []rune {
+	if unicode.Is(unicode.Cf, r) {
+		return nil
+	}
+
+	var out []rune
+	for _, d := range norm.NFKD.String(string(r)) {
+		if unicode.Is(unicode.Mn, d) {
+			continue
+		}
+		out = append(out, unicode.ToLower(d))
+	}
+	return out
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// normalizeWord folds a word pattern the same way text is folded and keeps
+// only its letters and digits.
+func normalizeWord(s string) string {
+	var b strings.Builder
+	for _, r := range normalize(s).runes {
+		if isWordRune(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// tokens splits normalized text on anything that is not a letter or digit,
+// so punctuation next to a word does not hide it.
+func (n normalized) tokens() []token {
+	var tokens []token
+	start := -1
+	for i, r := range n.runes {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, token{text: string(n.runes[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{text: string(n.runes[start:]), start: start, end: len(n.runes)})
+	}
+	return tokens
+}
+
+// original returns the span of the original text covered by runes [start, end).
+func (n normalized) original(start, end int) span {
+	return span{start: n.spans[start].start, end: n.spans[end-1].end}
+}
@@ -0,0 +1,112 @@
+package moderation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Kind describes how a rule's pattern is matched against text.
+type Kind string
+
+const (
+	KindWord  Kind = "word"
+	KindRegex Kind = "regex"
+)
+
+// Action describes what happens to text that matches a rule.
+type Action string
+
+const (
+	ActionMask   Action = "mask"
+	ActionReject Action = "reject"
+	ActionFlag   Action = "flag"
+)
+
+// Rule is a single moderation rule. Rules loaded from a file have no ID.
+type Rule struct {
+	ID      uuid.UUID `json:"id,omitempty"`
+	Kind    Kind      `json:"kind"`
+	Pattern string    `json:"pattern"`
+	Action  Action    `json:"action"`
+}
+
+// DefaultRules returns the word list used before rules were configurable.
+func DefaultRules() []Rule {
+	return []Rule{
+		{Kind: KindWord, Pattern: "kerfuffle", Action: ActionMask},
+		{Kind: KindWord, Pattern: "sharbert", Action: ActionMask},
+		{Kind: KindWord, Pattern: "fornax", Action: ActionMask},
+	}
+}
+
+// ValidateRule checks that a rule has a known kind and action and a usable pattern.
+func ValidateRule(rule Rule) error {
+	switch rule.Action {
+	case ActionMask, ActionReject, ActionFlag:
+	default:
+		return fmt.Errorf("unknown action %q", rule.Action)
+	}
+
+	if strings.TrimSpace(rule.Pattern) == "" {
+		return errors.New("pattern cannot be empty")
+	}
+
+	switch rule.Kind {
+	case KindWord:
+		if len(normalizeWord(rule.Pattern)) == 0 {
+			return errors.New("word pattern must contain letters or digits")
+		}
+		// Text is matched one word at a time, so a phrase could never match
+		if strings.ContainsFunc(strings.TrimSpace(rule.Pattern), unicode.IsSpace) {
+			return errors.New("word pattern must be a single word; use a regex rule for phrases")
+		}
+		// Text is split into words on punctuation too, so "can't" is read
+		// as "can" and "t" and a word pattern of "can't" could never match
+		if string(normalize(strings.TrimSpace(rule.Pattern)).runes) != normalizeWord(rule.Pattern) {
+			return errors.New("word pattern must only contain letters and digits; use a regex rule for words with punctuation")
+		}
+	case KindRegex:
+		if _, err := compileRegex(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown kind %q", rule.Kind)
+	}
+
+	return nil
+}
+
+// compileRegex compiles a regex rule's pattern. Text is lower-cased before
+// regex rules run, so the pattern ignores case; otherwise upper-case
+// letters in it could never match.
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// LoadRulesFile reads a JSON array of rules from path.
+func LoadRulesFile(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("decoding rules file %s: %w", path, err)
+	}
+
+	for i, rule := range rules {
+		if err := ValidateRule(rule); err != nil {
+			return nil, fmt.Errorf("rule %d in %s: %w", i, path, err)
+		}
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,103 @@
+package pubsub
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	minReconnectInterval = 10 * time.Second
+	maxReconnectInterval = time.Minute
+	// Without traffic the listener's connection is pinged this often, so a
+	// dead connection is noticed and replaced.
+	listenerPingInterval = 90 * time.Second
+)
+
+// Postgres is a Broker shared by every server instance using the same
+// database. Messages go out with NOTIFY and arrive through a dedicated
+// LISTEN connection, so payloads must stay under Postgres's limit of about
+// 8000 bytes.
+type Postgres struct {
+	db       *sql.DB
+	listener *pq.Listener
+	hub      *hub
+
+	mu        sync.Mutex
+	listening map[string]bool
+	done      chan struct{}
+}
+
+// NewPostgres returns a Broker that publishes through db and listens on a
+// connection of its own opened from dsn.
+func NewPostgres(db *sql.DB, dsn string) *Postgres {
+	listener := pq.NewListener(dsn, minReconnectInterval, maxReconnectInterval, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("pubsub: listener: %v", err)
+		}
+	})
+	p := &Postgres{
+		db:        db,
+		listener:  listener,
+		hub:       newHub(),
+		listening: make(map[string]bool),
+		done:      make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *Postgres) Publish(ctx context.Context, channel string, payload []byte) error {
+	_, err := p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, string(payload))
+	return err
+}
+
+// Subscribe listens on the channel the first time anyone subscribes to it.
+// If that fails, the next subscriber tries again.
+func (p *Postgres) Subscribe(channel string, buffer int) *Subscription {
+	sub, _ := p.hub.add(channel, buffer)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.listening[channel] {
+		if err := p.listener.Listen(channel); err != nil && err != pq.ErrChannelAlreadyOpen {
+			log.Printf("pubsub: listen on %s: %v", channel, err)
+			return sub
+		}
+		p.listening[channel] = true
+	}
+	return sub
+}
+
+// Close stops listening and closes every subscription.
+func (p *Postgres) Close() error {
+	close(p.done)
+	p.hub.dropAll()
+	return p.listener.Close()
+}
+
+func (p *Postgres) run() {
+	for {
+		select {
+		case <-p.done:
+			return
+		case n, ok := <-p.listener.Notify:
+			if !ok {
+				return
+			}
+			// A nil notification means the connection was re-established;
+			// anything sent in between is lost
+			if n == nil {
+				p.hub.dropAll()
+				continue
+			}
+			p.hub.deliver(n.Channel, []byte(n.Extra))
+		case <-time.After(listenerPingInterval):
+			go p.listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Package pubsub fans messages out to subscribers, either within one process
+// or, through Postgres LISTEN/NOTIFY, across every server instance.
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// Broker publishes messages on named channels and hands them to every
+// subscriber of the channel.
+type Broker interface {
+	Publish(ctx context.Context, channel string, payload []byte) error
+	// Subscribe starts receiving the channel's messages. Up to buffer
+	// messages are held for a slow subscriber; see Subscription.
+	Subscribe(channel string, buffer int) *Subscription
+}
+
+// Subscription receives the messages published on one channel. Delivery
+// never blocks the publisher: a subscriber that falls more than its buffer
+// behind is dropped and C is closed, so it can catch up from wherever the
+// messages are stored and subscribe again. C is also closed by Close.
+type Subscription struct {
+	C <-chan []byte
+
+	c       chan []byte
+	channel string
+	hub     *hub
+	closed  bool
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+// hub keeps track of local subscribers and delivers messages to them.
+type hub struct {
+	mu   sync.Mutex
+	subs map[string]map[*Subscription]struct{}
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[string]map[*Subscription]struct{})}
+}
+
+// add registers a subscription and reports whether it is the channel's
+// first.
+func (h *hub) add(channel string, buffer int) (*Subscription, bool) {
+	c := make(chan []byte, buffer)
+	sub := &Subscription{C: c, c: c, channel: channel, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	first := len(h.subs[channel]) == 0
+	if first {
+		h.subs[channel] = make(map[*Subscription]struct{})
+	}
+	h.subs[channel][sub] = struct{}{}
+	return sub, first
+}
+
+func (h *hub) remove(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(sub)
+}
+
+// drop must be called with mu held.
+func (h *hub) drop(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.c)
+	delete(h.subs[sub.channel], sub)
+}
+
+func (h *hub) deliver(channel string, payload []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs[channel] {
+		select {
+		case sub.c <- payload:
+		default:
+			h.drop(sub)
+		}
+	}
+}
+
+// dropAll closes every subscription, telling subscribers they may have
+// missed messages.
+func (h *hub) dropAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, subs := range h.subs {
+		for sub := range subs {
+			h.drop(sub)
+		}
+	}
+}
+
+// Memory is a Broker for a single server instance.
+type Memory struct {
+	hub *hub
+}
+
+// NewMemory returns an in-process Broker.
+func NewMemory() *Memory {
+	return &Memory{hub: newHub()}
+}
+
+func (m *Memory) Publish(ctx context.Context, channel string, payload []byte) error {
+	m.hub.deliver(channel, payload)
+	return nil
+}
+
+func (m *Memory) Subscribe(channel string, buffer int) *Subscription {
+	sub, _ := m.hub.add(channel, buffer)
+	return sub
+}
@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when no object exists for a key.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage stores uploaded files by key. Keys are slash-separated relative
+// paths chosen by the caller, such as "media/3f2a.jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
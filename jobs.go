@@ -0,0 +1,14 @@
+package main
+
+// registerJobs wires up the handlers for every kind of background job and
+// the periodic sweeps.
+func (cfg *apiConfig) registerJobs() {
+	cfg.jobs.Handle(jobDeleteAccount, cfg.runDeleteAccount)
+	cfg.jobs.Handle(jobExportUserData, cfg.runExportUserData)
+	cfg.jobs.Handle(jobDeliverActivity, cfg.runDeliverActivity)
+
+	cfg.jobs.Every("purge_deleted_gobits", gobitPurgeInterval, cfg.purgeDeletedGobits)
+	cfg.jobs.Every("publish_scheduled_gobits", schedulerInterval, cfg.publishDueGobits)
+	cfg.jobs.Every("close_expired_polls", pollCloseInterval, cfg.closeExpiredPolls)
+	cfg.jobs.Every("prune_gobit_events", gobitEventPruneInterval, cfg.pruneGobitEvents)
+}
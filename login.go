@@ -9,7 +9,6 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/twomotive/gohost/internal/auth"
-	"github.com/twomotive/gohost/internal/database"
 )
 
 type loginRequest struct {
@@ -67,32 +66,39 @@ func (cfg *apiConfig) userLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jwtExpiresIn := time.Hour
-
-	// Generate JWT
-	tokenString, err := auth.MakeJWT(user.ID, cfg.jwtSecret, jwtExpiresIn)
-	if err != nil {
-		log.Printf("Error generating JWT for user %s: %v", user.Email, err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	// Banned and suspended accounts cannot sign in
+	if message, locked := accountLockout(user); locked {
+		log.Printf("Login refused for %s account: %s", user.AccountStatus, req.Email)
+		http.Error(w, message, http.StatusForbidden)
 		return
 	}
 
+	// Logging in during the grace period cancels a pending account deletion
+	if user.DeletionScheduledFor.Valid {
+		cancelled, err := cfg.db.CancelUserDeletion(r.Context(), user.ID)
+		if err != nil {
+			log.Printf("Error cancelling deletion for user %s: %v", user.ID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Account deletion cancelled by login: %s", user.ID)
+		user = cancelled
+	}
+
 	// Generate Refresh Token
-	refreshTokenString, err := auth.MakeRefreshToken()
+	refreshTokenString, err := issueRefreshToken(r.Context(), cfg.db, user.ID)
 	if err != nil {
-		log.Printf("Error generating refresh token for user %s: %v", user.Email, err)
+		log.Printf("Error storing refresh token for user %s: %v", user.Email, err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	refreshTokenExpiresAt := time.Now().Add(60 * 24 * time.Hour) // 60 days expiration
-	_, err = cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		Token:     refreshTokenString,
-		UserID:    user.ID,
-		ExpiresAt: refreshTokenExpiresAt,
-	})
+	jwtExpiresIn := time.Hour
+
+	// Generate JWT, tied to the session the refresh token starts
+	tokenString, err := auth.MakeJWT(user.ID, auth.SessionID(refreshTokenString), cfg.jwtSecret, jwtExpiresIn)
 	if err != nil {
-		log.Printf("Error storing refresh token for user %s: %v", user.Email, err)
+		log.Printf("Error generating JWT for user %s: %v", user.Email, err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -1,23 +1,55 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	"github.com/twomotive/gohost/internal/activitypub"
 	"github.com/twomotive/gohost/internal/database"
+	"github.com/twomotive/gohost/internal/jobs"
+	"github.com/twomotive/gohost/internal/mailer"
+	"github.com/twomotive/gohost/internal/moderation"
+	"github.com/twomotive/gohost/internal/pubsub"
+	"github.com/twomotive/gohost/internal/storage"
 )
 
 type apiConfig struct {
 	fileServerHits atomic.Int32
 	db             *database.Queries
+	sqlDB          *sql.DB // for transactions; queries go through db
 	jwtSecret      string
 	stripKey       string // Add stripKey field
+
+	moderation          *moderation.Engine
+	fileModerationRules []moderation.Rule
+
+	storage        storage.Storage
+	maxUploadBytes int64
+
+	mailer mailer.Mailer
+
+	jobs                 *jobs.Queue
+	pubsub               pubsub.Broker
+	gobitEvents          *gobitEventFanout
+	accountDeletionGrace time.Duration
+	gobitTrashRetention  time.Duration
+
+	// publicURL is where clients reach the server, used for absolute links
+	publicURL string
+
+	// apiV1Sunset is announced on version 1 responses as the date they stop
+	// working
+	apiV1Sunset time.Time
 }
 
 func main() {
@@ -44,61 +76,149 @@ func main() {
 
 	dbQueries := database.New(db)
 
-	apiCfg := &apiConfig{
-		fileServerHits: atomic.Int32{},
-		db:             dbQueries,
-		jwtSecret:      jwtSecret,
-		stripKey:       stripKey, // Store stripKey in config
+	// Optional static moderation rules, applied alongside the rules in the database
+	var fileModerationRules []moderation.Rule
+	if rulesFile := os.Getenv("MODERATION_RULES_FILE"); rulesFile != "" {
+		fileModerationRules, err = moderation.LoadRulesFile(rulesFile)
+		if err != nil {
+			log.Fatalf("Error loading moderation rules: %s", err)
+		}
 	}
 
-	mux := http.NewServeMux()
-
-	server := &http.Server{
-		Addr:    ":8080",
-		Handler: mux,
+	moderationEngine, err := moderation.NewEngine(fileModerationRules)
+	if err != nil {
+		log.Fatalf("Error building moderation engine: %s", err)
 	}
 
-	// Update fileserver paths with metrics middleware
-	fileServer := http.FileServer(http.Dir("."))
-	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app/", fileServer)))
-	mux.Handle("/assets/", apiCfg.middlewareMetricsInc(http.FileServer(http.Dir("assets"))))
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "uploads"
+	}
+	mediaStorage, err := storage.NewLocal(mediaDir)
+	if err != nil {
+		log.Fatalf("Error opening media storage: %s", err)
+	}
 
-	// Add health check endpoint
-	mux.HandleFunc("GET /api/healthz", HandleReadiness)
+	maxUploadBytes := int64(defaultMaxUploadBytes)
+	if v := os.Getenv("MEDIA_MAX_UPLOAD_BYTES"); v != "" {
+		maxUploadBytes, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || maxUploadBytes <= 0 {
+			log.Fatalf("MEDIA_MAX_UPLOAD_BYTES must be a positive integer")
+		}
+	}
 
-	// Add metrics endpoint
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handleMetrics)
+	// How long a deleted account can still be recovered by logging in
+	accountDeletionGrace := defaultAccountDeletionGrace
+	if v := os.Getenv("ACCOUNT_DELETION_GRACE"); v != "" {
+		accountDeletionGrace, err = time.ParseDuration(v)
+		if err != nil || accountDeletionGrace < 0 {
+			log.Fatalf("ACCOUNT_DELETION_GRACE must be a duration such as 720h")
+		}
+	}
 
-	// Add reset endpoint
-	mux.HandleFunc("POST /admin/reset", apiCfg.handleReset)
+	// How long deleted gobits stay in the trash before they are purged
+	gobitTrashRetention := defaultGobitTrashRetention
+	if v := os.Getenv("GOBIT_TRASH_RETENTION"); v != "" {
+		gobitTrashRetention, err = time.ParseDuration(v)
+		if err != nil || gobitTrashRetention <= 0 {
+			log.Fatalf("GOBIT_TRASH_RETENTION must be a duration such as 720h")
+		}
+	}
 
-	// Add validate endpoint
-	mux.HandleFunc("POST /api/validate", handleValidate)
+	// Streams on one instance only hear about events from the others when
+	// they share Postgres LISTEN/NOTIFY
+	var broker pubsub.Broker
+	switch os.Getenv("PUBSUB_BACKEND") {
+	case "", "memory":
+		broker = pubsub.NewMemory()
+	case "postgres":
+		broker = pubsub.NewPostgres(db, dbURL)
+	default:
+		log.Fatalf("PUBSUB_BACKEND must be memory or postgres")
+	}
 
-	// Add users api endpoint to create users
-	mux.HandleFunc("POST /api/users", apiCfg.createUsers)
+	// Absolute links in feeds use PUBLIC_URL, or the request's own host.
+	// Federation is only enabled when it is set.
+	publicURL := strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/")
+
+	// When version 1 of the gobits and users API goes away
+	apiV1Sunset := defaultAPIV1Sunset
+	if v := os.Getenv("API_V1_SUNSET"); v != "" {
+		apiV1Sunset, err = time.Parse(time.DateOnly, v)
+		if err != nil {
+			log.Fatalf("API_V1_SUNSET must be a date such as 2027-04-19")
+		}
+	}
 
-	mux.HandleFunc("PUT /api/users", apiCfg.updateUser)
+	// Federating over plain http or with servers on the local network is
+	// only for development
+	activitypub.SetDevelopment(os.Getenv("PLATFORM") == "dev")
+
+	// Mail goes out over SMTP, or is only logged, which suits development
+	var mail mailer.Mailer
+	switch os.Getenv("MAILER") {
+	case "smtp":
+		mail, err = mailer.NewSMTP(os.Getenv("SMTP_ADDR"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("SMTP_FROM"))
+		if err != nil {
+			log.Fatalf("Error configuring SMTP: %s", err)
+		}
+	case "log":
+		mail = mailer.NewLog()
+	case "":
+		if os.Getenv("PLATFORM") != "dev" {
+			log.Printf("WARNING: MAILER is not set, so mail (such as email verification) is only logged and never sent. Set MAILER=smtp to send it, or MAILER=log to keep logging it.")
+		}
+		mail = mailer.NewLog()
+	default:
+		log.Fatalf("MAILER must be smtp or log")
+	}
 
-	mux.HandleFunc("POST /api/login", apiCfg.userLogin)
+	apiCfg := &apiConfig{
+		fileServerHits:       atomic.Int32{},
+		db:                   dbQueries,
+		sqlDB:                db,
+		jwtSecret:            jwtSecret,
+		stripKey:             stripKey, // Store stripKey in config
+		moderation:           moderationEngine,
+		fileModerationRules:  fileModerationRules,
+		storage:              mediaStorage,
+		maxUploadBytes:       maxUploadBytes,
+		mailer:               mail,
+		jobs:                 jobs.NewQueue(dbQueries),
+		pubsub:               broker,
+		accountDeletionGrace: accountDeletionGrace,
+		gobitTrashRetention:  gobitTrashRetention,
+		publicURL:            publicURL,
+		apiV1Sunset:          apiV1Sunset,
+	}
 
-	// Add refresh token endpoint
-	mux.HandleFunc("POST /api/refresh", apiCfg.handleRefresh)
+	if err := apiCfg.reloadModerationRules(context.Background()); err != nil {
+		log.Printf("Error loading moderation rules from database, falling back to defaults: %s", err)
+		moderationEngine.SetRules(append(fileModerationRules, moderation.DefaultRules()...))
+	}
+	go apiCfg.watchModerationRules(context.Background())
 
-	// Add revoke token endpoint
-	mux.HandleFunc("POST /api/revoke", apiCfg.handleRevoke)
+	apiCfg.gobitEvents = newGobitEventFanout(apiCfg)
+	go apiCfg.gobitEvents.run(context.Background())
 
-	mux.HandleFunc("POST /api/gobits", apiCfg.createGoBits)
+	apiCfg.registerJobs()
+	go apiCfg.jobs.Run(context.Background())
 
-	mux.HandleFunc("GET /api/gobits", apiCfg.getAllGoBits)
+	mux := http.NewServeMux()
 
-	mux.HandleFunc("GET /api/gobits/{gobitID}", apiCfg.getGoBitByID)
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
 
-	mux.HandleFunc("DELETE /api/gobits/{gobitID}", apiCfg.deleteGobit)
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: mux,
+	}
 
-	mux.HandleFunc("POST /api/strip/webhooks", apiCfg.handleStripWebhook)
+	apiCfg.registerRoutes(mux)
 
-	fmt.Println("Server starting on http://localhost:8080")
+	fmt.Println("Server starting on http://localhost:" + port)
 	if err := server.ListenAndServe(); err != nil {
 		fmt.Printf("Server error: %v\\n", err)
 	}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+	"github.com/twomotive/gohost/internal/media"
+	"github.com/twomotive/gohost/internal/storage"
+)
+
+const (
+	defaultMaxUploadBytes = 5 << 20
+	maxMediaPerGobit      = 4
+)
+
+type mediaResponse struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+}
+
+type avatarRequest struct {
+	MediaID *uuid.UUID `json:"media_id"`
+}
+
+func mediaURL(id uuid.UUID) string {
+	return "/media/" + id.String()
+}
+
+func toMediaResponse(file database.MediaFile) mediaResponse {
+	return mediaResponse{
+		ID:           file.ID,
+		CreatedAt:    file.CreatedAt,
+		ContentType:  file.ContentType,
+		SizeBytes:    file.SizeBytes,
+		Width:        file.Width,
+		Height:       file.Height,
+		URL:          mediaURL(file.ID),
+		ThumbnailURL: mediaURL(file.ID) + "/thumbnail",
+	}
+}
+
+// gobitMedia looks up the attached media for each gobit, keyed by gobit ID
+// and in attachment order.
+func (cfg *apiConfig) gobitMedia(ctx context.Context, gobitIDs []uuid.UUID) (map[uuid.UUID][]mediaResponse, error) {
+	rows, err := cfg.db.ListMediaForGobits(ctx, gobitIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	attached := make(map[uuid.UUID][]mediaResponse)
+	for _, row := range rows {
+		attached[row.GobitID] = append(attached[row.GobitID], toMediaResponse(database.MediaFile{
+			ID:                   row.ID,
+			CreatedAt:            row.CreatedAt,
+			UserID:               row.UserID,
+			ContentType:          row.ContentType,
+			SizeBytes:            row.SizeBytes,
+			Width:                row.Width,
+			Height:               row.Height,
+			StorageKey:           row.StorageKey,
+			ThumbnailContentType: row.ThumbnailContentType,
+			ThumbnailKey:         row.ThumbnailKey,
+		}))
+	}
+	return attached, nil
+}
+
+// ownedMedia checks that every ID refers to media uploaded by userID. On
+// failure it writes the error response and returns false.
+func (cfg *apiConfig) ownedMedia(w http.ResponseWriter, r *http.Request, userID uuid.UUID, ids []uuid.UUID) bool {
+	for _, id := range ids {
+		file, err := cfg.db.GetMediaFile(r.Context(), id)
+		if err != nil && err != sql.ErrNoRows {
+			log.Printf("Error getting media %s: %v", id, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return false
+		}
+		if err == sql.ErrNoRows || file.UserID != userID {
+			http.Error(w, "media not found: "+id.String(), http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
+func (cfg *apiConfig) uploadMedia(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for upload: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for upload: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting uploader %s: %v", userID, err)
+		http.Error(w, "Unauthorized: Unknown user", http.StatusUnauthorized)
+		return
+	}
+	if message, locked := accountLockout(user); locked {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
+
+	// Leave some room for the multipart framing around the file itself
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.maxUploadBytes+1<<20)
+	if err := r.ParseMultipartForm(cfg.maxUploadBytes); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		}
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Missing form file \"file\"", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > cfg.maxUploadBytes {
+		http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, cfg.maxUploadBytes+1))
+	if err != nil {
+		log.Printf("Error reading upload: %v", err)
+		http.Error(w, "Failed to read upload", http.StatusBadRequest)
+		return
+	}
+	if int64(len(data)) > cfg.maxUploadBytes {
+		http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	img, err := media.Process(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, media.ErrUnsupportedType):
+			http.Error(w, "Unsupported file type: only JPEG, PNG and GIF images are accepted", http.StatusUnsupportedMediaType)
+		case errors.Is(err, media.ErrTooLarge):
+			http.Error(w, "Image dimensions are too large", http.StatusRequestEntityTooLarge)
+		default:
+			log.Printf("Error processing upload: %v", err)
+			http.Error(w, "Invalid image", http.StatusBadRequest)
+		}
+		return
+	}
+
+	mediaID := uuid.New()
+	key := "media/" + mediaID.String() + img.Ext
+	thumbnailKey := "media/" + mediaID.String() + "_thumb" + img.ThumbnailExt
+
+	if err := cfg.storage.Put(r.Context(), key, bytes.NewReader(img.Data)); err != nil {
+		log.Printf("Error storing upload %s: %v", key, err)
+		http.Error(w, "Failed to store upload", http.StatusInternalServerError)
+		return
+	}
+	if err := cfg.storage.Put(r.Context(), thumbnailKey, bytes.NewReader(img.Thumbnail)); err != nil {
+		log.Printf("Error storing thumbnail %s: %v", thumbnailKey, err)
+		http.Error(w, "Failed to store upload", http.StatusInternalServerError)
+		return
+	}
+
+	mediaFile, err := cfg.db.CreateMediaFile(r.Context(), database.CreateMediaFileParams{
+		ID:                   mediaID,
+		UserID:               userID,
+		ContentType:          img.ContentType,
+		SizeBytes:            int64(len(img.Data)),
+		Width:                int32(img.Width),
+		Height:               int32(img.Height),
+		StorageKey:           key,
+		ThumbnailContentType: img.ThumbnailContentType,
+		ThumbnailKey:         thumbnailKey,
+	})
+	if err != nil {
+		log.Printf("cannot create media record: %v", err)
+		http.Error(w, "Failed to store upload", http.StatusInternalServerError)
+		return
+	}
+
+	data, err = json.Marshal(toMediaResponse(mediaFile))
+	if err != nil {
+		log.Printf("Error marshalling media response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request) {
+	cfg.streamMedia(w, r, false)
+}
+
+func (cfg *apiConfig) serveMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	cfg.streamMedia(w, r, true)
+}
+
+// mediaVisibleTo reports whether the viewer may see an uploaded file: the
+// uploader always can, anyone can see an avatar, and otherwise the file must
+// be attached to a gobit the viewer can open. Unattached uploads stay with
+// the uploader.
+func (cfg *apiConfig) mediaVisibleTo(ctx context.Context, file database.MediaFile, viewerID uuid.NullUUID) (visible, avatar bool, err error) {
+	avatar, err = cfg.db.IsAvatarMedia(ctx, uuid.NullUUID{UUID: file.ID, Valid: true})
+	if err != nil || avatar {
+		return avatar, avatar, err
+	}
+	if viewerID.Valid && viewerID.UUID == file.UserID {
+		return true, false, nil
+	}
+
+	gobitIDs, err := cfg.db.ListGobitIDsForMedia(ctx, file.ID)
+	if err != nil || len(gobitIDs) == 0 {
+		return false, false, err
+	}
+	gobits, err := cfg.db.GetVisibleGobitsByIDs(ctx, database.GetVisibleGobitsByIDsParams{
+		Ids:      gobitIDs,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		return false, false, err
+	}
+	return len(gobits) > 0, false, nil
+}
+
+// streamMedia writes an uploaded file, or its thumbnail, from storage, to
+// those who can see it.
+func (cfg *apiConfig) streamMedia(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	mediaID, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		http.Error(w, "Invalid media ID format", http.StatusBadRequest)
+		return
+	}
+
+	file, err := cfg.db.GetMediaFile(r.Context(), mediaID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "media not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error getting media %s: %v", mediaID, err)
+			http.Error(w, "Failed to get media", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	visible, avatar, err := cfg.mediaVisibleTo(r.Context(), file, cfg.optionalViewer(r))
+	if err != nil {
+		log.Printf("Error checking visibility of media %s: %v", mediaID, err)
+		http.Error(w, "Failed to get media", http.StatusInternalServerError)
+		return
+	}
+	if !visible {
+		http.Error(w, "media not found", http.StatusNotFound)
+		return
+	}
+
+	key, contentType := file.StorageKey, file.ContentType
+	if thumbnail {
+		key, contentType = file.ThumbnailKey, file.ThumbnailContentType
+	}
+
+	body, err := cfg.storage.Open(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "media not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error opening media %s: %v", key, err)
+			http.Error(w, "Failed to get media", http.StatusInternalServerError)
+		}
+		return
+	}
+	defer body.Close()
+
+	// Media never changes once uploaded, but who can see it does: only
+	// avatars are cached publicly, and other media only briefly
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if avatar {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "private, max-age=300")
+		w.Header().Add("Vary", "Authorization")
+	}
+	if key == file.StorageKey {
+		w.Header().Set("Content-Length", strconv.FormatInt(file.SizeBytes, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
+
+func (cfg *apiConfig) updateAvatar(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for avatar update: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for avatar update: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req avatarRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("JSON avatar decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return
+	}
+
+	// A null media_id removes the avatar
+	var avatarID uuid.NullUUID
+	if req.MediaID != nil {
+		if !cfg.ownedMedia(w, r, userID, []uuid.UUID{*req.MediaID}) {
+			return
+		}
+		avatarID = uuid.NullUUID{UUID: *req.MediaID, Valid: true}
+	}
+
+	user, err := cfg.db.SetUserAvatar(r.Context(), database.SetUserAvatarParams{
+		ID:            userID,
+		AvatarMediaID: avatarID,
+	})
+	if err != nil {
+		log.Printf("cannot set avatar for user %s: %v", userID, err)
+		http.Error(w, "Internal server error updating avatar", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(toPublicProfile(user))
+	if err != nil {
+		log.Printf("Error marshalling profile response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
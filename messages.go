@@ -0,0 +1,848 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	// maxConversationMembers includes whoever starts the conversation
+	maxConversationMembers = 8
+	maxMessageLength       = 1000
+
+	defaultConversationsLimit = 20
+	maxConversationsLimit     = 100
+	defaultMessagesLimit      = 50
+	maxMessagesLimit          = 100
+
+	// messagesChannel is the pub/sub channel conversation activity goes out on
+	messagesChannel = "messages"
+
+	eventMessageCreated   = "message.created"
+	eventMessageDeleted   = "message.deleted"
+	eventConversationRead = "conversation.read"
+
+	blockedConversationMsg = "You cannot message users you have blocked or who have blocked you"
+)
+
+type conversationRequest struct {
+	MemberIDs []uuid.UUID `json:"member_ids"`
+}
+
+type messageRequest struct {
+	Body string `json:"body"`
+}
+
+type conversationMemberResponse struct {
+	User       authorSummary `json:"user"`
+	LastReadAt *time.Time    `json:"last_read_at,omitempty"`
+}
+
+type conversationResponse struct {
+	ID        uuid.UUID                    `json:"id"`
+	CreatedAt time.Time                    `json:"created_at"`
+	UpdatedAt time.Time                    `json:"updated_at"`
+	IsGroup   bool                         `json:"is_group"`
+	Members   []conversationMemberResponse `json:"members"`
+	// UnreadCount is how many messages from others the caller hasn't read
+	UnreadCount int64            `json:"unread_count"`
+	LastMessage *messageResponse `json:"last_message,omitempty"`
+}
+
+type messageResponse struct {
+	ID             uuid.UUID `json:"id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Body           string    `json:"body"`
+	// ReadBy lists the other members who have read the message
+	ReadBy []uuid.UUID `json:"read_by"`
+}
+
+type conversationPage struct {
+	Conversations []conversationResponse `json:"conversations"`
+	NextCursor    string                 `json:"next_cursor,omitempty"`
+}
+
+type messagePage struct {
+	Messages   []messageResponse `json:"messages"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// messageEvent goes out over pub/sub when a message is sent or deleted, or
+// a member reads a conversation.
+type messageEvent struct {
+	Type           string     `json:"type"`
+	ConversationID uuid.UUID  `json:"conversation_id"`
+	MessageID      *uuid.UUID `json:"message_id,omitempty"`
+	UserID         uuid.UUID  `json:"user_id"`
+	ReadAt         *time.Time `json:"read_at,omitempty"`
+}
+
+// toMessageResponse works out read receipts from how far each member has
+// read.
+func toMessageResponse(message database.Message, members []database.ConversationMember) messageResponse {
+	response := messageResponse{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		CreatedAt:      message.CreatedAt,
+		Body:           message.Body,
+		ReadBy:         []uuid.UUID{},
+	}
+	for _, member := range members {
+		if member.UserID != message.SenderID && member.LastReadAt.Valid && !member.LastReadAt.Time.Before(message.CreatedAt) {
+			response.ReadBy = append(response.ReadBy, member.UserID)
+		}
+	}
+	return response
+}
+
+// conversationResponses fills in the members, unread counts and latest
+// message of each conversation, as seen by userID.
+func (cfg *apiConfig) conversationResponses(ctx context.Context, userID uuid.UUID, conversations []database.Conversation) ([]conversationResponse, error) {
+	responses := make([]conversationResponse, len(conversations))
+	if len(conversations) == 0 {
+		return responses, nil
+	}
+
+	conversationIDs := make([]uuid.UUID, len(conversations))
+	for i, conversation := range conversations {
+		conversationIDs[i] = conversation.ID
+	}
+
+	members, err := cfg.db.ListConversationMembers(ctx, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+	membersOf := make(map[uuid.UUID][]database.ConversationMember)
+	var userIDs []uuid.UUID
+	for _, member := range members {
+		membersOf[member.ConversationID] = append(membersOf[member.ConversationID], member)
+		userIDs = append(userIDs, member.UserID)
+	}
+
+	users, err := cfg.db.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make(map[uuid.UUID]authorSummary, len(users))
+	for _, user := range users {
+		summaries[user.ID] = toAuthorSummary(user)
+	}
+
+	unread, err := cfg.db.CountUnreadMessages(ctx, database.CountUnreadMessagesParams{
+		UserID:          userID,
+		ConversationIds: conversationIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	unreadCounts := make(map[uuid.UUID]int64, len(unread))
+	for _, row := range unread {
+		unreadCounts[row.ConversationID] = row.UnreadCount
+	}
+
+	latest, err := cfg.db.ListLatestMessages(ctx, conversationIDs)
+	if err != nil {
+		return nil, err
+	}
+	latestIn := make(map[uuid.UUID]database.Message, len(latest))
+	for _, message := range latest {
+		latestIn[message.ConversationID] = message
+	}
+
+	for i, conversation := range conversations {
+		response := conversationResponse{
+			ID:          conversation.ID,
+			CreatedAt:   conversation.CreatedAt,
+			UpdatedAt:   conversation.UpdatedAt,
+			IsGroup:     conversation.IsGroup,
+			Members:     []conversationMemberResponse{},
+			UnreadCount: unreadCounts[conversation.ID],
+		}
+		for _, member := range membersOf[conversation.ID] {
+			memberResponse := conversationMemberResponse{User: summaries[member.UserID]}
+			if member.LastReadAt.Valid {
+				memberResponse.LastReadAt = &member.LastReadAt.Time
+			}
+			response.Members = append(response.Members, memberResponse)
+		}
+		if message, ok := latestIn[conversation.ID]; ok {
+			lastMessage := toMessageResponse(message, membersOf[conversation.ID])
+			response.LastMessage = &lastMessage
+		}
+		responses[i] = response
+	}
+	return responses, nil
+}
+
+// blockedWithAny reports whether a block stands between the user and any of
+// the others.
+func (cfg *apiConfig) blockedWithAny(ctx context.Context, userID uuid.UUID, otherIDs []uuid.UUID) (bool, error) {
+	for _, otherID := range otherIDs {
+		if otherID == userID {
+			continue
+		}
+		blocked, err := cfg.blockedBetween(ctx, userID, otherID)
+		if err != nil || blocked {
+			return blocked, err
+		}
+	}
+	return false, nil
+}
+
+// memberConversation loads the conversation in the path, answering 404 if
+// the user isn't in it.
+func (cfg *apiConfig) memberConversation(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (database.Conversation, bool) {
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		http.Error(w, "Invalid conversation ID format", http.StatusBadRequest)
+		return database.Conversation{}, false
+	}
+
+	conversation, err := cfg.db.GetConversationForMember(r.Context(), database.GetConversationForMemberParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "conversation not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error getting conversation %s for %s: %v", conversationID, userID, err)
+			http.Error(w, "Failed to get conversation", http.StatusInternalServerError)
+		}
+		return database.Conversation{}, false
+	}
+	return conversation, true
+}
+
+// publishMessageEvent tells the conversation's members about it in real
+// time. Failures are logged; the change is stored either way.
+func (cfg *apiConfig) publishMessageEvent(ctx context.Context, event messageEvent) {
+	payload, err := json.Marshal(event)
+	if err == nil {
+		err = cfg.pubsub.Publish(ctx, messagesChannel, payload)
+	}
+	if err != nil {
+		log.Printf("Error publishing %s for conversation %s: %v", event.Type, event.ConversationID, err)
+	}
+}
+
+func respondWithConversation(w http.ResponseWriter, status int, conversation conversationResponse) {
+	data, err := json.Marshal(conversation)
+	if err != nil {
+		log.Printf("Error marshalling conversation response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// createConversation starts a conversation with one or more other users. A
+// one-to-one conversation that already exists is returned instead of
+// starting another.
+func (cfg *apiConfig) createConversation(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for conversation: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for conversation: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req conversationRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("JSON conversation decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return
+	}
+
+	seen := map[uuid.UUID]bool{userID: true}
+	var otherIDs []uuid.UUID
+	for _, memberID := range req.MemberIDs {
+		if !seen[memberID] {
+			seen[memberID] = true
+			otherIDs = append(otherIDs, memberID)
+		}
+	}
+	if len(otherIDs) == 0 {
+		respondWithViolations(w, []violation{{
+			Field:   "member_ids",
+			Code:    "required",
+			Message: "Add at least one other member",
+		}})
+		return
+	}
+	if len(otherIDs)+1 > maxConversationMembers {
+		respondWithViolations(w, []violation{{
+			Field:   "member_ids",
+			Code:    "too_many",
+			Message: "Too many members",
+			Limit:   maxConversationMembers,
+		}})
+		return
+	}
+
+	creator, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting user %s for conversation: %v", userID, err)
+		http.Error(w, "Unauthorized: Unknown user", http.StatusUnauthorized)
+		return
+	}
+	if message, locked := accountLockout(creator); locked {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
+
+	others, err := cfg.db.GetUsersByIDs(r.Context(), otherIDs)
+	if err != nil {
+		log.Printf("Error getting conversation members for %s: %v", userID, err)
+		http.Error(w, "Failed to create conversation", http.StatusInternalServerError)
+		return
+	}
+	if len(others) != len(otherIDs) {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
+
+	blocked, err := cfg.blockedWithAny(r.Context(), userID, otherIDs)
+	if err != nil {
+		log.Printf("Error checking blocks for conversation by %s: %v", userID, err)
+		http.Error(w, "Failed to create conversation", http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		http.Error(w, blockedConversationMsg, http.StatusForbidden)
+		return
+	}
+
+	// The conversation and all its members are created together. Two users
+	// only ever share one direct conversation: locking one of their rows
+	// makes concurrent requests between the same pair take turns, whichever
+	// of them asks
+	status := http.StatusCreated
+	var conversation database.Conversation
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		if len(otherIDs) == 1 {
+			lockID := userID
+			if bytes.Compare(otherIDs[0][:], userID[:]) < 0 {
+				lockID = otherIDs[0]
+			}
+			if _, err := q.GetUserByIDForUpdate(r.Context(), lockID); err != nil {
+				return err
+			}
+
+			var err error
+			conversation, err = q.GetDirectConversation(r.Context(), database.GetDirectConversationParams{
+				UserID:  userID,
+				OtherID: otherIDs[0],
+			})
+			if err == nil {
+				status = http.StatusOK
+				return nil
+			}
+			if err != sql.ErrNoRows {
+				return err
+			}
+		}
+
+		var err error
+		conversation, err = q.CreateConversation(r.Context(), database.CreateConversationParams{
+			CreatedBy: uuid.NullUUID{UUID: userID, Valid: true},
+			IsGroup:   len(otherIDs) > 1,
+		})
+		if err != nil {
+			return err
+		}
+		for _, memberID := range append([]uuid.UUID{userID}, otherIDs...) {
+			err = q.AddConversationMember(r.Context(), database.AddConversationMemberParams{
+				ConversationID: conversation.ID,
+				UserID:         memberID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error creating conversation for %s: %v", userID, err)
+		http.Error(w, "Failed to create conversation", http.StatusInternalServerError)
+		return
+	}
+
+	responses, err := cfg.conversationResponses(r.Context(), userID, []database.Conversation{conversation})
+	if err != nil {
+		log.Printf("Error building conversation %s response: %v", conversation.ID, err)
+		http.Error(w, "Failed to create conversation", http.StatusInternalServerError)
+		return
+	}
+	respondWithConversation(w, status, responses[0])
+}
+
+// listConversations lists the caller's conversations, most recently active
+// first, with unread counts.
+func (cfg *apiConfig) listConversations(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for conversation listing: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for conversation listing: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	params := database.ListConversationsParams{
+		UserID: userID,
+		Limit:  defaultConversationsLimit,
+	}
+	query := r.URL.Query()
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxConversationsLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		params.Limit = int32(limit)
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		updatedAt, conversationID, err := decodeCursor(cursor)
+		if err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		params.BeforeUpdatedAt = sql.NullTime{Time: updatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: conversationID, Valid: true}
+	}
+
+	// Fetch one extra to find out whether there is another page
+	pageSize := params.Limit
+	params.Limit++
+	conversations, err := cfg.db.ListConversations(r.Context(), params)
+	if err != nil {
+		log.Printf("cannot list conversations for user %s: %v", userID, err)
+		http.Error(w, "Failed to list conversations", http.StatusInternalServerError)
+		return
+	}
+
+	var page conversationPage
+	if len(conversations) > int(pageSize) {
+		conversations = conversations[:pageSize]
+		last := conversations[len(conversations)-1]
+		page.NextCursor = encodeCursor(last.UpdatedAt, last.ID)
+	}
+
+	page.Conversations, err = cfg.conversationResponses(r.Context(), userID, conversations)
+	if err != nil {
+		log.Printf("cannot build conversations response for user %s: %v", userID, err)
+		http.Error(w, "Failed to list conversations", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(page)
+	if err != nil {
+		log.Printf("Error marshalling conversations response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) getConversation(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for conversation: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for conversation: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	conversation, ok := cfg.memberConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	responses, err := cfg.conversationResponses(r.Context(), userID, []database.Conversation{conversation})
+	if err != nil {
+		log.Printf("Error building conversation %s response: %v", conversation.ID, err)
+		http.Error(w, "Failed to get conversation", http.StatusInternalServerError)
+		return
+	}
+	respondWithConversation(w, http.StatusOK, responses[0])
+}
+
+// sendMessage posts a message to a conversation the caller is in. Nobody
+// can message someone they have blocked or who has blocked them.
+func (cfg *apiConfig) sendMessage(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for message: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for message: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	conversation, ok := cfg.memberConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req messageRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("JSON message decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		respondWithViolations(w, []violation{{
+			Field:   "body",
+			Code:    "required",
+			Message: "Message cannot be empty",
+		}})
+		return
+	}
+	if utf8.RuneCountInString(body) > maxMessageLength {
+		respondWithViolations(w, []violation{{
+			Field:   "body",
+			Code:    "too_long",
+			Message: "Message is too long",
+			Limit:   maxMessageLength,
+		}})
+		return
+	}
+
+	sender, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting sender %s: %v", userID, err)
+		http.Error(w, "Unauthorized: Unknown user", http.StatusUnauthorized)
+		return
+	}
+	if message, locked := accountLockout(sender); locked {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
+
+	members, err := cfg.db.ListConversationMembers(r.Context(), []uuid.UUID{conversation.ID})
+	if err != nil {
+		log.Printf("Error getting members of conversation %s: %v", conversation.ID, err)
+		http.Error(w, "Failed to send message", http.StatusInternalServerError)
+		return
+	}
+	memberIDs := make([]uuid.UUID, len(members))
+	for i, member := range members {
+		memberIDs[i] = member.UserID
+	}
+	blocked, err := cfg.blockedWithAny(r.Context(), userID, memberIDs)
+	if err != nil {
+		log.Printf("Error checking blocks for message by %s: %v", userID, err)
+		http.Error(w, "Failed to send message", http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		http.Error(w, blockedConversationMsg, http.StatusForbidden)
+		return
+	}
+
+	// The conversation moves up the list with the message it gets
+	var message database.Message
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		message, err = q.CreateMessage(r.Context(), database.CreateMessageParams{
+			ConversationID: conversation.ID,
+			SenderID:       userID,
+			Body:           body,
+		})
+		if err != nil {
+			return err
+		}
+		return q.TouchConversation(r.Context(), conversation.ID)
+	})
+	if err != nil {
+		log.Printf("Error sending message to conversation %s: %v", conversation.ID, err)
+		http.Error(w, "Failed to send message", http.StatusInternalServerError)
+		return
+	}
+
+	cfg.publishMessageEvent(r.Context(), messageEvent{
+		Type:           eventMessageCreated,
+		ConversationID: conversation.ID,
+		MessageID:      &message.ID,
+		UserID:         userID,
+	})
+
+	data, err := json.Marshal(toMessageResponse(message, members))
+	if err != nil {
+		log.Printf("Error marshalling message response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+// listMessages pages through a conversation, newest first, with read
+// receipts.
+func (cfg *apiConfig) listMessages(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for message listing: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for message listing: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	conversation, ok := cfg.memberConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	params := database.ListMessagesParams{
+		ConversationID: conversation.ID,
+		Limit:          defaultMessagesLimit,
+	}
+	query := r.URL.Query()
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxMessagesLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		params.Limit = int32(limit)
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		createdAt, messageID, err := decodeCursor(cursor)
+		if err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: messageID, Valid: true}
+	}
+
+	// Fetch one extra to find out whether there is another page
+	pageSize := params.Limit
+	params.Limit++
+	messages, err := cfg.db.ListMessages(r.Context(), params)
+	if err != nil {
+		log.Printf("cannot list messages in conversation %s: %v", conversation.ID, err)
+		http.Error(w, "Failed to list messages", http.StatusInternalServerError)
+		return
+	}
+
+	page := messagePage{Messages: []messageResponse{}}
+	if len(messages) > int(pageSize) {
+		messages = messages[:pageSize]
+		last := messages[len(messages)-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	members, err := cfg.db.ListConversationMembers(r.Context(), []uuid.UUID{conversation.ID})
+	if err != nil {
+		log.Printf("Error getting members of conversation %s: %v", conversation.ID, err)
+		http.Error(w, "Failed to list messages", http.StatusInternalServerError)
+		return
+	}
+	for _, message := range messages {
+		page.Messages = append(page.Messages, toMessageResponse(message, members))
+	}
+
+	data, err := json.Marshal(page)
+	if err != nil {
+		log.Printf("Error marshalling messages response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// deleteMessage removes one of the caller's own messages for everyone.
+func (cfg *apiConfig) deleteMessage(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for message deletion: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for message deletion: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	conversation, ok := cfg.memberConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	messageID, err := uuid.Parse(r.PathValue("messageID"))
+	if err != nil {
+		http.Error(w, "Invalid message ID format", http.StatusBadRequest)
+		return
+	}
+
+	message, err := cfg.db.GetMessage(r.Context(), database.GetMessageParams{
+		ID:             messageID,
+		ConversationID: conversation.ID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "message not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error getting message %s: %v", messageID, err)
+			http.Error(w, "Failed to delete message", http.StatusInternalServerError)
+		}
+		return
+	}
+	if message.SenderID != userID {
+		http.Error(w, "Forbidden: You did not send this message", http.StatusForbidden)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteMessage(r.Context(), database.DeleteMessageParams{
+		ID:       messageID,
+		SenderID: userID,
+	})
+	if err != nil {
+		log.Printf("Error deleting message %s: %v", messageID, err)
+		http.Error(w, "Failed to delete message", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "message not found", http.StatusNotFound)
+		return
+	}
+
+	cfg.publishMessageEvent(r.Context(), messageEvent{
+		Type:           eventMessageDeleted,
+		ConversationID: conversation.ID,
+		MessageID:      &messageID,
+		UserID:         userID,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// markConversationRead marks everything in a conversation so far as read,
+// which other members see as read receipts.
+func (cfg *apiConfig) markConversationRead(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for marking conversation read: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for marking conversation read: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	conversation, ok := cfg.memberConversation(w, r, userID)
+	if !ok {
+		return
+	}
+
+	member, err := cfg.db.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: conversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		log.Printf("Error marking conversation %s read for %s: %v", conversation.ID, userID, err)
+		http.Error(w, "Failed to mark conversation read", http.StatusInternalServerError)
+		return
+	}
+
+	cfg.publishMessageEvent(r.Context(), messageEvent{
+		Type:           eventConversationRead,
+		ConversationID: conversation.ID,
+		UserID:         userID,
+		ReadAt:         &member.LastReadAt.Time,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
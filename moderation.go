@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/database"
+	"github.com/twomotive/gohost/internal/moderation"
+)
+
+// moderationRulesChannel carries a message whenever the stored rules change.
+const moderationRulesChannel = "moderation_rules"
+
+type moderationRuleRequest struct {
+	Kind    string `json:"kind"`
+	Pattern string `json:"pattern"`
+	Action  string `json:"action"`
+}
+
+type moderationRuleResponse struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Kind      string    `json:"kind"`
+	Pattern   string    `json:"pattern"`
+	Action    string    `json:"action"`
+}
+
+func toModerationRuleResponse(rule database.ModerationRule) moderationRuleResponse {
+	return moderationRuleResponse{
+		ID:        rule.ID,
+		CreatedAt: rule.CreatedAt,
+		UpdatedAt: rule.UpdatedAt,
+		Kind:      rule.Kind,
+		Pattern:   rule.Pattern,
+		Action:    rule.Action,
+	}
+}
+
+// reloadModerationRules rebuilds the moderation engine from the rules file
+// (if any) followed by the rules stored in the database.
+func (cfg *apiConfig) reloadModerationRules(ctx context.Context) error {
+	dbRules, err := cfg.db.ListModerationRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules := make([]moderation.Rule, 0, len(cfg.fileModerationRules)+len(dbRules))
+	rules = append(rules, cfg.fileModerationRules...)
+	for _, rule := range dbRules {
+		rules = append(rules, moderation.Rule{
+			ID:      rule.ID,
+			Kind:    moderation.Kind(rule.Kind),
+			Pattern: rule.Pattern,
+			Action:  moderation.Action(rule.Action),
+		})
+	}
+
+	return cfg.moderation.SetRules(rules)
+}
+
+// moderationRulesChanged reloads the rules here and tells every other
+// instance to do the same. Failures are logged; the rule change itself has
+// already been saved.
+func (cfg *apiConfig) moderationRulesChanged(ctx context.Context) {
+	if err := cfg.reloadModerationRules(ctx); err != nil {
+		log.Printf("Error reloading moderation rules: %v", err)
+	}
+	if err := cfg.pubsub.Publish(ctx, moderationRulesChannel, nil); err != nil {
+		log.Printf("Error announcing moderation rule change: %v", err)
+	}
+}
+
+// watchModerationRules reloads the rules whenever another instance changes
+// them, until ctx is done.
+func (cfg *apiConfig) watchModerationRules(ctx context.Context) {
+	sub := cfg.pubsub.Subscribe(moderationRulesChannel, 1)
+	defer func() { sub.Close() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind; one reload covers every
+				// change missed
+				sub = cfg.pubsub.Subscribe(moderationRulesChannel, 1)
+			}
+			if err := cfg.reloadModerationRules(ctx); err != nil {
+				log.Printf("Error reloading moderation rules: %v", err)
+			}
+		}
+	}
+}
+
+// decodeModerationRule reads and validates a rule from the request body. On
+// failure it writes the error response and returns false.
+func decodeModerationRule(w http.ResponseWriter, r *http.Request) (moderationRuleRequest, bool) {
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return moderationRuleRequest{}, false
+	}
+
+	var req moderationRuleRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("JSON moderation rule decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return moderationRuleRequest{}, false
+	}
+
+	err = moderation.ValidateRule(moderation.Rule{
+		Kind:    moderation.Kind(req.Kind),
+		Pattern: req.Pattern,
+		Action:  moderation.Action(req.Action),
+	})
+	if err != nil {
+		http.Error(w, "Invalid rule: "+err.Error(), http.StatusBadRequest)
+		return moderationRuleRequest{}, false
+	}
+
+	return req, true
+}
+
+func (cfg *apiConfig) listModerationRules(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.authorizeRole(w, r, roleAdmin); !ok {
+		return
+	}
+
+	rules, err := cfg.db.ListModerationRules(r.Context())
+	if err != nil {
+		log.Printf("cannot list moderation rules: %v", err)
+		http.Error(w, "Failed to list moderation rules", http.StatusInternalServerError)
+		return
+	}
+
+	responseRules := make([]moderationRuleResponse, len(rules))
+	for i, rule := range rules {
+		responseRules[i] = toModerationRuleResponse(rule)
+	}
+
+	data, err := json.Marshal(responseRules)
+	if err != nil {
+		log.Printf("Error marshalling moderation rules response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) createModerationRule(w http.ResponseWriter, r *http.Request) {
+	admin, ok := cfg.authorizeRole(w, r, roleAdmin)
+	if !ok {
+		return
+	}
+
+	req, ok := decodeModerationRule(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := cfg.db.CreateModerationRule(r.Context(), database.CreateModerationRuleParams{
+		Kind:    req.Kind,
+		Pattern: req.Pattern,
+		Action:  req.Action,
+	})
+	if err != nil {
+		log.Printf("cannot create moderation rule: %v", err)
+		http.Error(w, "Failed to create moderation rule", http.StatusInternalServerError)
+		return
+	}
+
+	cfg.moderationRulesChanged(r.Context())
+
+	log.Printf("Admin %s created moderation rule %s (%s %q -> %s)", admin.ID, rule.ID, rule.Kind, rule.Pattern, rule.Action)
+
+	data, err := json.Marshal(toModerationRuleResponse(rule))
+	if err != nil {
+		log.Printf("Error marshalling moderation rule response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) updateModerationRule(w http.ResponseWriter, r *http.Request) {
+	admin, ok := cfg.authorizeRole(w, r, roleAdmin)
+	if !ok {
+		return
+	}
+
+	ruleID, err := uuid.Parse(r.PathValue("ruleID"))
+	if err != nil {
+		http.Error(w, "Invalid rule ID format", http.StatusBadRequest)
+		return
+	}
+
+	req, ok := decodeModerationRule(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := cfg.db.UpdateModerationRule(r.Context(), database.UpdateModerationRuleParams{
+		ID:      ruleID,
+		Kind:    req.Kind,
+		Pattern: req.Pattern,
+		Action:  req.Action,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "moderation rule not found", http.StatusNotFound)
+		} else {
+			log.Printf("cannot update moderation rule %s: %v", ruleID, err)
+			http.Error(w, "Failed to update moderation rule", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	cfg.moderationRulesChanged(r.Context())
+
+	log.Printf("Admin %s updated moderation rule %s (%s %q -> %s)", admin.ID, rule.ID, rule.Kind, rule.Pattern, rule.Action)
+
+	data, err := json.Marshal(toModerationRuleResponse(rule))
+	if err != nil {
+		log.Printf("Error marshalling moderation rule response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) deleteModerationRule(w http.ResponseWriter, r *http.Request) {
+	admin, ok := cfg.authorizeRole(w, r, roleAdmin)
+	if !ok {
+		return
+	}
+
+	ruleID, err := uuid.Parse(r.PathValue("ruleID"))
+	if err != nil {
+		http.Error(w, "Invalid rule ID format", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteModerationRule(r.Context(), ruleID)
+	if err != nil {
+		log.Printf("cannot delete moderation rule %s: %v", ruleID, err)
+		http.Error(w, "Failed to delete moderation rule", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "moderation rule not found", http.StatusNotFound)
+		return
+	}
+
+	cfg.moderationRulesChanged(r.Context())
+
+	log.Printf("Admin %s deleted moderation rule %s", admin.ID, ruleID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,604 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	notificationMention  = "mention"
+	notificationReply    = "reply"
+	notificationFollow   = "follow"
+	notificationReaction = "reaction"
+	notificationRepost   = "repost"
+
+	// Moderation notices, which can't be turned off
+	notificationWarning        = "warning"
+	notificationReportResolved = "report_resolved"
+
+	// notificationsChannel is the pub/sub channel new notifications go out on
+	notificationsChannel = "notifications"
+
+	defaultNotificationsLimit = 20
+	maxNotificationsLimit     = 100
+	// maxNotificationActors is how many of a grouped notification's actors
+	// are listed by name
+	maxNotificationActors = 3
+)
+
+var notificationTypes = []string{
+	notificationMention,
+	notificationReply,
+	notificationFollow,
+	notificationReaction,
+	notificationRepost,
+}
+
+var notificationActions = map[string]string{
+	notificationMention:  "mentioned you",
+	notificationReply:    "replied to your gobit",
+	notificationFollow:   "followed you",
+	notificationReaction: "reacted to your gobit",
+	notificationRepost:   "reposted your gobit",
+}
+
+var moderationNoticeSummaries = map[string]string{
+	notificationWarning:        "A moderator has warned you about breaking the rules",
+	notificationReportResolved: "A moderator has reviewed your report",
+}
+
+// mentionPattern finds @handles in a gobit body. Handles follow
+// handlePattern, but may be written in any case.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_]{3,30})\b`)
+
+type notificationResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Read      bool       `json:"read"`
+	GobitID   *uuid.UUID `json:"gobit_id,omitempty"`
+	// ReportID is set on moderation notices
+	ReportID *uuid.UUID `json:"report_id,omitempty"`
+	// Actors are the most recent people behind the notification; ActorCount
+	// is how many there are in all
+	Actors     []authorSummary `json:"actors"`
+	ActorCount int64           `json:"actor_count"`
+	Summary    string          `json:"summary"`
+}
+
+// notificationEvent goes out over pub/sub when a notification is created or
+// gains an actor.
+type notificationEvent struct {
+	UserID         uuid.UUID `json:"user_id"`
+	NotificationID uuid.UUID `json:"notification_id"`
+}
+
+type notificationPage struct {
+	Notifications []notificationResponse `json:"notifications"`
+	UnreadCount   int64                  `json:"unread_count"`
+	// NextCursor is passed back as ?cursor= for the next page; it is left
+	// out on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// notify tells a user that someone did something involving them. While the
+// user hasn't read it, repeats of the same event on the same gobit are
+// folded into one notification. Self-notifications, blocked or muted actors,
+// shadowbanned actors and types the user has turned off are skipped.
+func (cfg *apiConfig) notify(ctx context.Context, userID, actorID uuid.UUID, kind string, gobitID uuid.NullUUID) error {
+	if userID == actorID {
+		return nil
+	}
+
+	actor, err := cfg.db.GetUserByID(ctx, actorID)
+	if err != nil {
+		return err
+	}
+	if actor.AccountStatus == accountShadowbanned {
+		return nil
+	}
+
+	blocked, err := cfg.blockedBetween(ctx, userID, actorID)
+	if err != nil || blocked {
+		return err
+	}
+	muted, err := cfg.db.IsMuting(ctx, database.IsMutingParams{MuterID: userID, MutedID: actorID})
+	if err != nil || muted {
+		return err
+	}
+	enabled, err := cfg.db.NotificationEnabled(ctx, database.NotificationEnabledParams{UserID: userID, Type: kind})
+	if err != nil || !enabled {
+		return err
+	}
+
+	groupKey := kind
+	if gobitID.Valid {
+		groupKey += ":" + gobitID.UUID.String()
+	}
+	notification, err := cfg.db.UpsertNotification(ctx, database.UpsertNotificationParams{
+		UserID:   userID,
+		Type:     kind,
+		GobitID:  gobitID,
+		GroupKey: groupKey,
+	})
+	if err != nil {
+		return err
+	}
+	err = cfg.db.AddNotificationActor(ctx, database.AddNotificationActorParams{
+		NotificationID: notification.ID,
+		ActorID:        actorID,
+	})
+	if err != nil {
+		return err
+	}
+
+	cfg.publishNotification(ctx, notification)
+	return nil
+}
+
+// notifyModeration stores a moderation notice about a report for a user, using
+// q so it is part of the moderation action's transaction. Call
+// publishNotification once that has committed.
+func notifyModeration(ctx context.Context, q *database.Queries, userID uuid.UUID, kind string, gobitID uuid.NullUUID, reportID uuid.UUID) (database.Notification, error) {
+	return q.CreateModerationNotice(ctx, database.CreateModerationNoticeParams{
+		UserID:   userID,
+		Type:     kind,
+		GobitID:  gobitID,
+		ReportID: uuid.NullUUID{UUID: reportID, Valid: true},
+		GroupKey: kind + ":" + reportID.String(),
+	})
+}
+
+// publishNotification delivers a stored notification to the user's live
+// connections. Delivery is best effort.
+func (cfg *apiConfig) publishNotification(ctx context.Context, notification database.Notification) {
+	payload, err := json.Marshal(notificationEvent{UserID: notification.UserID, NotificationID: notification.ID})
+	if err == nil {
+		err = cfg.pubsub.Publish(ctx, notificationsChannel, payload)
+	}
+	if err != nil {
+		log.Printf("Error publishing notification %s: %v", notification.ID, err)
+	}
+}
+
+// notifyPublished tells the author of the gobit being replied to, and anyone
+// mentioned, about a gobit that has just been published. Only people who
+// can see the gobit hear about it.
+func (cfg *apiConfig) notifyPublished(ctx context.Context, gobit database.Gobit) error {
+	notified := map[uuid.UUID]bool{gobit.UserID: true}
+
+	if gobit.ReplyToID.Valid {
+		parent, err := cfg.db.GetGobit(ctx, gobit.ReplyToID.UUID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil && !notified[parent.UserID] {
+			notified[parent.UserID] = true
+			visible, err := cfg.gobitVisibleTo(ctx, gobit, uuid.NullUUID{UUID: parent.UserID, Valid: true})
+			if err != nil {
+				return err
+			}
+			if visible {
+				err = cfg.notify(ctx, parent.UserID, gobit.UserID, notificationReply, uuid.NullUUID{UUID: parent.ID, Valid: true})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	var handles []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(gobit.Body, -1) {
+		handles = append(handles, normalizeHandle(match[1]))
+	}
+	if len(handles) == 0 {
+		return nil
+	}
+	mentioned, err := cfg.db.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return err
+	}
+	for _, user := range mentioned {
+		if notified[user.ID] {
+			continue
+		}
+		notified[user.ID] = true
+		visible, err := cfg.gobitVisibleTo(ctx, gobit, uuid.NullUUID{UUID: user.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		if !visible {
+			continue
+		}
+		err = cfg.notify(ctx, user.ID, gobit.UserID, notificationMention, uuid.NullUUID{UUID: gobit.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notificationSummary renders a notification as a sentence, such as
+// "@ada and 4 others reacted to your gobit".
+func notificationSummary(kind string, actors []authorSummary, actorCount int64) string {
+	if summary, ok := moderationNoticeSummaries[kind]; ok {
+		return summary
+	}
+
+	names := make([]string, len(actors))
+	for i, actor := range actors {
+		names[i] = actor.DisplayName
+		if actor.Handle != "" {
+			names[i] = "@" + actor.Handle
+		}
+		if names[i] == "" {
+			names[i] = "Someone"
+		}
+	}
+
+	var who string
+	switch {
+	case len(names) == 0:
+		who = "Someone"
+	case actorCount == 1:
+		who = names[0]
+	case actorCount == 2 && len(names) == 2:
+		who = names[0] + " and " + names[1]
+	case actorCount == 2:
+		who = names[0] + " and 1 other"
+	default:
+		who = fmt.Sprintf("%s and %d others", names[0], actorCount-1)
+	}
+	return who + " " + notificationActions[kind]
+}
+
+func (cfg *apiConfig) listNotifications(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for notification listing: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for notification listing: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	params := database.ListNotificationsParams{
+		UserID: userID,
+		Limit:  defaultNotificationsLimit,
+	}
+	query := r.URL.Query()
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxNotificationsLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		params.Limit = int32(limit)
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		updatedAt, notificationID, err := decodeCursor(cursor)
+		if err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		params.BeforeUpdatedAt = sql.NullTime{Time: updatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: notificationID, Valid: true}
+	}
+
+	// Fetch one extra to find out whether there is another page
+	pageSize := params.Limit
+	params.Limit++
+	notifications, err := cfg.db.ListNotifications(r.Context(), params)
+	if err != nil {
+		log.Printf("cannot list notifications for user %s: %v", userID, err)
+		http.Error(w, "Failed to list notifications", http.StatusInternalServerError)
+		return
+	}
+
+	page := notificationPage{Notifications: []notificationResponse{}}
+	if len(notifications) > int(pageSize) {
+		notifications = notifications[:pageSize]
+		last := notifications[len(notifications)-1]
+		page.NextCursor = encodeCursor(last.UpdatedAt, last.ID)
+	}
+
+	page.UnreadCount, err = cfg.db.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		log.Printf("cannot count unread notifications for user %s: %v", userID, err)
+		http.Error(w, "Failed to list notifications", http.StatusInternalServerError)
+		return
+	}
+
+	page.Notifications, err = cfg.notificationResponses(r.Context(), notifications)
+	if err != nil {
+		log.Printf("cannot build notifications response for user %s: %v", userID, err)
+		http.Error(w, "Failed to list notifications", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(page)
+	if err != nil {
+		log.Printf("Error marshalling notifications response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// notificationResponses fills in the actors behind each notification.
+func (cfg *apiConfig) notificationResponses(ctx context.Context, notifications []database.Notification) ([]notificationResponse, error) {
+	responses := make([]notificationResponse, len(notifications))
+	if len(notifications) == 0 {
+		return responses, nil
+	}
+
+	notificationIDs := make([]uuid.UUID, len(notifications))
+	for i, notification := range notifications {
+		notificationIDs[i] = notification.ID
+	}
+	actorRows, err := cfg.db.ListNotificationActors(ctx, notificationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	actorIDs := make(map[uuid.UUID][]uuid.UUID)
+	actorCounts := make(map[uuid.UUID]int64)
+	var userIDs []uuid.UUID
+	for _, row := range actorRows {
+		actorCounts[row.NotificationID] = row.ActorCount
+		if len(actorIDs[row.NotificationID]) < maxNotificationActors {
+			actorIDs[row.NotificationID] = append(actorIDs[row.NotificationID], row.ActorID)
+			userIDs = append(userIDs, row.ActorID)
+		}
+	}
+
+	users, err := cfg.db.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make(map[uuid.UUID]authorSummary, len(users))
+	for _, user := range users {
+		summaries[user.ID] = toAuthorSummary(user)
+	}
+
+	for i, notification := range notifications {
+		response := notificationResponse{
+			ID:         notification.ID,
+			Type:       notification.Type,
+			CreatedAt:  notification.CreatedAt,
+			UpdatedAt:  notification.UpdatedAt,
+			Read:       notification.ReadAt.Valid,
+			Actors:     []authorSummary{},
+			ActorCount: actorCounts[notification.ID],
+		}
+		if notification.GobitID.Valid {
+			response.GobitID = &notification.GobitID.UUID
+		}
+		if notification.ReportID.Valid {
+			response.ReportID = &notification.ReportID.UUID
+		}
+		for _, actorID := range actorIDs[notification.ID] {
+			if summary, ok := summaries[actorID]; ok {
+				response.Actors = append(response.Actors, summary)
+			}
+		}
+		response.Summary = notificationSummary(notification.Type, response.Actors, response.ActorCount)
+		responses[i] = response
+	}
+	return responses, nil
+}
+
+func (cfg *apiConfig) markNotificationRead(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for marking notification read: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for marking notification read: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	notificationID, err := uuid.Parse(r.PathValue("notificationID"))
+	if err != nil {
+		http.Error(w, "Invalid notification ID format", http.StatusBadRequest)
+		return
+	}
+
+	marked, err := cfg.db.MarkNotificationRead(r.Context(), database.MarkNotificationReadParams{
+		ID:     notificationID,
+		UserID: userID,
+	})
+	if err != nil {
+		log.Printf("Error marking notification %s read: %v", notificationID, err)
+		http.Error(w, "Failed to mark notification read", http.StatusInternalServerError)
+		return
+	}
+
+	// Marking an already read notification is fine; a missing one isn't
+	if marked == 0 {
+		_, err = cfg.db.GetNotification(r.Context(), database.GetNotificationParams{
+			ID:     notificationID,
+			UserID: userID,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "notification not found", http.StatusNotFound)
+			} else {
+				log.Printf("Error getting notification %s: %v", notificationID, err)
+				http.Error(w, "Failed to mark notification read", http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) markAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for marking notifications read: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for marking notifications read: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	_, err = cfg.db.MarkAllNotificationsRead(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error marking notifications read for %s: %v", userID, err)
+		http.Error(w, "Failed to mark notifications read", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getNotificationPreferences returns whether each notification type is on.
+func (cfg *apiConfig) getNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for notification preferences: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for notification preferences: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	cfg.respondWithNotificationPreferences(w, r, userID)
+}
+
+// updateNotificationPreferences turns notification types on or off. Types
+// left out of the body are unchanged.
+func (cfg *apiConfig) updateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for notification preferences: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for notification preferences: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req map[string]bool
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("JSON notification preferences decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return
+	}
+
+	var violations []violation
+	for kind := range req {
+		if _, ok := notificationActions[kind]; !ok {
+			violations = append(violations, violation{
+				Field:   kind,
+				Code:    "invalid",
+				Message: "Notification type must be one of " + strings.Join(notificationTypes, ", "),
+			})
+		}
+	}
+	if len(violations) > 0 {
+		respondWithViolations(w, violations)
+		return
+	}
+
+	for kind, enabled := range req {
+		err = cfg.db.SetNotificationPreference(r.Context(), database.SetNotificationPreferenceParams{
+			UserID:  userID,
+			Type:    kind,
+			Enabled: enabled,
+		})
+		if err != nil {
+			log.Printf("Error setting %s notification preference for %s: %v", kind, userID, err)
+			http.Error(w, "Failed to update notification preferences", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	cfg.respondWithNotificationPreferences(w, r, userID)
+}
+
+func (cfg *apiConfig) respondWithNotificationPreferences(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	preferences, err := cfg.db.ListNotificationPreferences(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting notification preferences for %s: %v", userID, err)
+		http.Error(w, "Failed to get notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	response := make(map[string]bool, len(notificationTypes))
+	for _, kind := range notificationTypes {
+		response[kind] = true
+	}
+	for _, preference := range preferences {
+		response[preference.Type] = preference.Enabled
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("Error marshalling notification preferences response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
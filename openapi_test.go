@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// recordingMux collects the patterns registerRoutes adds.
+type recordingMux struct {
+	patterns []string
+}
+
+func (m *recordingMux) Handle(pattern string, handler http.Handler) {
+	m.patterns = append(m.patterns, pattern)
+}
+
+func (m *recordingMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.patterns = append(m.patterns, pattern)
+}
+
+// undocumentedPrefixes are file servers rather than API routes.
+var undocumentedPrefixes = map[string]bool{
+	"/app/":    true,
+	"/assets/": true,
+}
+
+type openAPIDocument struct {
+	OpenAPI string                                `json:"openapi"`
+	Paths   map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+func loadOpenAPISpec(t *testing.T) openAPIDocument {
+	t.Helper()
+	var doc openAPIDocument
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("openapi/openapi.json is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Fatalf("openapi/openapi.json declares version %q, want 3.x", doc.OpenAPI)
+	}
+	return doc
+}
+
+func registeredRoutes(t *testing.T) map[string]bool {
+	t.Helper()
+	mux := &recordingMux{}
+	(&apiConfig{}).registerRoutes(mux)
+
+	routes := make(map[string]bool, len(mux.patterns))
+	for _, pattern := range mux.patterns {
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			if !undocumentedPrefixes[pattern] {
+				t.Errorf("route %q has no method; register it as \"METHOD %s\"", pattern, pattern)
+			}
+			continue
+		}
+		routes[strings.ToLower(method)+" "+path] = true
+	}
+	return routes
+}
+
+// TestRoutesRegisterOnServeMux catches patterns that only fail on a real
+// mux, such as two routes that conflict, which http.ServeMux reports by
+// panicking.
+func TestRoutesRegisterOnServeMux(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("registering routes on http.ServeMux panicked: %v", r)
+		}
+	}()
+	(&apiConfig{}).registerRoutes(http.NewServeMux())
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	doc := loadOpenAPISpec(t)
+	routes := registeredRoutes(t)
+
+	var missing []string
+	for route := range routes {
+		method, path, _ := strings.Cut(route, " ")
+		if _, ok := doc.Paths[path][method]; !ok {
+			missing = append(missing, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(missing)
+	for _, route := range missing {
+		t.Errorf("%s is registered but missing from openapi/openapi.json", route)
+	}
+}
+
+func TestOpenAPIHasNoStaleRoutes(t *testing.T) {
+	doc := loadOpenAPISpec(t)
+	routes := registeredRoutes(t)
+
+	var stale []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options", "trace":
+			default:
+				continue // parameters, summary and other path-level fields
+			}
+			if !routes[method+" "+path] {
+				stale = append(stale, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+	sort.Strings(stale)
+	for _, route := range stale {
+		t.Errorf("%s is documented in openapi/openapi.json but not registered", route)
+	}
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	var doc map[string]any
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("openapi/openapi.json is not valid JSON: %v", err)
+	}
+
+	var walk func(node any)
+	walk = func(node any) {
+		switch v := node.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok && !resolvesIn(doc, ref) {
+				t.Errorf("$ref %q does not resolve", ref)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+}
+
+// resolvesIn follows a local JSON pointer such as
+// "#/components/schemas/CreatedGobit" through doc.
+func resolvesIn(doc map[string]any, ref string) bool {
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return false
+	}
+	var node any = doc
+	for _, key := range strings.Split(pointer, "/") {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return false
+		}
+		if node, ok = object[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+// maxPinnedGobits is how many gobits a user can pin to their author listing.
+const maxPinnedGobits = 3
+
+// errPinLimit means the user already has maxPinnedGobits pinned.
+var errPinLimit = errors.New("pin limit reached")
+
+// pinGobit pins one of the caller's published gobits to the top of their
+// author listing.
+func (cfg *apiConfig) pinGobit(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for pin: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for pin: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	gobitID, err := uuid.Parse(r.PathValue("gobitID"))
+	if err != nil {
+		http.Error(w, "Invalid gobit ID format", http.StatusBadRequest)
+		return
+	}
+
+	gobit, err := cfg.db.GetGobit(r.Context(), gobitID)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error getting gobit %s to pin: %v", gobitID, err)
+		http.Error(w, "Failed to pin gobit", http.StatusInternalServerError)
+		return
+	}
+	if err == sql.ErrNoRows || gobit.UserID != userID || gobit.Status != gobitPublished ||
+		gobit.HiddenAt.Valid || gobit.DeletedAt.Valid {
+		http.Error(w, "gobit not found", http.StatusNotFound)
+		return
+	}
+	if gobit.RepostOfID.Valid {
+		http.Error(w, "Reposts cannot be pinned", http.StatusBadRequest)
+		return
+	}
+
+	// Pinning is idempotent
+	if gobit.PinnedAt.Valid {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Locking the author's row queues up their concurrent pins, so each one
+	// counts the pins committed before it
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		if _, err := q.GetUserByIDForUpdate(r.Context(), userID); err != nil {
+			return err
+		}
+		pinned, err := q.PinGobit(r.Context(), database.PinGobitParams{
+			ID:        gobitID,
+			UserID:    userID,
+			MaxPinned: maxPinnedGobits,
+		})
+		if err != nil || pinned > 0 {
+			return err
+		}
+
+		// Nothing changed: either a concurrent request pinned this gobit
+		// first, or the limit is reached
+		gobit, err := q.GetGobit(r.Context(), gobitID)
+		if err != nil {
+			return err
+		}
+		if gobit.PinnedAt.Valid {
+			return nil
+		}
+		return errPinLimit
+	})
+	if err == errPinLimit {
+		http.Error(w, fmt.Sprintf("You can pin at most %d gobits", maxPinnedGobits), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Error pinning gobit %s: %v", gobitID, err)
+		http.Error(w, "Failed to pin gobit", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unpinGobit(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for unpin: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for unpin: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	gobitID, err := uuid.Parse(r.PathValue("gobitID"))
+	if err != nil {
+		http.Error(w, "Invalid gobit ID format", http.StatusBadRequest)
+		return
+	}
+
+	unpinned, err := cfg.db.UnpinGobit(r.Context(), database.UnpinGobitParams{
+		ID:     gobitID,
+		UserID: userID,
+	})
+	if err != nil {
+		log.Printf("Error unpinning gobit %s: %v", gobitID, err)
+		http.Error(w, "Failed to unpin gobit", http.StatusInternalServerError)
+		return
+	}
+	if unpinned == 0 {
+		http.Error(w, "pin not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
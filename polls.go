@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 50
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+
+	pollCloseInterval = time.Minute
+)
+
+type pollRequest struct {
+	Options        []string  `json:"options"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	MultipleChoice bool      `json:"multiple_choice"`
+}
+
+type pollVoteRequest struct {
+	OptionIDs []uuid.UUID `json:"option_ids"`
+}
+
+type pollResponse struct {
+	ID             uuid.UUID            `json:"id"`
+	ExpiresAt      time.Time            `json:"expires_at"`
+	Closed         bool                 `json:"closed"`
+	MultipleChoice bool                 `json:"multiple_choice"`
+	VotersCount    int64                `json:"voters_count"`
+	Options        []pollOptionResponse `json:"options"`
+	// ViewerVotes holds the options the signed-in viewer picked, if they
+	// have voted
+	ViewerVotes []uuid.UUID `json:"viewer_votes,omitempty"`
+}
+
+type pollOptionResponse struct {
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+	Votes int64     `json:"votes"`
+}
+
+// validatePoll checks a poll being attached to a new gobit. The poll has to
+// stay open for a while after the gobit goes out, which for a scheduled gobit
+// is publishAt. Option titles go through the same moderation rules as gobit
+// bodies; the poll is returned with them trimmed and masked, along with
+// whether any of them was flagged for review.
+func (cfg *apiConfig) validatePoll(req pollRequest, publishAt sql.NullTime) (pollRequest, bool, []violation) {
+	var violations []violation
+
+	if len(req.Options) < minPollOptions || len(req.Options) > maxPollOptions {
+		violations = append(violations, violation{
+			Field:   "poll.options",
+			Code:    "invalid_count",
+			Message: "A poll needs between 2 and 4 options",
+		})
+	}
+
+	flagged := false
+	cleaned := make([]string, len(req.Options))
+	seen := make(map[string]bool, len(req.Options))
+	for i, option := range req.Options {
+		field := fmt.Sprintf("poll.options[%d]", i)
+		title := strings.TrimSpace(option)
+		switch {
+		case title == "":
+			violations = append(violations, violation{
+				Field:   field,
+				Code:    "required",
+				Message: "Poll options cannot be empty",
+			})
+		case utf8.RuneCountInString(title) > maxPollOptionLength:
+			violations = append(violations, violation{
+				Field:   field,
+				Code:    "too_long",
+				Message: "Poll option is too long",
+				Limit:   maxPollOptionLength,
+			})
+		case seen[strings.ToLower(title)]:
+			violations = append(violations, violation{
+				Field:   field,
+				Code:    "duplicate",
+				Message: "Poll options must be different from each other",
+			})
+		}
+		seen[strings.ToLower(title)] = true
+
+		result := cfg.moderation.Check(title)
+		if result.Rejected {
+			violations = append(violations, violation{
+				Field:   field,
+				Code:    "prohibited_content",
+				Message: "Poll option contains prohibited content",
+			})
+		}
+		flagged = flagged || result.Flagged
+		cleaned[i] = result.Cleaned
+	}
+
+	opensAt := time.Now()
+	if publishAt.Valid {
+		opensAt = publishAt.Time
+	}
+	duration := req.ExpiresAt.Sub(opensAt)
+	if duration < minPollDuration || duration > maxPollDuration {
+		violations = append(violations, violation{
+			Field:   "poll.expires_at",
+			Code:    "invalid",
+			Message: "poll.expires_at must be between 5 minutes and 7 days after the gobit is published",
+		})
+	}
+
+	if len(violations) > 0 {
+		return pollRequest{}, false, violations
+	}
+
+	req.Options = cleaned
+	return req, flagged, nil
+}
+
+// createPoll stores a validated poll for a new gobit, through q so it is
+// part of the transaction creating the gobit.
+func createPoll(ctx context.Context, q *database.Queries, gobitID uuid.UUID, req pollRequest) error {
+	poll, err := q.CreatePoll(ctx, database.CreatePollParams{
+		GobitID:        gobitID,
+		MultipleChoice: req.MultipleChoice,
+		ExpiresAt:      req.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, option := range req.Options {
+		err = q.CreatePollOption(ctx, database.CreatePollOptionParams{
+			PollID:   poll.ID,
+			Position: int32(i),
+			Title:    strings.TrimSpace(option),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gobitPolls returns the polls attached to the given gobits, with live
+// tallies and, for a signed-in viewer, their own votes. Gobits without a
+// poll are left out.
+func (cfg *apiConfig) gobitPolls(ctx context.Context, gobitIDs []uuid.UUID, viewerID uuid.NullUUID) (map[uuid.UUID]*pollResponse, error) {
+	polls, err := cfg.db.ListPollsForGobits(ctx, gobitIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(polls) == 0 {
+		return nil, nil
+	}
+
+	byGobit := make(map[uuid.UUID]*pollResponse, len(polls))
+	byPoll := make(map[uuid.UUID]*pollResponse, len(polls))
+	pollIDs := make([]uuid.UUID, len(polls))
+	for i, poll := range polls {
+		response := &pollResponse{
+			ID:             poll.ID,
+			ExpiresAt:      poll.ExpiresAt,
+			Closed:         poll.ClosedAt.Valid || !poll.ExpiresAt.After(time.Now()),
+			MultipleChoice: poll.MultipleChoice,
+			VotersCount:    poll.VotersCount,
+			Options:        []pollOptionResponse{},
+		}
+		byGobit[poll.GobitID] = response
+		byPoll[poll.ID] = response
+		pollIDs[i] = poll.ID
+	}
+
+	tallies, err := cfg.db.ListPollOptionTallies(ctx, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, tally := range tallies {
+		poll := byPoll[tally.PollID]
+		poll.Options = append(poll.Options, pollOptionResponse{
+			ID:    tally.ID,
+			Title: tally.Title,
+			Votes: tally.Votes,
+		})
+	}
+
+	if viewerID.Valid {
+		votes, err := cfg.db.ListUserPollVotes(ctx, database.ListUserPollVotesParams{
+			UserID:  viewerID.UUID,
+			PollIds: pollIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, vote := range votes {
+			poll := byPoll[vote.PollID]
+			poll.ViewerVotes = append(poll.ViewerVotes, vote.OptionID)
+		}
+	}
+
+	return byGobit, nil
+}
+
+// votePoll casts the caller's vote in the poll on a gobit. Each user votes
+// once; multiple-choice polls take several options in that one vote.
+func (cfg *apiConfig) votePoll(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for poll vote: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for poll vote: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	gobitID, err := uuid.Parse(r.PathValue("gobitID"))
+	if err != nil {
+		http.Error(w, "Invalid gobit ID format", http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req pollVoteRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("JSON poll vote decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return
+	}
+
+	voter, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting voter %s: %v", userID, err)
+		http.Error(w, "Unauthorized: Unknown user", http.StatusUnauthorized)
+		return
+	}
+	if message, locked := accountLockout(voter); locked {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
+
+	// Only people who can see the gobit can vote on its poll
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+	visible, err := cfg.db.GetVisibleGobitsByIDs(r.Context(), database.GetVisibleGobitsByIDsParams{
+		Ids:      []uuid.UUID{gobitID},
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		log.Printf("Error getting gobit %s for poll vote: %v", gobitID, err)
+		http.Error(w, "Failed to vote", http.StatusInternalServerError)
+		return
+	}
+	if len(visible) == 0 {
+		http.Error(w, "gobit not found", http.StatusNotFound)
+		return
+	}
+
+	poll, err := cfg.db.GetPollByGobit(r.Context(), gobitID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "poll not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error getting poll for gobit %s: %v", gobitID, err)
+			http.Error(w, "Failed to vote", http.StatusInternalServerError)
+		}
+		return
+	}
+	if poll.ClosedAt.Valid || !poll.ExpiresAt.After(time.Now()) {
+		http.Error(w, "This poll has closed", http.StatusConflict)
+		return
+	}
+
+	options, err := cfg.db.ListPollOptions(r.Context(), poll.ID)
+	if err != nil {
+		log.Printf("Error getting options for poll %s: %v", poll.ID, err)
+		http.Error(w, "Failed to vote", http.StatusInternalServerError)
+		return
+	}
+	if violations := validatePollVote(req, poll, options); len(violations) > 0 {
+		respondWithViolations(w, violations)
+		return
+	}
+
+	cast, err := cfg.db.CastPollVote(r.Context(), database.CastPollVoteParams{
+		UserID:    userID,
+		PollID:    poll.ID,
+		OptionIds: req.OptionIDs,
+	})
+	if err != nil {
+		log.Printf("Error casting vote in poll %s for %s: %v", poll.ID, userID, err)
+		http.Error(w, "Failed to vote", http.StatusInternalServerError)
+		return
+	}
+	if cast == 0 {
+		http.Error(w, "You have already voted in this poll", http.StatusConflict)
+		return
+	}
+
+	polls, err := cfg.gobitPolls(r.Context(), []uuid.UUID{gobitID}, viewerID)
+	if err != nil {
+		log.Printf("Error getting poll %s after vote: %v", poll.ID, err)
+		http.Error(w, "Failed to vote", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(polls[gobitID])
+	if err != nil {
+		log.Printf("Error marshalling poll response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// validatePollVote checks the chosen options belong to the poll, and that a
+// single-choice poll gets exactly one.
+func validatePollVote(req pollVoteRequest, poll database.Poll, options []database.PollOption) []violation {
+	if len(req.OptionIDs) == 0 {
+		return []violation{{
+			Field:   "option_ids",
+			Code:    "required",
+			Message: "Choose at least one option",
+		}}
+	}
+	if !poll.MultipleChoice && len(req.OptionIDs) > 1 {
+		return []violation{{
+			Field:   "option_ids",
+			Code:    "too_many",
+			Message: "This poll only allows one choice",
+			Limit:   1,
+		}}
+	}
+
+	valid := make(map[uuid.UUID]bool, len(options))
+	for _, option := range options {
+		valid[option.ID] = true
+	}
+	chosen := make(map[uuid.UUID]bool, len(req.OptionIDs))
+	for _, optionID := range req.OptionIDs {
+		if !valid[optionID] {
+			return []violation{{
+				Field:   "option_ids",
+				Code:    "invalid",
+				Message: "Option does not belong to this poll",
+			}}
+		}
+		if chosen[optionID] {
+			return []violation{{
+				Field:   "option_ids",
+				Code:    "duplicate",
+				Message: "Each option can only be chosen once",
+			}}
+		}
+		chosen[optionID] = true
+	}
+	return nil
+}
+
+// closeExpiredPolls marks polls whose time is up as closed.
+func (cfg *apiConfig) closeExpiredPolls(ctx context.Context) error {
+	polls, err := cfg.db.CloseExpiredPolls(ctx)
+	if err != nil {
+		return err
+	}
+	for _, poll := range polls {
+		log.Printf("Closed poll %s on gobit %s", poll.ID, poll.GobitID)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxWebsiteLength     = 200
+)
+
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// reservedHandles can't be claimed because they collide with routes under
+// /api/users or could be mistaken for staff accounts.
+var reservedHandles = map[string]struct{}{
+	"me":        {},
+	"profile":   {},
+	"avatar":    {},
+	"verify":    {},
+	"export":    {},
+	"admin":     {},
+	"moderator": {},
+	"gohost":    {},
+}
+
+type profileRequest struct {
+	Handle      string `json:"handle"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	Website     string `json:"website"`
+}
+
+// publicProfile is everything about a user that anyone may see. It must
+// never include the email address.
+type publicProfile struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	Website     string    `json:"website"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	IsGohostRed bool      `json:"is_gohost_red"`
+}
+
+// authorSummary is the short form of a profile embedded in gobit responses.
+type authorSummary struct {
+	ID          uuid.UUID `json:"id"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+}
+
+func toPublicProfile(user database.User) publicProfile {
+	return publicProfile{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		Handle:      user.Handle.String,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Website:     user.Website,
+		AvatarURL:   avatarURL(user),
+		IsGohostRed: user.IsGohostRed.Valid && user.IsGohostRed.Bool,
+	}
+}
+
+func toAuthorSummary(user database.User) authorSummary {
+	return authorSummary{
+		ID:          user.ID,
+		Handle:      user.Handle.String,
+		DisplayName: user.DisplayName,
+		AvatarURL:   avatarURL(user),
+	}
+}
+
+// avatarURL is the thumbnail of the user's avatar, or empty if they have none.
+func avatarURL(user database.User) string {
+	if !user.AvatarMediaID.Valid {
+		return ""
+	}
+	return mediaURL(user.AvatarMediaID.UUID) + "/thumbnail"
+}
+
+// normalizeHandle strips a leading "@" and lower-cases the handle so that
+// handles are unique regardless of case.
+func normalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint error.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// validateProfile checks every profile field and returns all violations.
+func validateProfile(req profileRequest) []violation {
+	var violations []violation
+
+	if req.Handle != "" {
+		if !handlePattern.MatchString(req.Handle) {
+			violations = append(violations, violation{
+				Field:   "handle",
+				Code:    "invalid_format",
+				Message: "Handle must be 3-30 characters of letters, digits or underscores",
+			})
+		} else if _, reserved := reservedHandles[req.Handle]; reserved {
+			violations = append(violations, violation{
+				Field:   "handle",
+				Code:    "reserved",
+				Message: "Handle is reserved",
+			})
+		}
+	}
+
+	if utf8.RuneCountInString(req.DisplayName) > maxDisplayNameLength {
+		violations = append(violations, violation{
+			Field:   "display_name",
+			Code:    "too_long",
+			Message: "Display name is too long",
+			Limit:   maxDisplayNameLength,
+		})
+	}
+
+	if utf8.RuneCountInString(req.Bio) > maxBioLength {
+		violations = append(violations, violation{
+			Field:   "bio",
+			Code:    "too_long",
+			Message: "Bio is too long",
+			Limit:   maxBioLength,
+		})
+	}
+
+	if req.Website != "" {
+		u, err := url.Parse(req.Website)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			violations = append(violations, violation{
+				Field:   "website",
+				Code:    "invalid_format",
+				Message: "Website must be an http or https URL",
+			})
+		} else if len(req.Website) > maxWebsiteLength {
+			violations = append(violations, violation{
+				Field:   "website",
+				Code:    "too_long",
+				Message: "Website is too long",
+				Limit:   maxWebsiteLength,
+			})
+		}
+	}
+
+	return violations
+}
+
+func (cfg *apiConfig) getPublicProfile(w http.ResponseWriter, r *http.Request) {
+	handle := normalizeHandle(r.PathValue("handle"))
+	if handle == "" {
+		http.Error(w, "handle is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := cfg.db.GetUserByHandle(r.Context(), handle)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "user not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error getting user by handle %s: %v", handle, err)
+			http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if viewerID := cfg.optionalViewer(r); viewerID.Valid && viewerID.UUID != user.ID {
+		blocked, err := cfg.blockedBetween(r.Context(), viewerID.UUID, user.ID)
+		if err != nil {
+			log.Printf("Error checking blocks for profile %s: %v", handle, err)
+			http.Error(w, "Failed to get user", http.StatusInternalServerError)
+			return
+		}
+		if blocked {
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	data, err := json.Marshal(toPublicProfile(user))
+	if err != nil {
+		log.Printf("Error marshalling profile response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) updateProfile(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for profile update: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for profile update: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req profileRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("JSON profile decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return
+	}
+
+	req.Handle = normalizeHandle(req.Handle)
+	req.DisplayName = strings.TrimSpace(req.DisplayName)
+	req.Bio = strings.TrimSpace(req.Bio)
+	req.Website = strings.TrimSpace(req.Website)
+
+	if violations := validateProfile(req); len(violations) > 0 {
+		respondWithViolations(w, violations)
+		return
+	}
+
+	user, err := cfg.db.UpdateUserProfile(r.Context(), database.UpdateUserProfileParams{
+		ID:          userID,
+		Handle:      sql.NullString{String: req.Handle, Valid: req.Handle != ""},
+		DisplayName: req.DisplayName,
+		Bio:         req.Bio,
+		Website:     req.Website,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			http.Error(w, "Handle is already taken", http.StatusConflict)
+		} else {
+			log.Printf("cannot update profile for user %s: %v", userID, err)
+			http.Error(w, "Internal server error updating profile", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	data, err := json.Marshal(toPublicProfile(user))
+	if err != nil {
+		log.Printf("Error marshalling profile response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// includesAuthor reports whether the request asked for author summaries to
+// be embedded, e.g. ?include=author.
+func includesAuthor(r *http.Request) bool {
+	for _, include := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(include) == "author" {
+			return true
+		}
+	}
+	return false
+}
+
+// gobitAuthors looks up the author summary for each distinct author of gobits.
+func (cfg *apiConfig) gobitAuthors(ctx context.Context, gobits []database.Gobit) (map[uuid.UUID]authorSummary, error) {
+	seen := make(map[uuid.UUID]struct{}, len(gobits))
+	var ids []uuid.UUID
+	for _, gobit := range gobits {
+		if _, ok := seen[gobit.UserID]; !ok {
+			seen[gobit.UserID] = struct{}{}
+			ids = append(ids, gobit.UserID)
+		}
+	}
+
+	users, err := cfg.db.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	authors := make(map[uuid.UUID]authorSummary, len(users))
+	for _, user := range users {
+		authors[user.ID] = toAuthorSummary(user)
+	}
+	return authors, nil
+}
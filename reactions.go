@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+var reactionKinds = map[string]bool{
+	"like":  true,
+	"love":  true,
+	"laugh": true,
+	"wow":   true,
+	"sad":   true,
+}
+
+type reactionRequest struct {
+	Kind string `json:"kind"`
+}
+
+// gobitReactions counts the reactions on each of the given gobits by kind,
+// and looks up the signed-in viewer's own reaction to each.
+func (cfg *apiConfig) gobitReactions(ctx context.Context, gobitIDs []uuid.UUID, viewerID uuid.NullUUID) (map[uuid.UUID]map[string]int64, map[uuid.UUID]string, error) {
+	rows, err := cfg.db.CountReactionsForGobits(ctx, gobitIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	counts := make(map[uuid.UUID]map[string]int64)
+	for _, row := range rows {
+		if counts[row.GobitID] == nil {
+			counts[row.GobitID] = make(map[string]int64)
+		}
+		counts[row.GobitID][row.Kind] = row.ReactionCount
+	}
+
+	own := make(map[uuid.UUID]string)
+	if viewerID.Valid {
+		reactions, err := cfg.db.ListUserReactions(ctx, database.ListUserReactionsParams{
+			UserID:   viewerID.UUID,
+			GobitIds: gobitIDs,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, reaction := range reactions {
+			own[reaction.GobitID] = reaction.Kind
+		}
+	}
+	return counts, own, nil
+}
+
+// setReaction sets the caller's reaction to a gobit, replacing any earlier
+// one. Reacting to a repost reacts to the original.
+func (cfg *apiConfig) setReaction(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for reaction: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for reaction: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	gobitID, err := uuid.Parse(r.PathValue("gobitID"))
+	if err != nil {
+		http.Error(w, "Invalid gobit ID format", http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req reactionRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("JSON reaction decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return
+	}
+	if !reactionKinds[req.Kind] {
+		respondWithViolations(w, []violation{{
+			Field:   "kind",
+			Code:    "invalid",
+			Message: "kind must be one of like, love, laugh, wow or sad",
+		}})
+		return
+	}
+
+	reactor, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting user %s for reaction: %v", userID, err)
+		http.Error(w, "Unauthorized: Unknown user", http.StatusUnauthorized)
+		return
+	}
+	if message, locked := accountLockout(reactor); locked {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
+
+	gobit, ok, err := cfg.visibleOriginal(r.Context(), gobitID, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error getting gobit %s to react to: %v", gobitID, err)
+		http.Error(w, "Failed to react", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "gobit not found", http.StatusNotFound)
+		return
+	}
+
+	inserted, err := cfg.db.UpsertReaction(r.Context(), database.UpsertReactionParams{
+		UserID:  userID,
+		GobitID: gobit.ID,
+		Kind:    req.Kind,
+	})
+	if err != nil {
+		log.Printf("Error reacting to gobit %s for %s: %v", gobit.ID, userID, err)
+		http.Error(w, "Failed to react", http.StatusInternalServerError)
+		return
+	}
+
+	// Changing the kind of reaction doesn't notify the author again
+	if inserted {
+		err = cfg.notify(r.Context(), gobit.UserID, userID, notificationReaction, uuid.NullUUID{UUID: gobit.ID, Valid: true})
+		if err != nil {
+			log.Printf("Error notifying %s of reaction to %s: %v", gobit.UserID, gobit.ID, err)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) deleteReaction(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for reaction removal: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for reaction removal: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	gobitID, err := uuid.Parse(r.PathValue("gobitID"))
+	if err != nil {
+		http.Error(w, "Invalid gobit ID format", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteReaction(r.Context(), database.DeleteReactionParams{
+		UserID:  userID,
+		GobitID: gobitID,
+	})
+	if err != nil {
+		log.Printf("Error removing reaction to %s for %s: %v", gobitID, userID, err)
+		http.Error(w, "Failed to remove reaction", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "reaction not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,18 +1,42 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
 )
 
+const refreshTokenTTL = 60 * 24 * time.Hour // 60 days expiration
+
 type refreshResponse struct {
 	Token string `json:"token"`
 }
 
+// issueRefreshToken creates and stores a new refresh token for the user
+// through q, which can belong to a transaction.
+func issueRefreshToken(ctx context.Context, q *database.Queries, userID uuid.UUID) (string, error) {
+	refreshTokenString, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = q.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		Token:     refreshTokenString,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return refreshTokenString, nil
+}
+
 func (cfg *apiConfig) handleRefresh(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -49,9 +73,22 @@ func (cfg *apiConfig) handleRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Banned and suspended accounts cannot mint new access tokens
+	user, err := cfg.db.GetUserByID(r.Context(), refreshTokenData.UserID)
+	if err != nil {
+		log.Printf("Error getting user %s during refresh: %v", refreshTokenData.UserID, err)
+		http.Error(w, "Unauthorized: Invalid or expired refresh token", http.StatusUnauthorized)
+		return
+	}
+	if message, locked := accountLockout(user); locked {
+		log.Printf("Refresh refused for %s account %s", user.AccountStatus, user.ID)
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
+
 	// Token is valid, issue a new access token
 	newJwtExpiresIn := time.Hour
-	newAccessTokenString, err := auth.MakeJWT(refreshTokenData.UserID, cfg.jwtSecret, newJwtExpiresIn)
+	newAccessTokenString, err := auth.MakeJWT(refreshTokenData.UserID, auth.SessionID(refreshTokenString), cfg.jwtSecret, newJwtExpiresIn)
 	if err != nil {
 		log.Printf("Error generating new JWT during refresh for user %s: %v", refreshTokenData.UserID, err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -0,0 +1,591 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	reportTargetGobit = "gobit"
+	reportTargetUser  = "user"
+
+	defaultSuspendHours = 7 * 24
+	defaultReportsLimit = 50
+	maxReportsLimit     = 200
+)
+
+// errReportResolved means another moderator resolved the report first.
+var errReportResolved = errors.New("report already resolved")
+
+// reportOutcomes is the text shown to a reporter for each moderation action.
+var reportOutcomes = map[string]string{
+	"hide":    "The reported gobit has been hidden.",
+	"delete":  "The reported gobit has been removed.",
+	"warn":    "The account has been warned.",
+	"suspend": "The account has been suspended.",
+	"dismiss": "No action was taken.",
+}
+
+type reportRequest struct {
+	TargetType string    `json:"target_type"`
+	TargetID   uuid.UUID `json:"target_id"`
+	Reason     string    `json:"reason"`
+}
+
+type reportResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ReporterID *uuid.UUID `json:"reporter_id,omitempty"`
+	TargetType string     `json:"target_type"`
+	TargetID   uuid.UUID  `json:"target_id"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	AssigneeID *uuid.UUID `json:"assignee_id,omitempty"`
+	Outcome    string     `json:"outcome,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+type assignReportRequest struct {
+	AssigneeID *uuid.UUID `json:"assignee_id"`
+}
+
+type moderationActionRequest struct {
+	Action       string `json:"action"`
+	Reason       string `json:"reason"`
+	SuspendHours int    `json:"suspend_hours"`
+}
+
+type moderationActionResponse struct {
+	ID          uuid.UUID      `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	ModeratorID *uuid.UUID     `json:"moderator_id,omitempty"`
+	Action      string         `json:"action"`
+	TargetType  string         `json:"target_type"`
+	TargetID    uuid.UUID      `json:"target_id"`
+	Reason      string         `json:"reason"`
+	Report      reportResponse `json:"report"`
+}
+
+func toReportResponse(report database.Report) reportResponse {
+	response := reportResponse{
+		ID:         report.ID,
+		CreatedAt:  report.CreatedAt,
+		UpdatedAt:  report.UpdatedAt,
+		TargetType: report.TargetType,
+		TargetID:   report.TargetID,
+		Reason:     report.Reason,
+		Status:     report.Status,
+		Outcome:    report.Outcome.String,
+	}
+	if report.ReporterID.Valid {
+		response.ReporterID = &report.ReporterID.UUID
+	}
+	if report.AssigneeID.Valid {
+		response.AssigneeID = &report.AssigneeID.UUID
+	}
+	if report.ResolvedAt.Valid {
+		response.ResolvedAt = &report.ResolvedAt.Time
+	}
+	return response
+}
+
+func (cfg *apiConfig) createReport(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for report: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for report: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req reportRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("JSON report decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(req.Reason) == "" {
+		http.Error(w, "Reason is required", http.StatusBadRequest)
+		return
+	}
+
+	switch req.TargetType {
+	case reportTargetGobit:
+		gobit, err := cfg.db.GetGobit(r.Context(), req.TargetID)
+		if err != nil || gobit.HiddenAt.Valid || gobit.DeletedAt.Valid || gobit.Status != gobitPublished {
+			if err != nil && err != sql.ErrNoRows {
+				log.Printf("Error getting reported gobit %s: %v", req.TargetID, err)
+				http.Error(w, "Failed to create report", http.StatusInternalServerError)
+				return
+			}
+			http.Error(w, "gobit not found", http.StatusNotFound)
+			return
+		}
+
+		visible, err := cfg.gobitVisibleTo(r.Context(), gobit, uuid.NullUUID{UUID: userID, Valid: true})
+		if err != nil {
+			log.Printf("Error checking visibility of reported gobit %s: %v", req.TargetID, err)
+			http.Error(w, "Failed to create report", http.StatusInternalServerError)
+			return
+		}
+		if !visible {
+			http.Error(w, "gobit not found", http.StatusNotFound)
+			return
+		}
+	case reportTargetUser:
+		_, err := cfg.db.GetUserByID(r.Context(), req.TargetID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "user not found", http.StatusNotFound)
+			} else {
+				log.Printf("Error getting reported user %s: %v", req.TargetID, err)
+				http.Error(w, "Failed to create report", http.StatusInternalServerError)
+			}
+			return
+		}
+	default:
+		http.Error(w, "target_type must be 'gobit' or 'user'", http.StatusBadRequest)
+		return
+	}
+
+	report, err := cfg.db.CreateReport(r.Context(), database.CreateReportParams{
+		ReporterID: uuid.NullUUID{UUID: userID, Valid: true},
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Reason:     req.Reason,
+	})
+	if err != nil {
+		log.Printf("cannot create report: %v", err)
+		http.Error(w, "Failed to create report", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(toReportResponse(report))
+	if err != nil {
+		log.Printf("Error marshalling report response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+// listMyReports lets a reporter follow up on their reports and see the
+// outcome once a moderator has acted.
+func (cfg *apiConfig) listMyReports(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for report listing: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for report listing: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	reports, err := cfg.db.ListReportsByReporter(r.Context(), uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("cannot list reports for user %s: %v", userID, err)
+		http.Error(w, "Failed to list reports", http.StatusInternalServerError)
+		return
+	}
+
+	responseReports := make([]reportResponse, len(reports))
+	for i, report := range reports {
+		responseReports[i] = toReportResponse(report)
+		// Reporters don't get to see which moderator handled their report
+		responseReports[i].AssigneeID = nil
+	}
+
+	data, err := json.Marshal(responseReports)
+	if err != nil {
+		log.Printf("Error marshalling reports response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// listReportQueue is the moderation queue. It supports filtering by status
+// (default "open", or "all"), target_type and assignee ("me", "unassigned"
+// or a user ID), with limit/offset paging.
+func (cfg *apiConfig) listReportQueue(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := cfg.authorizeRole(w, r, roleModerator, roleAdmin)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	params := database.ListReportsParams{
+		Limit: defaultReportsLimit,
+	}
+
+	switch status := query.Get("status"); status {
+	case "":
+		params.Status = sql.NullString{String: "open", Valid: true}
+	case "all":
+	case "open", "resolved", "dismissed":
+		params.Status = sql.NullString{String: status, Valid: true}
+	default:
+		http.Error(w, "Invalid status filter", http.StatusBadRequest)
+		return
+	}
+
+	if targetType := query.Get("target_type"); targetType != "" {
+		if targetType != reportTargetGobit && targetType != reportTargetUser {
+			http.Error(w, "Invalid target_type filter", http.StatusBadRequest)
+			return
+		}
+		params.TargetType = sql.NullString{String: targetType, Valid: true}
+	}
+
+	switch assignee := query.Get("assignee"); assignee {
+	case "":
+	case "me":
+		params.AssigneeID = uuid.NullUUID{UUID: moderator.ID, Valid: true}
+	case "unassigned":
+		params.Unassigned = true
+	default:
+		assigneeID, err := uuid.Parse(assignee)
+		if err != nil {
+			http.Error(w, "Invalid assignee filter", http.StatusBadRequest)
+			return
+		}
+		params.AssigneeID = uuid.NullUUID{UUID: assigneeID, Valid: true}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxReportsLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		params.Limit = int32(limit)
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		params.Offset = int32(offset)
+	}
+
+	reports, err := cfg.db.ListReports(r.Context(), params)
+	if err != nil {
+		log.Printf("cannot list report queue: %v", err)
+		http.Error(w, "Failed to list reports", http.StatusInternalServerError)
+		return
+	}
+
+	responseReports := make([]reportResponse, len(reports))
+	for i, report := range reports {
+		responseReports[i] = toReportResponse(report)
+	}
+
+	data, err := json.Marshal(responseReports)
+	if err != nil {
+		log.Printf("Error marshalling report queue response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) assignReport(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := cfg.authorizeRole(w, r, roleModerator, roleAdmin)
+	if !ok {
+		return
+	}
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		http.Error(w, "Invalid report ID format", http.StatusBadRequest)
+		return
+	}
+
+	// An empty body assigns the report to the caller
+	var req assignReportRequest
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Printf("JSON report assignment decode error: %v", err)
+			http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	assigneeID := moderator.ID
+	if req.AssigneeID != nil {
+		assigneeID = *req.AssigneeID
+		assignee, err := cfg.db.GetUserByID(r.Context(), assigneeID)
+		if err != nil || (assignee.Role != roleModerator && assignee.Role != roleAdmin) {
+			http.Error(w, "Assignee must be a moderator", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := cfg.db.AssignReport(r.Context(), database.AssignReportParams{
+		ID:         reportID,
+		AssigneeID: uuid.NullUUID{UUID: assigneeID, Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "report not found", http.StatusNotFound)
+		} else {
+			log.Printf("cannot assign report %s: %v", reportID, err)
+			http.Error(w, "Failed to assign report", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	data, err := json.Marshal(toReportResponse(report))
+	if err != nil {
+		log.Printf("Error marshalling report response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// actOnReport applies a moderation action to a report's target, records it
+// against the moderator and resolves the report with an outcome the
+// reporter can see. The reporter is told about the outcome, and a warned
+// account about the warning.
+func (cfg *apiConfig) actOnReport(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := cfg.authorizeRole(w, r, roleModerator, roleAdmin)
+	if !ok {
+		return
+	}
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		http.Error(w, "Invalid report ID format", http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req moderationActionRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("JSON moderation action decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return
+	}
+
+	if _, known := reportOutcomes[req.Action]; !known {
+		http.Error(w, "action must be one of hide, delete, warn, suspend or dismiss", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		http.Error(w, "Reason is required", http.StatusBadRequest)
+		return
+	}
+
+	report, err := cfg.db.GetReport(r.Context(), reportID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "report not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error getting report %s: %v", reportID, err)
+			http.Error(w, "Failed to get report", http.StatusInternalServerError)
+		}
+		return
+	}
+	if report.Status != "open" {
+		http.Error(w, "Report has already been resolved", http.StatusConflict)
+		return
+	}
+
+	// Work out which account warn and suspend apply to
+	targetType := report.TargetType
+	targetID := report.TargetID
+	subjectUserID := report.TargetID
+	var gobit database.Gobit
+	if report.TargetType == reportTargetGobit && req.Action != "dismiss" {
+		gobit, err = cfg.db.GetGobit(r.Context(), report.TargetID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Reported gobit no longer exists", http.StatusConflict)
+			} else {
+				log.Printf("Error getting reported gobit %s: %v", report.TargetID, err)
+				http.Error(w, "Failed to get gobit", http.StatusInternalServerError)
+			}
+			return
+		}
+		subjectUserID = gobit.UserID
+	}
+
+	switch req.Action {
+	case "hide", "delete":
+		if report.TargetType != reportTargetGobit {
+			http.Error(w, "Only gobits can be hidden or deleted", http.StatusBadRequest)
+			return
+		}
+	case "warn", "suspend":
+		targetType, targetID = reportTargetUser, subjectUserID
+	}
+
+	status := "resolved"
+	if req.Action == "dismiss" {
+		status = "dismissed"
+	}
+
+	// Resolving first makes sure only one moderator acts on the report; the
+	// action, its record and the notices stand or fall with it
+	var action database.ModerationAction
+	var notices []database.Notification
+	var removed int64
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		report, err = q.ResolveReport(r.Context(), database.ResolveReportParams{
+			ID:      report.ID,
+			Status:  status,
+			Outcome: sql.NullString{String: reportOutcomes[req.Action], Valid: true},
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return errReportResolved
+			}
+			return err
+		}
+
+		switch req.Action {
+		case "hide":
+			removed, err = q.HideGobit(r.Context(), report.TargetID)
+		case "delete":
+			removed, err = q.DeleteGobitByID(r.Context(), database.DeleteGobitByIDParams{
+				ID:        report.TargetID,
+				DeletedBy: uuid.NullUUID{UUID: moderator.ID, Valid: true},
+			})
+		case "warn":
+			var gobitID uuid.NullUUID
+			if report.TargetType == reportTargetGobit {
+				gobitID = uuid.NullUUID{UUID: gobit.ID, Valid: true}
+			}
+			var notice database.Notification
+			notice, err = notifyModeration(r.Context(), q, subjectUserID, notificationWarning, gobitID, report.ID)
+			notices = append(notices, notice)
+		case "suspend":
+			hours := req.SuspendHours
+			if hours <= 0 {
+				hours = defaultSuspendHours
+			}
+			_, err = applyAccountStatus(r.Context(), q, subjectUserID, uuid.NullUUID{UUID: moderator.ID, Valid: true}, accountSuspended,
+				sql.NullTime{Time: time.Now().Add(time.Duration(hours) * time.Hour), Valid: true},
+				"Report "+report.ID.String()+": "+req.Reason)
+		}
+		if err != nil {
+			return err
+		}
+
+		action, err = q.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+			ModeratorID: uuid.NullUUID{UUID: moderator.ID, Valid: true},
+			ReportID:    uuid.NullUUID{UUID: report.ID, Valid: true},
+			Action:      req.Action,
+			TargetType:  targetType,
+			TargetID:    targetID,
+			Reason:      req.Reason,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Reporters find out what came of their report
+		if report.ReporterID.Valid {
+			notice, err := notifyModeration(r.Context(), q, report.ReporterID.UUID, notificationReportResolved, uuid.NullUUID{}, report.ID)
+			if err != nil {
+				return err
+			}
+			notices = append(notices, notice)
+		}
+		return nil
+	})
+	if err != nil {
+		if err == errReportResolved {
+			http.Error(w, "Report has already been resolved", http.StatusConflict)
+		} else {
+			log.Printf("Error applying %s to %s %s (report %s): %v", req.Action, targetType, targetID, report.ID, err)
+			http.Error(w, "Failed to apply moderation action", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if removed > 0 {
+		cfg.gobitDeleted(r.Context(), gobit)
+	}
+	for _, notice := range notices {
+		cfg.publishNotification(r.Context(), notice)
+	}
+
+	log.Printf("Moderator %s applied %s to %s %s (report %s): %s", moderator.ID, req.Action, targetType, targetID, report.ID, req.Reason)
+
+	response := moderationActionResponse{
+		ID:          action.ID,
+		CreatedAt:   action.CreatedAt,
+		ModeratorID: &moderator.ID,
+		Action:      action.Action,
+		TargetType:  action.TargetType,
+		TargetID:    action.TargetID,
+		Reason:      action.Reason,
+		Report:      toReportResponse(report),
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("Error marshalling moderation action response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+// repostCounts returns how many live reposts each of the given gobits has.
+// Gobits without any are left out.
+func (cfg *apiConfig) repostCounts(ctx context.Context, gobitIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	rows, err := cfg.db.CountRepostsForGobits(ctx, gobitIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.RepostOfID.UUID] = row.RepostCount
+	}
+	return counts, nil
+}
+
+// createRepost shares someone's gobit to the caller's followers. Reposting
+// a repost shares the original; each user can repost a gobit once.
+func (cfg *apiConfig) createRepost(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for repost: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for repost: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	gobitID, err := uuid.Parse(r.PathValue("gobitID"))
+	if err != nil {
+		http.Error(w, "Invalid gobit ID format", http.StatusBadRequest)
+		return
+	}
+
+	author, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting author %s for repost: %v", userID, err)
+		http.Error(w, "Unauthorized: Unknown user", http.StatusUnauthorized)
+		return
+	}
+	if message, locked := accountLockout(author); locked {
+		http.Error(w, message, http.StatusForbidden)
+		return
+	}
+
+	original, ok, err := cfg.visibleOriginal(r.Context(), gobitID, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error getting gobit %s to repost: %v", gobitID, err)
+		http.Error(w, "Failed to repost gobit", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "gobit not found", http.StatusNotFound)
+		return
+	}
+
+	// Reposting would put followers-only and private gobits in front of
+	// people they weren't meant for
+	if original.Visibility != visibilityPublic && original.Visibility != visibilityUnlisted {
+		http.Error(w, "Only public and unlisted gobits can be reposted", http.StatusForbidden)
+		return
+	}
+
+	repost, err := cfg.db.CreateRepost(r.Context(), database.CreateRepostParams{
+		UserID:     userID,
+		Visibility: original.Visibility,
+		RepostOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			http.Error(w, "You have already reposted this gobit", http.StatusConflict)
+		} else {
+			log.Printf("Error reposting gobit %s for %s: %v", original.ID, userID, err)
+			http.Error(w, "Failed to repost gobit", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	err = cfg.notify(r.Context(), original.UserID, userID, notificationRepost, uuid.NullUUID{UUID: original.ID, Valid: true})
+	if err != nil {
+		log.Printf("Error notifying %s of repost of %s: %v", original.UserID, original.ID, err)
+	}
+
+	err = cfg.publishGobitEvent(r.Context(), eventGobitCreated, repost)
+	if err != nil {
+		log.Printf("Error streaming repost %s: %v", repost.ID, err)
+	}
+
+	responseGobits, err := cfg.gobitResponses(r, []database.Gobit{repost})
+	if err != nil || len(responseGobits) == 0 {
+		log.Printf("Error building response for repost %s: %v", repost.ID, err)
+		http.Error(w, "Failed to repost gobit", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(responseGobits[0])
+	if err != nil {
+		log.Printf("Error marshalling repost response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
+
+// deleteRepost undoes the caller's repost of a gobit. The repost is removed
+// outright rather than going to the trash.
+func (cfg *apiConfig) deleteRepost(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for repost removal: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for repost removal: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	gobitID, err := uuid.Parse(r.PathValue("gobitID"))
+	if err != nil {
+		http.Error(w, "Invalid gobit ID format", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteRepost(r.Context(), database.DeleteRepostParams{
+		UserID:     userID,
+		RepostOfID: uuid.NullUUID{UUID: gobitID, Valid: true},
+	})
+	if err != nil {
+		log.Printf("Error removing repost of %s for %s: %v", gobitID, userID, err)
+		http.Error(w, "Failed to remove repost", http.StatusInternalServerError)
+		return
+	}
+	if len(deleted) == 0 {
+		http.Error(w, "repost not found", http.StatusNotFound)
+		return
+	}
+	for _, repost := range deleted {
+		cfg.gobitDeleted(r.Context(), repost)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
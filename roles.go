@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	roleUser      = "user"
+	roleModerator = "moderator"
+	roleAdmin     = "admin"
+)
+
+// authorizeRole authenticates the request and checks that the user holds one
+// of roles. On failure it writes the error response and returns false.
+func (cfg *apiConfig) authorizeRole(w http.ResponseWriter, r *http.Request, roles ...string) (database.User, bool) {
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for %s: %v", r.URL.Path, err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return database.User{}, false
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for %s: %v", r.URL.Path, err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return database.User{}, false
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Unauthorized: Unknown user", http.StatusUnauthorized)
+		} else {
+			log.Printf("Error getting user %s for role check: %v", userID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return database.User{}, false
+	}
+
+	if !slices.Contains(roles, user.Role) {
+		log.Printf("User %s with role %s denied access to %s", user.ID, user.Role, r.URL.Path)
+		http.Error(w, "Forbidden: insufficient role", http.StatusForbidden)
+		return database.User{}, false
+	}
+
+	return user, true
+}
+
+// viewerIsModerator reports whether the optional viewer is a moderator or
+// admin, for endpoints that show staff more than other users.
+func (cfg *apiConfig) viewerIsModerator(ctx context.Context, viewerID uuid.NullUUID) (bool, error) {
+	if !viewerID.Valid {
+		return false, nil
+	}
+
+	viewer, err := cfg.db.GetUserByID(ctx, viewerID.UUID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return viewer.Role == roleModerator || viewer.Role == roleAdmin, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import "net/http"
+
+// routeMux is the part of *http.ServeMux that registerRoutes uses, so the
+// route table can be inspected without starting a server.
+type routeMux interface {
+	Handle(pattern string, handler http.Handler)
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+// registerRoutes adds every endpoint to mux. Routes added here must also be
+// documented in openapi/openapi.json, at each version for versioned routes.
+func (cfg *apiConfig) registerRoutes(mux routeMux) {
+	// Gobit and user routes are also served under /api/v1 and /api/v2
+	api := versionedMux{mux: mux, cfg: cfg}
+
+	// Update fileserver paths with metrics middleware
+	fileServer := http.FileServer(http.Dir("."))
+	mux.Handle("/app/", cfg.middlewareMetricsInc(http.StripPrefix("/app/", fileServer)))
+	mux.Handle("/assets/", cfg.middlewareMetricsInc(http.FileServer(http.Dir("assets"))))
+
+	// Add health check endpoint
+	mux.HandleFunc("GET /api/healthz", HandleReadiness)
+
+	// API reference
+	mux.HandleFunc("GET /api/openapi.json", serveOpenAPI)
+	mux.HandleFunc("GET /api/docs", serveAPIDocs)
+
+	// Add metrics endpoint
+	mux.HandleFunc("GET /admin/metrics", cfg.handleMetrics)
+
+	// Add reset endpoint
+	mux.HandleFunc("POST /admin/reset", cfg.handleReset)
+
+	// Moderation rule management (admin only)
+	mux.HandleFunc("GET /admin/moderation/rules", cfg.listModerationRules)
+	mux.HandleFunc("POST /admin/moderation/rules", cfg.createModerationRule)
+	mux.HandleFunc("PUT /admin/moderation/rules/{ruleID}", cfg.updateModerationRule)
+	mux.HandleFunc("DELETE /admin/moderation/rules/{ruleID}", cfg.deleteModerationRule)
+
+	// Add validate endpoint
+	mux.HandleFunc("POST /api/validate", cfg.handleValidate)
+
+	// Add users api endpoint to create users
+	api.HandleFunc("POST /api/users", cfg.createUsers)
+
+	api.HandleFunc("PUT /api/users", cfg.updateUser)
+	api.HandleFunc("PATCH /api/users", cfg.patchUser)
+	api.HandleFunc("POST /api/users/verify", cfg.verifyEmail)
+	api.HandleFunc("DELETE /api/users", cfg.deleteAccount)
+
+	// Data exports
+	api.HandleFunc("POST /api/users/export", cfg.requestDataExport)
+	api.HandleFunc("GET /api/users/export/{exportID}", cfg.getDataExport)
+	api.HandleFunc("GET /api/users/export/{exportID}/download", cfg.downloadDataExport)
+
+	// Public profiles
+	api.HandleFunc("PUT /api/users/profile", cfg.updateProfile)
+	api.HandleFunc("GET /api/users/{handle}", cfg.getPublicProfile)
+	api.HandleFunc("PUT /api/users/avatar", cfg.updateAvatar)
+
+	// Media uploads
+	mux.HandleFunc("POST /api/media", cfg.uploadMedia)
+	mux.HandleFunc("GET /media/{mediaID}", cfg.serveMedia)
+	mux.HandleFunc("GET /media/{mediaID}/thumbnail", cfg.serveMediaThumbnail)
+
+	// RSS, Atom and JSON Feed syndication
+	mux.HandleFunc("GET /api/feeds/users/{handle}", cfg.getAuthorFeed)
+	mux.HandleFunc("GET /api/feeds/public", cfg.getPublicFeed)
+
+	mux.HandleFunc("POST /api/login", cfg.userLogin)
+
+	// Add refresh token endpoint
+	mux.HandleFunc("POST /api/refresh", cfg.handleRefresh)
+
+	// Add revoke token endpoint
+	mux.HandleFunc("POST /api/revoke", cfg.handleRevoke)
+
+	// Signed-in sessions
+	mux.HandleFunc("GET /api/sessions", cfg.listSessions)
+	mux.HandleFunc("DELETE /api/sessions/{sessionID}", cfg.revokeSession)
+
+	api.HandleFunc("POST /api/gobits", cfg.createGoBits)
+
+	api.HandleVersions("GET /api/gobits", cfg.getAllGoBits, cfg.listGobitsV2)
+
+	api.HandleFunc("GET /api/gobits/{gobitID}", cfg.getGoBitByID)
+
+	api.HandleFunc("DELETE /api/gobits/{gobitID}", cfg.deleteGobit)
+
+	// Drafts and scheduled gobits
+	api.HandleFunc("GET /api/gobits/drafts", cfg.listDrafts)
+	api.HandleFunc("POST /api/gobits/{gobitID}/publish", cfg.publishGobit)
+
+	// Trash for deleted gobits, and removed content for moderators
+	api.HandleFunc("GET /api/gobits/trash", cfg.listTrash)
+	api.HandleFunc("POST /api/gobits/{gobitID}/restore", cfg.restoreGobit)
+	mux.HandleFunc("GET /admin/gobits/removed", cfg.listRemovedGobits)
+
+	// Threads, search and the home feed
+	api.HandleFunc("GET /api/gobits/{gobitID}/thread", cfg.getThread)
+	api.HandleFunc("GET /api/gobits/search", cfg.searchGobits)
+	mux.HandleFunc("GET /api/feed", cfg.getFeed)
+	api.HandleFunc("GET /api/gobits/stream", cfg.streamGobits)
+	mux.HandleFunc("GET /api/ws", cfg.serveWebSocket)
+
+	// Reposts; quotes are created with quote_of_id on POST /api/gobits
+	api.HandleFunc("POST /api/gobits/{gobitID}/repost", cfg.createRepost)
+	api.HandleFunc("DELETE /api/gobits/{gobitID}/repost", cfg.deleteRepost)
+
+	// Voting in polls, which are created with the gobit
+	api.HandleFunc("POST /api/gobits/{gobitID}/poll/votes", cfg.votePoll)
+
+	// Pinning your own gobits and bookmarking anyone's
+	api.HandleFunc("POST /api/gobits/{gobitID}/pin", cfg.pinGobit)
+	api.HandleFunc("DELETE /api/gobits/{gobitID}/pin", cfg.unpinGobit)
+	api.HandleFunc("POST /api/gobits/{gobitID}/bookmark", cfg.createBookmark)
+	api.HandleFunc("DELETE /api/gobits/{gobitID}/bookmark", cfg.deleteBookmark)
+	mux.HandleFunc("GET /api/bookmarks", cfg.listBookmarks)
+
+	// Reactions
+	api.HandleFunc("PUT /api/gobits/{gobitID}/reaction", cfg.setReaction)
+	api.HandleFunc("DELETE /api/gobits/{gobitID}/reaction", cfg.deleteReaction)
+
+	// Direct messages
+	mux.HandleFunc("GET /api/conversations", cfg.listConversations)
+	mux.HandleFunc("POST /api/conversations", cfg.createConversation)
+	mux.HandleFunc("GET /api/conversations/{conversationID}", cfg.getConversation)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", cfg.markConversationRead)
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", cfg.listMessages)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", cfg.sendMessage)
+	mux.HandleFunc("DELETE /api/conversations/{conversationID}/messages/{messageID}", cfg.deleteMessage)
+
+	// Notifications
+	mux.HandleFunc("GET /api/notifications", cfg.listNotifications)
+	mux.HandleFunc("POST /api/notifications/read", cfg.markAllNotificationsRead)
+	mux.HandleFunc("POST /api/notifications/{notificationID}/read", cfg.markNotificationRead)
+	mux.HandleFunc("GET /api/notifications/preferences", cfg.getNotificationPreferences)
+	mux.HandleFunc("PUT /api/notifications/preferences", cfg.updateNotificationPreferences)
+
+	// Following other users
+	mux.HandleFunc("GET /api/follows", cfg.listFollowing)
+	mux.HandleFunc("POST /api/follows", cfg.createFollow)
+	mux.HandleFunc("DELETE /api/follows/{userID}", cfg.deleteFollow)
+	mux.HandleFunc("GET /api/followers", cfg.listFollowers)
+
+	// Blocking and muting other users
+	mux.HandleFunc("GET /api/blocks", cfg.listBlocks)
+	mux.HandleFunc("POST /api/blocks", cfg.createBlock)
+	mux.HandleFunc("DELETE /api/blocks/{userID}", cfg.deleteBlock)
+	mux.HandleFunc("GET /api/mutes", cfg.listMutes)
+	mux.HandleFunc("POST /api/mutes", cfg.createMute)
+	mux.HandleFunc("DELETE /api/mutes/{userID}", cfg.deleteMute)
+
+	// Reports and the moderation queue
+	mux.HandleFunc("POST /api/reports", cfg.createReport)
+	mux.HandleFunc("GET /api/reports", cfg.listMyReports)
+	mux.HandleFunc("GET /admin/reports", cfg.listReportQueue)
+	mux.HandleFunc("POST /admin/reports/{reportID}/assign", cfg.assignReport)
+	mux.HandleFunc("POST /admin/reports/{reportID}/actions", cfg.actOnReport)
+
+	// Account restrictions (admin only)
+	mux.HandleFunc("PUT /admin/users/{userID}/status", cfg.updateAccountStatus)
+	mux.HandleFunc("GET /admin/users/{userID}/status", cfg.listAccountStatusEvents)
+
+	mux.HandleFunc("POST /api/strip/webhooks", cfg.handleStripWebhook)
+
+	// ActivityPub federation
+	mux.HandleFunc("GET /.well-known/webfinger", cfg.webfinger)
+	mux.HandleFunc("GET /ap/users/{userID}", cfg.getActor)
+	mux.HandleFunc("GET /ap/users/{userID}/outbox", cfg.getOutbox)
+	mux.HandleFunc("GET /ap/users/{userID}/followers", cfg.getFollowersCollection)
+	mux.HandleFunc("POST /ap/users/{userID}/inbox", cfg.postInbox)
+	mux.HandleFunc("POST /ap/inbox", cfg.postInbox)
+	mux.HandleFunc("GET /ap/gobits/{gobitID}", cfg.getNote)
+
+	// Following accounts on other servers
+	mux.HandleFunc("GET /api/federation/follows", cfg.listRemoteFollowing)
+	mux.HandleFunc("POST /api/federation/follows", cfg.followRemote)
+	mux.HandleFunc("DELETE /api/federation/follows/{actorID}", cfg.unfollowRemote)
+	mux.HandleFunc("GET /api/federation/timeline", cfg.getRemoteTimeline)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+// sessionResponse describes one signed-in session. The ID is the session ID
+// access tokens carry, never the refresh token itself.
+type sessionResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Current   bool      `json:"current"`
+}
+
+func (cfg *apiConfig) listSessions(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for session listing: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := auth.ParseJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for session listing: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	tokens, err := cfg.db.ListRefreshTokensByUser(r.Context(), claims.UserID)
+	if err != nil {
+		log.Printf("cannot list sessions for user %s: %v", claims.UserID, err)
+		http.Error(w, "Failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	responseSessions := make([]sessionResponse, 0, len(tokens))
+	for _, token := range tokens {
+		if token.RevokedAt.Valid || now.After(token.ExpiresAt) {
+			continue
+		}
+		id := auth.SessionID(token.Token)
+		responseSessions = append(responseSessions, sessionResponse{
+			ID:        id,
+			CreatedAt: token.CreatedAt,
+			ExpiresAt: token.ExpiresAt,
+			Current:   id == claims.SessionID,
+		})
+	}
+
+	data, err := json.Marshal(responseSessions)
+	if err != nil {
+		log.Printf("Error marshalling sessions response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// revokeSession signs one of the user's sessions out, such as a lost device.
+// Its refresh token stops working at once; access tokens already issued for
+// it last until they expire, but WebSockets opened with them are closed at
+// their next session check.
+func (cfg *apiConfig) revokeSession(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for session revoke: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for session revoke: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	session, err := cfg.db.GetSession(r.Context(), database.GetSessionParams{
+		UserID:    userID,
+		SessionID: r.PathValue("sessionID"),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Session not found", http.StatusNotFound)
+		} else {
+			log.Printf("cannot get session for user %s: %v", userID, err)
+			http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if !session.RevokedAt.Valid {
+		if err := cfg.db.RevokeRefreshToken(r.Context(), session.Token); err != nil {
+			log.Printf("cannot revoke session for user %s: %v", userID, err)
+			http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,19 @@
+-- name: CreateAccountStatusEvent :one
+INSERT INTO account_status_events (id, created_at, user_id, actor_id, old_status, new_status, suspended_until, reason)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+
+-- name: ListAccountStatusEvents :many
+SELECT * FROM account_status_events
+WHERE user_id = $1
+ORDER BY created_at DESC;
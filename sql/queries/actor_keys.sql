@@ -0,0 +1,11 @@
+-- name: CreateActorKey :one
+-- Stores a new key pair for the user, or returns the one they already have
+-- if another request generated it first.
+INSERT INTO actor_keys (user_id, created_at, public_key_pem, private_key_pem)
+VALUES ($1, NOW(), $2, $3)
+ON CONFLICT (user_id) DO UPDATE SET user_id = actor_keys.user_id
+RETURNING *;
+
+-- name: GetActorKey :one
+SELECT * FROM actor_keys
+WHERE user_id = $1;
@@ -0,0 +1,26 @@
+-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, gobit_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id, gobit_id) DO NOTHING;
+
+
+-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1 AND gobit_id = $2;
+
+
+-- name: ListBookmarks :many
+-- Pages through a user's bookmarks, newest first. Pass the created_at and
+-- gobit_id of the last bookmark seen to get the next page.
+SELECT * FROM bookmarks
+WHERE user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('before_created_at')::timestamp IS NULL
+       OR (created_at, gobit_id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_gobit_id')::uuid))
+ORDER BY created_at DESC, gobit_id DESC
+LIMIT sqlc.arg('limit');
+
+
+-- name: ListBookmarksByUser :many
+SELECT * FROM bookmarks
+WHERE user_id = $1
+ORDER BY created_at DESC;
@@ -0,0 +1,83 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, created_by, is_group)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
+RETURNING *;
+
+
+-- name: AddConversationMember :exec
+INSERT INTO conversation_members (conversation_id, user_id, joined_at)
+VALUES ($1, $2, NOW());
+
+
+-- name: GetDirectConversation :one
+-- Finds the one-to-one conversation between two users, if they have one.
+SELECT conversations.* FROM conversations
+WHERE NOT conversations.is_group
+  AND EXISTS (
+      SELECT 1 FROM conversation_members
+      WHERE conversation_members.conversation_id = conversations.id AND conversation_members.user_id = sqlc.arg('user_id')
+  )
+  AND EXISTS (
+      SELECT 1 FROM conversation_members
+      WHERE conversation_members.conversation_id = conversations.id AND conversation_members.user_id = sqlc.arg('other_id')
+  )
+LIMIT 1;
+
+
+-- name: GetConversationForMember :one
+SELECT conversations.* FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversations.id = $1 AND conversation_members.user_id = $2;
+
+
+-- name: ListConversations :many
+-- Pages through a user's conversations, most recently active first. Pass the
+-- updated_at and id of the last conversation seen to get the next page.
+SELECT conversations.* FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversation_members.user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('before_updated_at')::timestamp IS NULL
+       OR (conversations.updated_at, conversations.id) < (sqlc.narg('before_updated_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg('limit');
+
+
+-- name: ListConversationMembers :many
+SELECT * FROM conversation_members
+WHERE conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+ORDER BY conversation_id, joined_at ASC, user_id ASC;
+
+
+-- name: IsConversationMember :one
+SELECT EXISTS (
+    SELECT 1 FROM conversation_members
+    WHERE conversation_id = $1 AND user_id = $2
+);
+
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = NOW()
+WHERE id = $1;
+
+
+-- name: MarkConversationRead :one
+-- Marks everything in the conversation so far as read by the user.
+UPDATE conversation_members
+SET last_read_at = NOW()
+WHERE conversation_id = $1 AND user_id = $2
+RETURNING *;
+
+
+-- name: CountUnreadMessages :many
+-- Counts the messages from others that the user hasn't read yet in each of
+-- the given conversations.
+SELECT messages.conversation_id, COUNT(*) AS unread_count
+FROM messages
+JOIN conversation_members ON conversation_members.conversation_id = messages.conversation_id
+     AND conversation_members.user_id = sqlc.arg('user_id')
+WHERE messages.conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+  AND messages.sender_id <> sqlc.arg('user_id')
+  AND messages.deleted_at IS NULL
+  AND (conversation_members.last_read_at IS NULL OR messages.created_at > conversation_members.last_read_at)
+GROUP BY messages.conversation_id;
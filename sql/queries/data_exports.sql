@@ -0,0 +1,33 @@
+-- name: CreateDataExport :one
+INSERT INTO data_exports (id, created_at, updated_at, user_id, status)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    'pending'
+)
+RETURNING *;
+
+
+-- name: GetDataExport :one
+SELECT * FROM data_exports WHERE id = $1;
+
+
+-- name: CompleteDataExport :one
+UPDATE data_exports
+SET status = 'ready', storage_key = $2, size_bytes = $3, expires_at = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: FailDataExport :exec
+UPDATE data_exports
+SET status = 'failed', updated_at = NOW()
+WHERE id = $1;
+
+
+-- name: ListDataExportsByUser :many
+SELECT * FROM data_exports
+WHERE user_id = $1
+ORDER BY created_at DESC;
@@ -0,0 +1,24 @@
+-- name: CreateEmailVerification :one
+INSERT INTO email_verifications (token, created_at, user_id, email, expires_at, used_at)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4,
+    NULL
+)
+RETURNING *;
+
+
+-- name: UseEmailVerification :one
+UPDATE email_verifications
+SET used_at = NOW()
+WHERE token = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING *;
+
+
+-- name: ExpireEmailVerificationsForUser :exec
+UPDATE email_verifications
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
@@ -0,0 +1,42 @@
+-- name: CreateFollow :execrows
+-- Follows nobody if either user has blocked the other, checked in the same
+-- statement so a block can't slip in between.
+INSERT INTO follows (follower_id, followee_id, created_at)
+SELECT sqlc.arg('follower_id')::uuid, sqlc.arg('followee_id')::uuid, NOW()
+WHERE NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (blocker_id = sqlc.arg('follower_id') AND blocked_id = sqlc.arg('followee_id'))
+       OR (blocker_id = sqlc.arg('followee_id') AND blocked_id = sqlc.arg('follower_id'))
+)
+ON CONFLICT (follower_id, followee_id) DO NOTHING;
+
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+
+-- name: DeleteFollowsBetween :exec
+-- Removes follows in both directions, e.g. when one user blocks the other.
+DELETE FROM follows
+WHERE (follower_id = sqlc.arg('user_id') AND followee_id = sqlc.arg('other_id'))
+   OR (follower_id = sqlc.arg('other_id') AND followee_id = sqlc.arg('user_id'));
+
+
+-- name: ListFollowing :many
+SELECT * FROM follows
+WHERE follower_id = $1
+ORDER BY created_at DESC;
+
+
+-- name: ListFollowers :many
+SELECT * FROM follows
+WHERE followee_id = $1
+ORDER BY created_at DESC;
+
+
+-- name: IsFollowing :one
+SELECT EXISTS (
+    SELECT 1 FROM follows
+    WHERE follower_id = $1 AND followee_id = $2
+);
@@ -0,0 +1,42 @@
+-- name: CreateGobitEvent :one
+INSERT INTO gobit_events (created_at, type, gobit_id, user_id, visibility)
+VALUES (NOW(), $1, $2, $3, $4)
+RETURNING *;
+
+
+-- name: ListGobitEventsAfter :many
+-- Pages through the events after the last one a client saw, oldest first.
+SELECT * FROM gobit_events
+WHERE id > $1
+ORDER BY id ASC
+LIMIT $2;
+
+
+-- name: GetLatestGobitEventID :one
+-- Where a new stream starts: it only wants events after this one.
+SELECT COALESCE(MAX(id), 0)::bigint FROM gobit_events;
+
+
+-- name: DeleteGobitEventsBefore :execrows
+DELETE FROM gobit_events
+WHERE created_at < $1;
+
+
+-- name: ListGobitEventAudience :many
+-- How each of the given viewers stands with an event's author, so one query
+-- decides what every open stream gets for the event.
+SELECT viewers.id::uuid AS viewer_id,
+       EXISTS (
+           SELECT 1 FROM follows
+           WHERE follows.follower_id = viewers.id AND follows.followee_id = sqlc.arg('author_id')
+       ) AS following,
+       EXISTS (
+           SELECT 1 FROM user_mutes
+           WHERE user_mutes.muter_id = viewers.id AND user_mutes.muted_id = sqlc.arg('author_id')
+       ) AS muting,
+       EXISTS (
+           SELECT 1 FROM user_blocks
+           WHERE (user_blocks.blocker_id = viewers.id AND user_blocks.blocked_id = sqlc.arg('author_id'))
+              OR (user_blocks.blocker_id = sqlc.arg('author_id') AND user_blocks.blocked_id = viewers.id)
+       ) AS blocked
+FROM unnest(sqlc.arg('viewer_ids')::uuid[]) AS viewers(id);
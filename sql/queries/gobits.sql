@@ -1,29 +1,349 @@
 -- name: CreateGobit :one
-INSERT INTO gobits (id, created_at, updated_at, body, user_id)
+INSERT INTO gobits (id, created_at, updated_at, body, user_id, status, publish_at, published_at, visibility, reply_to_id, quote_of_id)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    CASE WHEN $3 = 'published' THEN NOW() END,
+    $5,
+    $6,
+    $7
 )
 RETURNING *;
 
 
 -- name: GetAllGobits :many
-SELECT * FROM gobits
-ORDER BY created_at ASC;
+SELECT gobits.* FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.status = 'published'
+  AND (users.account_status <> 'shadowbanned' OR gobits.user_id = sqlc.narg('viewer_id'))
+  AND NOT EXISTS (
+      SELECT 1 FROM user_blocks
+      WHERE (user_blocks.blocker_id = sqlc.narg('viewer_id') AND user_blocks.blocked_id = gobits.user_id)
+         OR (user_blocks.blocker_id = gobits.user_id AND user_blocks.blocked_id = sqlc.narg('viewer_id'))
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM user_mutes
+      WHERE user_mutes.muter_id = sqlc.narg('viewer_id') AND user_mutes.muted_id = gobits.user_id
+  )
+  -- Unlisted gobits stay off the timeline; private ones are for the author
+  AND (gobits.user_id = sqlc.narg('viewer_id')
+       OR gobits.visibility = 'public'
+       OR (gobits.visibility = 'followers' AND EXISTS (
+           SELECT 1 FROM follows
+           WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = gobits.user_id
+       )))
+ORDER BY gobits.published_at ASC;
 
 
 -- name: GetGobitsByAuthor :many
+SELECT gobits.* FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE gobits.user_id = sqlc.arg('user_id') AND gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.status = 'published'
+  AND (users.account_status <> 'shadowbanned' OR gobits.user_id = sqlc.narg('viewer_id'))
+  AND NOT EXISTS (
+      SELECT 1 FROM user_blocks
+      WHERE (user_blocks.blocker_id = sqlc.narg('viewer_id') AND user_blocks.blocked_id = gobits.user_id)
+         OR (user_blocks.blocker_id = gobits.user_id AND user_blocks.blocked_id = sqlc.narg('viewer_id'))
+  )
+  AND (gobits.user_id = sqlc.narg('viewer_id')
+       OR gobits.visibility IN ('public', 'unlisted')
+       OR (gobits.visibility = 'followers' AND EXISTS (
+           SELECT 1 FROM follows
+           WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = gobits.user_id
+       )))
+-- Pinned gobits come first, most recently pinned at the top
+ORDER BY gobits.pinned_at DESC NULLS LAST, gobits.published_at ASC;
+
+
+-- name: ListGobitsPage :many
+-- Pages through published gobits newest first, everyone's or one author's,
+-- with the same visibility rules as GetAllGobits and GetGobitsByAuthor.
+-- Pass the published_at and id of the last gobit seen to get the next page.
+SELECT gobits.* FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR gobits.user_id = sqlc.narg('author_id'))
+  AND gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.status = 'published'
+  AND (users.account_status <> 'shadowbanned' OR gobits.user_id = sqlc.narg('viewer_id'))
+  AND NOT EXISTS (
+      SELECT 1 FROM user_blocks
+      WHERE (user_blocks.blocker_id = sqlc.narg('viewer_id') AND user_blocks.blocked_id = gobits.user_id)
+         OR (user_blocks.blocker_id = gobits.user_id AND user_blocks.blocked_id = sqlc.narg('viewer_id'))
+  )
+  -- Muted users stay reachable on their own listing
+  AND (sqlc.narg('author_id')::uuid IS NOT NULL OR NOT EXISTS (
+      SELECT 1 FROM user_mutes
+      WHERE user_mutes.muter_id = sqlc.narg('viewer_id') AND user_mutes.muted_id = gobits.user_id
+  ))
+  AND (gobits.user_id = sqlc.narg('viewer_id')
+       OR gobits.visibility = 'public'
+       OR (gobits.visibility = 'unlisted' AND sqlc.narg('author_id')::uuid IS NOT NULL)
+       OR (gobits.visibility = 'followers' AND EXISTS (
+           SELECT 1 FROM follows
+           WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = gobits.user_id
+       )))
+  AND (sqlc.narg('before_published_at')::timestamp IS NULL
+       OR (gobits.published_at, gobits.id) < (sqlc.narg('before_published_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY gobits.published_at DESC, gobits.id DESC
+LIMIT sqlc.arg('limit');
+
+
+-- name: GetGobit :one
+SELECT * FROM gobits WHERE id = $1;
+
+-- name: DeleteGobit :execrows
+-- Moves the gobit to its author's trash, from where it can be restored
+-- until it is purged.
+UPDATE gobits
+SET deleted_at = NOW(), deleted_by = user_id, pinned_at = NULL, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;
+
+-- name: HideGobit :execrows
+UPDATE gobits
+SET hidden_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteGobitByID :execrows
+-- Removes a gobit on behalf of a moderator. The author can't restore it.
+UPDATE gobits
+SET deleted_at = NOW(), deleted_by = $2, pinned_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
+
+
+-- name: ListGobitsByUser :many
 SELECT * FROM gobits
 WHERE user_id = $1
 ORDER BY created_at ASC;
 
 
--- name: GetGobit :one
-SELECT * FROM gobits WHERE id = $1;
+-- name: RestoreGobit :one
+UPDATE gobits
+SET deleted_at = NULL, deleted_by = NULL, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_by = user_id AND deleted_at IS NOT NULL
+RETURNING *;
+
+
+-- name: ListTrashedGobits :many
+SELECT * FROM gobits
+WHERE user_id = $1 AND deleted_by = user_id AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+
+-- name: ListRemovedGobits :many
+SELECT * FROM gobits
+WHERE deleted_at IS NOT NULL OR hidden_at IS NOT NULL
+ORDER BY COALESCE(deleted_at, hidden_at) DESC
+LIMIT $1 OFFSET $2;
+
 
--- name: DeleteGobit :exec
+-- name: PurgeDeletedGobits :execrows
+-- Only gobits their authors deleted are purged; those a moderator removed
+-- are kept as the record of the action.
 DELETE FROM gobits
-WHERE id = $1 AND user_id = $2;
\ No newline at end of file
+WHERE deleted_at < $1 AND deleted_by = user_id;
+
+
+-- name: PublishGobit :one
+UPDATE gobits
+SET status = $3,
+    publish_at = $4,
+    published_at = CASE WHEN $3 = 'published' THEN NOW() END,
+    updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND status <> 'published' AND deleted_at IS NULL
+RETURNING *;
+
+
+-- name: PublishDueGobits :many
+-- Publishes scheduled gobits whose time has come. Rows locked by another
+-- instance are skipped, and the status check means each gobit is returned
+-- by exactly one call.
+UPDATE gobits
+SET status = 'published', published_at = NOW(), updated_at = NOW()
+WHERE status = 'scheduled' AND id IN (
+    SELECT id FROM gobits
+    WHERE status = 'scheduled' AND publish_at <= NOW() AND deleted_at IS NULL
+      -- Hold posts from locked-out authors until they are allowed back
+      AND user_id NOT IN (
+          SELECT users.id FROM users
+          WHERE users.account_status = 'banned'
+             OR (users.account_status = 'suspended' AND (users.suspended_until IS NULL OR users.suspended_until > NOW()))
+      )
+    ORDER BY publish_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+
+-- name: ListUnpublishedGobits :many
+SELECT * FROM gobits
+WHERE user_id = $1 AND status <> 'published' AND deleted_at IS NULL
+ORDER BY COALESCE(publish_at, created_at) ASC;
+
+
+-- name: SearchGobits :many
+-- Matches gobit bodies against a LIKE pattern. Results follow the same rules
+-- as the public timeline, newest first.
+SELECT gobits.* FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE gobits.body ILIKE sqlc.arg('pattern')
+  AND gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.status = 'published'
+  AND (users.account_status <> 'shadowbanned' OR gobits.user_id = sqlc.narg('viewer_id'))
+  AND NOT EXISTS (
+      SELECT 1 FROM user_blocks
+      WHERE (user_blocks.blocker_id = sqlc.narg('viewer_id') AND user_blocks.blocked_id = gobits.user_id)
+         OR (user_blocks.blocker_id = gobits.user_id AND user_blocks.blocked_id = sqlc.narg('viewer_id'))
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM user_mutes
+      WHERE user_mutes.muter_id = sqlc.narg('viewer_id') AND user_mutes.muted_id = gobits.user_id
+  )
+  AND (gobits.user_id = sqlc.narg('viewer_id')
+       OR gobits.visibility = 'public'
+       OR (gobits.visibility = 'followers' AND EXISTS (
+           SELECT 1 FROM follows
+           WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = gobits.user_id
+       )))
+ORDER BY gobits.published_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+
+-- name: GetFeed :many
+-- The viewer's home feed: their own gobits and those of everyone they
+-- follow, newest first. Pass the published_at and id of the last gobit as
+-- before and before_id to page back; before alone skips anything published
+-- at that exact time.
+SELECT gobits.* FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE (gobits.user_id = sqlc.arg('viewer_id') OR gobits.user_id IN (
+          SELECT follows.followee_id FROM follows WHERE follows.follower_id = sqlc.arg('viewer_id')
+      ))
+  AND (gobits.user_id = sqlc.arg('viewer_id') OR gobits.visibility <> 'private')
+  AND gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.status = 'published'
+  AND (users.account_status <> 'shadowbanned' OR gobits.user_id = sqlc.arg('viewer_id'))
+  AND NOT EXISTS (
+      SELECT 1 FROM user_blocks
+      WHERE (user_blocks.blocker_id = sqlc.arg('viewer_id') AND user_blocks.blocked_id = gobits.user_id)
+         OR (user_blocks.blocker_id = gobits.user_id AND user_blocks.blocked_id = sqlc.arg('viewer_id'))
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM user_mutes
+      WHERE user_mutes.muter_id = sqlc.arg('viewer_id') AND user_mutes.muted_id = gobits.user_id
+  )
+  AND (sqlc.narg('before')::timestamp IS NULL
+       OR (gobits.published_at, gobits.id) < (sqlc.narg('before')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY gobits.published_at DESC, gobits.id DESC
+LIMIT sqlc.arg('limit');
+
+
+-- name: ListThreadGobitIDs :many
+-- Returns the gobit, the chain of gobits it replies to and every reply below
+-- it, however deep. Visibility is applied afterwards by GetVisibleGobitsByIDs.
+WITH RECURSIVE ancestors AS (
+    SELECT gobits.id, gobits.reply_to_id FROM gobits WHERE gobits.id = $1
+    UNION
+    SELECT gobits.id, gobits.reply_to_id FROM gobits
+    JOIN ancestors ON gobits.id = ancestors.reply_to_id
+), replies AS (
+    SELECT gobits.id FROM gobits WHERE gobits.id = $1
+    UNION
+    SELECT gobits.id FROM gobits
+    JOIN replies ON gobits.reply_to_id = replies.id
+)
+SELECT ancestors.id FROM ancestors
+UNION
+SELECT replies.id FROM replies;
+
+
+-- name: GetVisibleGobitsByIDs :many
+-- Loads the given gobits, dropping any the viewer isn't allowed to open.
+SELECT gobits.* FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE gobits.id = ANY(sqlc.arg('ids')::uuid[])
+  AND gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.status = 'published'
+  AND (users.account_status <> 'shadowbanned' OR gobits.user_id = sqlc.narg('viewer_id'))
+  AND NOT EXISTS (
+      SELECT 1 FROM user_blocks
+      WHERE (user_blocks.blocker_id = sqlc.narg('viewer_id') AND user_blocks.blocked_id = gobits.user_id)
+         OR (user_blocks.blocker_id = gobits.user_id AND user_blocks.blocked_id = sqlc.narg('viewer_id'))
+  )
+  AND (gobits.user_id = sqlc.narg('viewer_id')
+       OR gobits.visibility IN ('public', 'unlisted')
+       OR (gobits.visibility = 'followers' AND EXISTS (
+           SELECT 1 FROM follows
+           WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = gobits.user_id
+       )))
+ORDER BY gobits.published_at ASC;
+
+
+-- name: CreateRepost :one
+-- A repost takes the visibility of the gobit it reposts, so an unlisted
+-- gobit stays off public timelines.
+INSERT INTO gobits (id, created_at, updated_at, body, user_id, status, published_at, visibility, repost_of_id)
+VALUES (gen_random_uuid(), NOW(), NOW(), '', $1, 'published', NOW(), $2, $3)
+RETURNING *;
+
+
+-- name: DeleteRepost :many
+DELETE FROM gobits
+WHERE user_id = $1 AND repost_of_id = $2
+RETURNING *;
+
+
+-- name: CountRepostsForGobits :many
+SELECT repost_of_id, COUNT(*) AS repost_count FROM gobits
+WHERE repost_of_id = ANY(sqlc.arg('gobit_ids')::uuid[])
+  AND hidden_at IS NULL AND deleted_at IS NULL
+GROUP BY repost_of_id;
+
+
+-- name: PinGobit :execrows
+-- Pins the gobit unless its author already has max_pinned gobits pinned.
+-- Run it with the author's row locked so two pins cannot both squeeze in.
+UPDATE gobits
+SET pinned_at = NOW()
+WHERE id = sqlc.arg('id') AND user_id = sqlc.arg('user_id') AND pinned_at IS NULL
+  AND (
+      SELECT COUNT(*) FROM gobits AS pinned
+      WHERE pinned.user_id = sqlc.arg('user_id')
+        AND pinned.pinned_at IS NOT NULL AND pinned.deleted_at IS NULL
+  ) < sqlc.arg('max_pinned')::int;
+
+
+-- name: UnpinGobit :execrows
+UPDATE gobits
+SET pinned_at = NULL
+WHERE id = $1 AND user_id = $2 AND pinned_at IS NOT NULL;
+
+
+-- name: ListPublicGobits :many
+-- The newest public gobits for syndication, from everyone or from one
+-- author. Reposts are left out. Pass the published_at and id of the last
+-- gobit seen to get the next page.
+SELECT gobits.* FROM gobits
+JOIN users ON users.id = gobits.user_id
+WHERE gobits.visibility = 'public' AND gobits.status = 'published'
+  AND gobits.hidden_at IS NULL AND gobits.deleted_at IS NULL
+  AND gobits.repost_of_id IS NULL
+  AND users.account_status <> 'shadowbanned'
+  AND (sqlc.narg('user_id')::uuid IS NULL OR gobits.user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('before_published_at')::timestamp IS NULL
+       OR (gobits.published_at, gobits.id) < (sqlc.narg('before_published_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY gobits.published_at DESC, gobits.id DESC
+LIMIT sqlc.arg('limit');
+
+
+-- name: CountPublicGobits :one
+SELECT COUNT(*) FROM gobits
+WHERE user_id = $1 AND visibility = 'public' AND status = 'published'
+  AND hidden_at IS NULL AND deleted_at IS NULL AND repost_of_id IS NULL;
@@ -0,0 +1,48 @@
+-- name: EnqueueJob :one
+INSERT INTO jobs (id, created_at, updated_at, kind, payload, status, run_at, attempts, max_attempts)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    'queued',
+    $3,
+    0,
+    $4
+)
+RETURNING *;
+
+
+-- name: ClaimJob :one
+-- Takes the next due job, or one whose worker stopped responding. SKIP
+-- LOCKED lets several server instances poll without handing out a job twice.
+UPDATE jobs
+SET status = 'running', locked_at = NOW(), attempts = attempts + 1, updated_at = NOW()
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE (status = 'queued' AND run_at <= NOW())
+       OR (status = 'running' AND locked_at < sqlc.arg('stale_before'))
+    ORDER BY run_at
+    FOR UPDATE SKIP LOCKED
+    LIMIT 1
+)
+RETURNING *;
+
+
+-- name: CompleteJob :exec
+UPDATE jobs
+SET status = 'done', locked_at = NULL, completed_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+
+-- name: RetryJob :exec
+UPDATE jobs
+SET status = 'queued', locked_at = NULL, run_at = $2, last_error = $3, updated_at = NOW()
+WHERE id = $1;
+
+
+-- name: FailJob :exec
+UPDATE jobs
+SET status = 'failed', locked_at = NULL, last_error = $2, updated_at = NOW()
+WHERE id = $1;
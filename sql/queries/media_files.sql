@@ -0,0 +1,47 @@
+-- name: CreateMediaFile :one
+INSERT INTO media_files (id, created_at, user_id, content_type, size_bytes, width, height, storage_key, thumbnail_content_type, thumbnail_key)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
+
+
+-- name: GetMediaFile :one
+SELECT * FROM media_files WHERE id = $1;
+
+
+-- name: AttachMediaToGobit :exec
+INSERT INTO gobit_media (gobit_id, media_id, position)
+VALUES ($1, $2, $3);
+
+
+-- name: ListMediaForGobits :many
+SELECT gobit_media.gobit_id, media_files.* FROM gobit_media
+JOIN media_files ON media_files.id = gobit_media.media_id
+WHERE gobit_media.gobit_id = ANY(sqlc.arg('gobit_ids')::uuid[])
+ORDER BY gobit_media.gobit_id, gobit_media.position;
+
+
+-- name: ListGobitIDsForMedia :many
+SELECT gobit_id FROM gobit_media WHERE media_id = $1;
+
+
+-- name: IsAvatarMedia :one
+SELECT EXISTS (
+    SELECT 1 FROM users WHERE avatar_media_id = $1
+);
+
+
+-- name: ListMediaFilesByUser :many
+SELECT * FROM media_files
+WHERE user_id = $1
+ORDER BY created_at ASC;
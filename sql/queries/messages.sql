@@ -0,0 +1,40 @@
+-- name: CreateMessage :one
+INSERT INTO messages (id, created_at, conversation_id, sender_id, body)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3)
+RETURNING *;
+
+
+-- name: GetMessage :one
+SELECT * FROM messages
+WHERE id = $1 AND conversation_id = $2 AND deleted_at IS NULL;
+
+
+-- name: ListMessages :many
+-- Pages through a conversation, newest first. Pass the created_at and id of
+-- the last message seen to get the next page.
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg('conversation_id') AND deleted_at IS NULL
+  AND (sqlc.narg('before_created_at')::timestamp IS NULL
+       OR (created_at, id) < (sqlc.narg('before_created_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+
+-- name: ListLatestMessages :many
+-- Returns the newest message in each of the given conversations.
+SELECT DISTINCT ON (messages.conversation_id) messages.* FROM messages
+WHERE messages.conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[]) AND messages.deleted_at IS NULL
+ORDER BY messages.conversation_id, messages.created_at DESC, messages.id DESC;
+
+
+-- name: DeleteMessage :execrows
+-- Only the sender can delete a message. It disappears for everyone.
+UPDATE messages
+SET deleted_at = NOW(), body = ''
+WHERE id = $1 AND sender_id = $2 AND deleted_at IS NULL;
+
+
+-- name: ListMessagesBySender :many
+SELECT * FROM messages
+WHERE sender_id = $1 AND deleted_at IS NULL
+ORDER BY created_at ASC;
@@ -0,0 +1,28 @@
+-- name: CreateModerationRule :one
+INSERT INTO moderation_rules (id, created_at, updated_at, kind, pattern, action)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+
+-- name: ListModerationRules :many
+SELECT * FROM moderation_rules
+ORDER BY created_at ASC;
+
+
+-- name: UpdateModerationRule :one
+UPDATE moderation_rules
+SET kind = $2, pattern = $3, action = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: DeleteModerationRule :execrows
+DELETE FROM moderation_rules
+WHERE id = $1;
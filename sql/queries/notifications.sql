@@ -0,0 +1,83 @@
+-- name: UpsertNotification :one
+-- Returns the user's unread notification for the group, creating it if there
+-- isn't one.
+INSERT INTO notifications (id, created_at, updated_at, user_id, type, gobit_id, group_key)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4)
+ON CONFLICT (user_id, group_key) WHERE read_at IS NULL
+DO UPDATE SET updated_at = NOW()
+RETURNING *;
+
+
+-- name: CreateModerationNotice :one
+-- Moderation notices have no actors and are never folded into another
+-- notification.
+INSERT INTO notifications (id, created_at, updated_at, user_id, type, gobit_id, report_id, group_key)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5)
+RETURNING *;
+
+
+-- name: AddNotificationActor :exec
+INSERT INTO notification_actors (notification_id, actor_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (notification_id, actor_id) DO UPDATE SET created_at = NOW();
+
+
+-- name: ListNotifications :many
+-- Pages through a user's notifications, most recently active first. Pass the
+-- updated_at and id of the last notification seen to get the next page.
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('before_updated_at')::timestamp IS NULL
+       OR (updated_at, id) < (sqlc.narg('before_updated_at')::timestamp, sqlc.narg('before_id')::uuid))
+ORDER BY updated_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+
+-- name: ListNotificationActors :many
+-- Returns who triggered each notification, most recent first, with how many
+-- there are in total.
+SELECT notification_actors.notification_id, notification_actors.actor_id,
+       COUNT(*) OVER (PARTITION BY notification_actors.notification_id) AS actor_count
+FROM notification_actors
+WHERE notification_actors.notification_id = ANY(sqlc.arg('notification_ids')::uuid[])
+ORDER BY notification_actors.notification_id, notification_actors.created_at DESC;
+
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE id = $1 AND user_id = $2 AND read_at IS NULL;
+
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
+
+
+-- name: GetNotification :one
+SELECT * FROM notifications
+WHERE id = $1 AND user_id = $2;
+
+
+-- name: ListNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1;
+
+
+-- name: SetNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, type, enabled, updated_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = NOW();
+
+
+-- name: NotificationEnabled :one
+SELECT NOT EXISTS (
+    SELECT 1 FROM notification_preferences
+    WHERE user_id = $1 AND type = $2 AND NOT enabled
+) AS enabled;
@@ -0,0 +1,63 @@
+-- name: CreatePoll :one
+INSERT INTO polls (id, created_at, gobit_id, multiple_choice, expires_at)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3)
+RETURNING *;
+
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options (id, poll_id, position, title)
+VALUES (gen_random_uuid(), $1, $2, $3);
+
+
+-- name: GetPollByGobit :one
+SELECT * FROM polls WHERE gobit_id = $1;
+
+
+-- name: ListPollOptions :many
+SELECT * FROM poll_options
+WHERE poll_id = $1
+ORDER BY position ASC;
+
+
+-- name: ListPollsForGobits :many
+SELECT polls.*, (
+    SELECT COUNT(*) FROM poll_votes WHERE poll_votes.poll_id = polls.id
+) AS voters_count
+FROM polls
+WHERE polls.gobit_id = ANY(sqlc.arg('gobit_ids')::uuid[]);
+
+
+-- name: ListPollOptionTallies :many
+SELECT poll_options.id, poll_options.poll_id, poll_options.position, poll_options.title,
+       COUNT(poll_vote_options.option_id) AS votes
+FROM poll_options
+LEFT JOIN poll_vote_options ON poll_vote_options.option_id = poll_options.id
+WHERE poll_options.poll_id = ANY(sqlc.arg('poll_ids')::uuid[])
+GROUP BY poll_options.id
+ORDER BY poll_options.poll_id, poll_options.position ASC;
+
+
+-- name: ListUserPollVotes :many
+SELECT poll_id, option_id FROM poll_vote_options
+WHERE user_id = sqlc.arg('user_id') AND poll_id = ANY(sqlc.arg('poll_ids')::uuid[]);
+
+
+-- name: CastPollVote :execrows
+-- Records a user's vote in an open poll. Nothing is written, and no rows are
+-- affected, if the user has already voted or the poll has closed.
+WITH vote AS (
+    INSERT INTO poll_votes (poll_id, user_id, created_at)
+    SELECT polls.id, sqlc.arg('user_id')::uuid, NOW() FROM polls
+    WHERE polls.id = sqlc.arg('poll_id') AND polls.closed_at IS NULL AND polls.expires_at > NOW()
+    ON CONFLICT (poll_id, user_id) DO NOTHING
+    RETURNING poll_id, user_id
+)
+INSERT INTO poll_vote_options (poll_id, user_id, option_id)
+SELECT vote.poll_id, vote.user_id, unnest(sqlc.arg('option_ids')::uuid[]) FROM vote;
+
+
+-- name: CloseExpiredPolls :many
+UPDATE polls
+SET closed_at = NOW()
+WHERE closed_at IS NULL AND expires_at <= NOW()
+RETURNING *;
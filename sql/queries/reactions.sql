@@ -0,0 +1,23 @@
+-- name: UpsertReaction :one
+-- Sets the user's reaction to a gobit, replacing any earlier one. Inserted
+-- reports whether this is their first reaction to it.
+INSERT INTO reactions (user_id, gobit_id, kind, created_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (user_id, gobit_id) DO UPDATE SET kind = EXCLUDED.kind
+RETURNING (xmax = 0) AS inserted;
+
+
+-- name: DeleteReaction :execrows
+DELETE FROM reactions
+WHERE user_id = $1 AND gobit_id = $2;
+
+
+-- name: CountReactionsForGobits :many
+SELECT gobit_id, kind, COUNT(*) AS reaction_count FROM reactions
+WHERE gobit_id = ANY(sqlc.arg('gobit_ids')::uuid[])
+GROUP BY gobit_id, kind;
+
+
+-- name: ListUserReactions :many
+SELECT * FROM reactions
+WHERE user_id = sqlc.arg('user_id') AND gobit_id = ANY(sqlc.arg('gobit_ids')::uuid[]);
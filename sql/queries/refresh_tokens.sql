@@ -22,4 +22,22 @@ WHERE rt.token = $1;
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
-WHERE token = $1;
\ No newline at end of file
+WHERE token = $1;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+
+-- name: ListRefreshTokensByUser :many
+SELECT * FROM refresh_tokens
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+
+-- name: GetSession :one
+-- Finds the refresh token behind an access token's session ID, which is the
+-- hex SHA-256 of the token.
+SELECT * FROM refresh_tokens
+WHERE user_id = sqlc.arg('user_id') AND encode(sha256(convert_to(token, 'UTF8')), 'hex') = sqlc.arg('session_id');
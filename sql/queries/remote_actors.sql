@@ -0,0 +1,25 @@
+-- name: UpsertRemoteActor :one
+INSERT INTO remote_actors (id, created_at, updated_at, uri, handle, display_name, inbox_url, shared_inbox_url, followers_url, public_key_id, public_key_pem)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (uri) DO UPDATE SET
+    updated_at = NOW(),
+    handle = EXCLUDED.handle,
+    display_name = EXCLUDED.display_name,
+    inbox_url = EXCLUDED.inbox_url,
+    shared_inbox_url = EXCLUDED.shared_inbox_url,
+    followers_url = EXCLUDED.followers_url,
+    public_key_id = EXCLUDED.public_key_id,
+    public_key_pem = EXCLUDED.public_key_pem
+RETURNING *;
+
+-- name: GetRemoteActor :one
+SELECT * FROM remote_actors
+WHERE id = $1;
+
+-- name: GetRemoteActorByKeyID :one
+SELECT * FROM remote_actors
+WHERE public_key_id = $1;
+
+-- name: DeleteRemoteActorByURI :execrows
+DELETE FROM remote_actors
+WHERE uri = $1;
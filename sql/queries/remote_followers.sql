@@ -0,0 +1,21 @@
+-- name: CreateRemoteFollower :exec
+INSERT INTO remote_followers (user_id, actor_id, created_at, activity_uri)
+VALUES ($1, $2, NOW(), $3)
+ON CONFLICT (user_id, actor_id) DO UPDATE SET activity_uri = EXCLUDED.activity_uri;
+
+-- name: DeleteRemoteFollower :execrows
+-- Undoes a Follow, identified by the activity's id.
+DELETE FROM remote_followers
+WHERE actor_id = $1 AND activity_uri = $2;
+
+-- name: CountRemoteFollowers :one
+SELECT COUNT(*) FROM remote_followers
+WHERE user_id = $1;
+
+-- name: ListRemoteFollowerInboxes :many
+-- Where to deliver a user's activities: one inbox per remote server where
+-- the server has a shared inbox, otherwise one per follower.
+SELECT DISTINCT COALESCE(remote_actors.shared_inbox_url, remote_actors.inbox_url) AS inbox_url
+FROM remote_followers
+JOIN remote_actors ON remote_actors.id = remote_followers.actor_id
+WHERE remote_followers.user_id = $1;
@@ -0,0 +1,35 @@
+-- name: CreateRemoteFollowing :one
+INSERT INTO remote_following (user_id, actor_id, created_at, activity_uri)
+VALUES ($1, $2, NOW(), $3)
+ON CONFLICT (user_id, actor_id) DO UPDATE SET user_id = remote_following.user_id
+RETURNING *;
+
+-- name: AcceptRemoteFollowing :execrows
+UPDATE remote_following
+SET accepted_at = NOW()
+WHERE actor_id = $1 AND activity_uri = $2 AND accepted_at IS NULL;
+
+-- name: RejectRemoteFollowing :execrows
+DELETE FROM remote_following
+WHERE actor_id = $1 AND activity_uri = $2;
+
+-- name: DeleteRemoteFollowing :one
+DELETE FROM remote_following
+WHERE user_id = $1 AND actor_id = $2
+RETURNING *;
+
+-- name: ListRemoteFollowing :many
+SELECT remote_actors.id, remote_actors.uri, remote_actors.handle, remote_actors.display_name,
+       remote_following.created_at, remote_following.accepted_at
+FROM remote_following
+JOIN remote_actors ON remote_actors.id = remote_following.actor_id
+WHERE remote_following.user_id = $1
+ORDER BY remote_following.created_at DESC;
+
+-- name: HasLocalFollowers :one
+-- Reports whether anyone here follows the remote actor, so their posts are
+-- worth keeping.
+SELECT EXISTS (
+    SELECT 1 FROM remote_following
+    WHERE actor_id = $1 AND accepted_at IS NOT NULL
+);
@@ -0,0 +1,13 @@
+-- name: CreateRemoteLike :exec
+INSERT INTO remote_likes (actor_id, gobit_id, created_at, activity_uri)
+VALUES ($1, $2, NOW(), $3)
+ON CONFLICT (actor_id, gobit_id) DO UPDATE SET activity_uri = EXCLUDED.activity_uri;
+
+-- name: DeleteRemoteLike :execrows
+-- Undoes a Like, identified by the activity's id.
+DELETE FROM remote_likes
+WHERE actor_id = $1 AND activity_uri = $2;
+
+-- name: CountRemoteLikes :one
+SELECT COUNT(*) FROM remote_likes
+WHERE gobit_id = $1;
@@ -0,0 +1,26 @@
+-- name: CreateRemoteNote :exec
+INSERT INTO remote_notes (id, created_at, uri, actor_id, url, content, in_reply_to_id, published_at)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3, $4, $5, $6)
+ON CONFLICT (uri) DO NOTHING;
+
+-- name: DeleteRemoteNote :execrows
+DELETE FROM remote_notes
+WHERE uri = $1 AND actor_id = $2;
+
+-- name: ListRemoteTimeline :many
+-- Pages through the notes of the remote accounts a user follows, newest
+-- first. Pass the published_at and id of the last note seen to get the next
+-- page.
+SELECT remote_notes.id, remote_notes.uri, remote_notes.url, remote_notes.content,
+       remote_notes.in_reply_to_id, remote_notes.published_at,
+       remote_actors.id AS actor_id, remote_actors.uri AS actor_uri,
+       remote_actors.handle AS actor_handle, remote_actors.display_name AS actor_display_name
+FROM remote_notes
+JOIN remote_actors ON remote_actors.id = remote_notes.actor_id
+JOIN remote_following ON remote_following.actor_id = remote_notes.actor_id
+     AND remote_following.user_id = sqlc.arg('user_id')
+     AND remote_following.accepted_at IS NOT NULL
+WHERE sqlc.narg('before_published_at')::timestamp IS NULL
+   OR (remote_notes.published_at, remote_notes.id) < (sqlc.narg('before_published_at')::timestamp, sqlc.narg('before_id')::uuid)
+ORDER BY remote_notes.published_at DESC, remote_notes.id DESC
+LIMIT sqlc.arg('limit');
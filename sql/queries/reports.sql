@@ -0,0 +1,63 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, reporter_id, target_type, target_id, reason, status)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    'open'
+)
+RETURNING *;
+
+
+-- name: GetReport :one
+SELECT * FROM reports WHERE id = $1;
+
+
+-- name: ListReports :many
+SELECT * FROM reports
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('target_type')::text IS NULL OR target_type = sqlc.narg('target_type'))
+  AND (sqlc.narg('assignee_id')::uuid IS NULL OR assignee_id = sqlc.narg('assignee_id'))
+  AND (NOT sqlc.arg('unassigned')::bool OR assignee_id IS NULL)
+ORDER BY created_at ASC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+
+-- name: ListReportsByReporter :many
+SELECT * FROM reports
+WHERE reporter_id = $1
+ORDER BY created_at DESC;
+
+
+-- name: AssignReport :one
+UPDATE reports
+SET assignee_id = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: ResolveReport :one
+UPDATE reports
+SET status = $2, outcome = $3, resolved_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'open'
+RETURNING *;
+
+
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, created_at, moderator_id, report_id, action, target_type, target_id, reason)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
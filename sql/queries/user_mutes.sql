@@ -0,0 +1,22 @@
+-- name: CreateUserMute :exec
+INSERT INTO user_mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (muter_id, muted_id) DO NOTHING;
+
+
+-- name: DeleteUserMute :execrows
+DELETE FROM user_mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+
+-- name: ListUserMutes :many
+SELECT * FROM user_mutes
+WHERE muter_id = $1
+ORDER BY created_at DESC;
+
+
+-- name: IsMuting :one
+SELECT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE muter_id = $1 AND muted_id = $2
+);
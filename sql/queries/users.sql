@@ -17,15 +17,84 @@ SELECT * FROM users WHERE email = $1;
 -- name: ResetUsers :exec
 DELETE FROM users;
 
--- name: UpdateUser :one
+-- name: UpdateUserMembership :one
 UPDATE users
-SET email = $2, hashed_password = $3, updated_at = NOW()
+SET is_gohost_red = true
 WHERE id = $1
 RETURNING *;
 
 
--- name: UpdateUserMembership :one
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1;
+
+
+-- name: GetUserByIDForUpdate :one
+-- Locks the user's row until the end of the transaction.
+SELECT * FROM users WHERE id = $1 FOR UPDATE;
+
+
+-- name: SetUserAccountStatus :one
 UPDATE users
-SET is_gohost_red = true
+SET account_status = $2, suspended_until = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: GetUserByHandle :one
+SELECT * FROM users WHERE handle = sqlc.arg('handle')::text;
+
+
+-- name: GetUsersByIDs :many
+SELECT * FROM users WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = $2, display_name = $3, bio = $4, website = $5, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: SetUserAvatar :one
+UPDATE users
+SET avatar_media_id = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: SetVerifiedUserEmail :one
+-- Switches the user to an address they have just verified.
+UPDATE users
+SET email = $2, email_verified_at = NOW(), updated_at = NOW()
 WHERE id = $1
 RETURNING *;
+
+
+-- name: ScheduleUserDeletion :one
+UPDATE users
+SET deletion_scheduled_for = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: CancelUserDeletion :one
+UPDATE users
+SET deletion_scheduled_for = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: DeleteUserIfDue :execrows
+DELETE FROM users
+WHERE id = $1 AND deletion_scheduled_for IS NOT NULL AND deletion_scheduled_for <= NOW();
+
+
+-- name: GetUsersByHandles :many
+SELECT * FROM users WHERE handle = ANY(sqlc.arg('handles')::text[]);
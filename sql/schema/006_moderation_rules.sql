@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE moderation_rules (
+    id UUID NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('word', 'regex')),
+    pattern TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('mask', 'reject', 'flag')),
+    UNIQUE (kind, pattern)
+);
+
+INSERT INTO moderation_rules (id, created_at, updated_at, kind, pattern, action)
+VALUES
+    (gen_random_uuid(), NOW(), NOW(), 'word', 'kerfuffle', 'mask'),
+    (gen_random_uuid(), NOW(), NOW(), 'word', 'sharbert', 'mask'),
+    (gen_random_uuid(), NOW(), NOW(), 'word', 'fornax', 'mask');
+
+-- +goose Down
+DROP TABLE moderation_rules;
@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE reports (
+    id UUID NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    reporter_id UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    target_type TEXT NOT NULL CHECK (target_type IN ('gobit', 'user')),
+    target_id UUID NOT NULL,
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved', 'dismissed')),
+    assignee_id UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    outcome TEXT NULL,
+    resolved_at TIMESTAMP NULL
+);
+
+CREATE INDEX reports_status_created_at_idx ON reports (status, created_at);
+
+CREATE TABLE moderation_actions (
+    id UUID NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    moderator_id UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    report_id UUID NULL REFERENCES reports(id) ON DELETE SET NULL,
+    action TEXT NOT NULL CHECK (action IN ('hide', 'delete', 'warn', 'suspend', 'dismiss')),
+    target_type TEXT NOT NULL CHECK (target_type IN ('gobit', 'user')),
+    target_id UUID NOT NULL,
+    reason TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE moderation_actions;
+DROP TABLE reports;
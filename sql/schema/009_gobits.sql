@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE gobits ADD COLUMN hidden_at TIMESTAMP NULL;
+
+-- +goose Down
+ALTER TABLE gobits DROP COLUMN hidden_at;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN account_status TEXT NOT NULL DEFAULT 'active'
+    CHECK (account_status IN ('active', 'suspended', 'banned', 'shadowbanned'));
+
+UPDATE users SET account_status = 'suspended' WHERE suspended_until > NOW();
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN account_status;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE account_status_events (
+    id UUID NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    actor_id UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+    old_status TEXT NOT NULL,
+    new_status TEXT NOT NULL,
+    suspended_until TIMESTAMP NULL,
+    reason TEXT NOT NULL
+);
+
+CREATE INDEX account_status_events_user_id_idx ON account_status_events (user_id, created_at);
+
+-- +goose Down
+DROP TABLE account_status_events;
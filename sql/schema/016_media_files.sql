@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE media_files (
+    id UUID NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    storage_key TEXT NOT NULL,
+    thumbnail_content_type TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL
+);
+
+CREATE TABLE gobit_media (
+    gobit_id UUID NOT NULL REFERENCES gobits(id) ON DELETE CASCADE,
+    media_id UUID NOT NULL REFERENCES media_files(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (gobit_id, media_id)
+);
+
+-- +goose Down
+DROP TABLE gobit_media;
+DROP TABLE media_files;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN avatar_media_id UUID NULL REFERENCES media_files(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN avatar_media_id;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE jobs (
+    id UUID NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    kind TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'done', 'failed')),
+    run_at TIMESTAMP NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    locked_at TIMESTAMP NULL,
+    last_error TEXT NULL,
+    completed_at TIMESTAMP NULL
+);
+
+CREATE INDEX jobs_status_run_at_idx ON jobs (status, run_at);
+
+-- +goose Down
+DROP TABLE jobs;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE subscription_events (
+    id UUID NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event TEXT NOT NULL
+);
+
+CREATE INDEX subscription_events_user_id_idx ON subscription_events (user_id, created_at);
+
+-- +goose Down
+DROP TABLE subscription_events;
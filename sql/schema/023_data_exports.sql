@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE data_exports (
+    id UUID NOT NULL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')),
+    storage_key TEXT NULL,
+    size_bytes BIGINT NULL,
+    expires_at TIMESTAMP NULL
+);
+
+CREATE INDEX data_exports_user_id_idx ON data_exports (user_id);
+
+-- +goose Down
+DROP TABLE data_exports;
@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE gobits ADD COLUMN deleted_at TIMESTAMP NULL;
+ALTER TABLE gobits ADD COLUMN deleted_by UUID NULL REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX gobits_deleted_at_idx ON gobits (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX gobits_deleted_at_idx;
+ALTER TABLE gobits DROP COLUMN deleted_by;
+ALTER TABLE gobits DROP COLUMN deleted_at;
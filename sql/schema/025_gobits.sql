@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE gobits ADD COLUMN status TEXT NOT NULL DEFAULT 'published' CHECK (status IN ('draft', 'scheduled', 'published'));
+ALTER TABLE gobits ADD COLUMN publish_at TIMESTAMP NULL;
+ALTER TABLE gobits ADD COLUMN published_at TIMESTAMP NULL;
+
+UPDATE gobits SET published_at = created_at;
+
+CREATE INDEX gobits_scheduled_publish_at_idx ON gobits (publish_at) WHERE status = 'scheduled';
+
+-- +goose Down
+DROP INDEX gobits_scheduled_publish_at_idx;
+ALTER TABLE gobits DROP COLUMN published_at;
+ALTER TABLE gobits DROP COLUMN publish_at;
+ALTER TABLE gobits DROP COLUMN status;
@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE gobits ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'followers', 'unlisted', 'private'));
+ALTER TABLE gobits ADD COLUMN reply_to_id UUID NULL REFERENCES gobits(id) ON DELETE SET NULL;
+
+CREATE INDEX gobits_reply_to_id_idx ON gobits (reply_to_id);
+
+-- +goose Down
+DROP INDEX gobits_reply_to_id_idx;
+ALTER TABLE gobits DROP COLUMN reply_to_id;
+ALTER TABLE gobits DROP COLUMN visibility;
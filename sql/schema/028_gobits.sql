@@ -0,0 +1,17 @@
+-- +goose Up
+-- A repost is a gobit with no body of its own that goes when the original
+-- does. A quote keeps its body if the quoted gobit is removed.
+ALTER TABLE gobits ADD COLUMN repost_of_id UUID NULL REFERENCES gobits(id) ON DELETE CASCADE;
+ALTER TABLE gobits ADD COLUMN quote_of_id UUID NULL REFERENCES gobits(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX gobits_user_id_repost_of_id_idx ON gobits (user_id, repost_of_id)
+    WHERE repost_of_id IS NOT NULL AND deleted_at IS NULL;
+CREATE INDEX gobits_repost_of_id_idx ON gobits (repost_of_id);
+CREATE INDEX gobits_quote_of_id_idx ON gobits (quote_of_id);
+
+-- +goose Down
+DROP INDEX gobits_quote_of_id_idx;
+DROP INDEX gobits_repost_of_id_idx;
+DROP INDEX gobits_user_id_repost_of_id_idx;
+ALTER TABLE gobits DROP COLUMN quote_of_id;
+ALTER TABLE gobits DROP COLUMN repost_of_id;
@@ -0,0 +1,46 @@
+-- +goose Up
+CREATE TABLE polls (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    gobit_id UUID NOT NULL UNIQUE REFERENCES gobits(id) ON DELETE CASCADE,
+    multiple_choice BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP NULL
+);
+
+CREATE INDEX polls_open_expires_at_idx ON polls (expires_at) WHERE closed_at IS NULL;
+
+CREATE TABLE poll_options (
+    id UUID PRIMARY KEY,
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    UNIQUE (poll_id, position),
+    UNIQUE (poll_id, id)
+);
+
+-- One row per voter keeps each user to a single vote, however many options
+-- a multiple-choice vote picks
+CREATE TABLE poll_votes (
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (poll_id, user_id)
+);
+
+CREATE TABLE poll_vote_options (
+    poll_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    option_id UUID NOT NULL,
+    PRIMARY KEY (poll_id, user_id, option_id),
+    FOREIGN KEY (poll_id, user_id) REFERENCES poll_votes(poll_id, user_id) ON DELETE CASCADE,
+    FOREIGN KEY (poll_id, option_id) REFERENCES poll_options(poll_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX poll_vote_options_option_id_idx ON poll_vote_options (option_id);
+
+-- +goose Down
+DROP TABLE poll_vote_options;
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
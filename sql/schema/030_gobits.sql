@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE gobits ADD COLUMN pinned_at TIMESTAMP NULL;
+
+CREATE INDEX gobits_pinned_user_id_idx ON gobits (user_id) WHERE pinned_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX gobits_pinned_user_id_idx;
+ALTER TABLE gobits DROP COLUMN pinned_at;
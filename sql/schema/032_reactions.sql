@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE reactions (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    gobit_id UUID NOT NULL REFERENCES gobits(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('like', 'love', 'laugh', 'wow', 'sad')),
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, gobit_id)
+);
+
+CREATE INDEX reactions_gobit_id_idx ON reactions (gobit_id);
+
+-- +goose Down
+DROP TABLE reactions;
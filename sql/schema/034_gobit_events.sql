@@ -0,0 +1,16 @@
+-- +goose Up
+-- A short log of gobits appearing and disappearing, streamed to clients. The
+-- id doubles as the SSE event ID, so clients can resume where they left off.
+CREATE TABLE gobit_events (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('gobit.created', 'gobit.deleted')),
+    gobit_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    visibility TEXT NOT NULL
+);
+
+CREATE INDEX gobit_events_created_at_idx ON gobit_events (created_at);
+
+-- +goose Down
+DROP TABLE gobit_events;
@@ -0,0 +1,80 @@
+-- +goose Up
+-- Each local account signs its ActivityPub requests with its own key pair,
+-- generated the first time it is needed
+CREATE TABLE actor_keys (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    public_key_pem TEXT NOT NULL,
+    private_key_pem TEXT NOT NULL
+);
+
+-- Accounts on other servers, cached from their actor documents
+CREATE TABLE remote_actors (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    uri TEXT NOT NULL UNIQUE,
+    -- user@host, as looked up through WebFinger
+    handle TEXT NOT NULL,
+    display_name TEXT NOT NULL,
+    inbox_url TEXT NOT NULL,
+    shared_inbox_url TEXT NULL,
+    followers_url TEXT NULL,
+    public_key_id TEXT NOT NULL UNIQUE,
+    public_key_pem TEXT NOT NULL
+);
+
+-- Remote accounts following local users
+CREATE TABLE remote_followers (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES remote_actors(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    -- The Follow activity's id, which an Undo refers back to
+    activity_uri TEXT NOT NULL,
+    PRIMARY KEY (user_id, actor_id)
+);
+
+-- Local users following remote accounts, pending until the remote server
+-- sends an Accept
+CREATE TABLE remote_following (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES remote_actors(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    activity_uri TEXT NOT NULL UNIQUE,
+    accepted_at TIMESTAMP NULL,
+    PRIMARY KEY (user_id, actor_id)
+);
+
+CREATE INDEX remote_following_actor_id_idx ON remote_following (actor_id);
+
+-- Notes delivered by remote accounts that local users follow or that reply
+-- to local gobits. content is stored as plain text.
+CREATE TABLE remote_notes (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    uri TEXT NOT NULL UNIQUE,
+    actor_id UUID NOT NULL REFERENCES remote_actors(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    content TEXT NOT NULL,
+    in_reply_to_id UUID NULL REFERENCES gobits(id) ON DELETE SET NULL,
+    published_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX remote_notes_actor_id_published_at_idx ON remote_notes (actor_id, published_at DESC, id DESC);
+
+-- Likes of local gobits from remote accounts
+CREATE TABLE remote_likes (
+    actor_id UUID NOT NULL REFERENCES remote_actors(id) ON DELETE CASCADE,
+    gobit_id UUID NOT NULL REFERENCES gobits(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    activity_uri TEXT NOT NULL,
+    PRIMARY KEY (actor_id, gobit_id)
+);
+
+-- +goose Down
+DROP TABLE remote_likes;
+DROP TABLE remote_notes;
+DROP TABLE remote_following;
+DROP TABLE remote_followers;
+DROP TABLE remote_actors;
+DROP TABLE actor_keys;
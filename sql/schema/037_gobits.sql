@@ -0,0 +1,8 @@
+-- +goose Up
+-- Keyset pagination of listings, newest first
+CREATE INDEX gobits_published_at_id_idx ON gobits (published_at DESC, id DESC) WHERE status = 'published';
+CREATE INDEX gobits_user_id_published_at_id_idx ON gobits (user_id, published_at DESC, id DESC) WHERE status = 'published';
+
+-- +goose Down
+DROP INDEX gobits_user_id_published_at_id_idx;
+DROP INDEX gobits_published_at_id_idx;
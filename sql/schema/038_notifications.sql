@@ -0,0 +1,14 @@
+-- +goose Up
+-- Moderation notices: warnings to the account acted on and outcomes to
+-- reporters. They have no actors and can't be turned off.
+ALTER TABLE notifications DROP CONSTRAINT notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('mention', 'reply', 'follow', 'reaction', 'repost', 'warning', 'report_resolved'));
+ALTER TABLE notifications ADD COLUMN report_id UUID NULL REFERENCES reports(id) ON DELETE CASCADE;
+
+-- +goose Down
+DELETE FROM notifications WHERE type IN ('warning', 'report_resolved');
+ALTER TABLE notifications DROP COLUMN report_id;
+ALTER TABLE notifications DROP CONSTRAINT notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('mention', 'reply', 'follow', 'reaction', 'repost'));
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	eventGobitCreated = "gobit.created"
+	eventGobitDeleted = "gobit.deleted"
+
+	// gobitEventsChannel is the pub/sub channel new gobit events go out on
+	gobitEventsChannel = "gobit_events"
+
+	streamHeartbeatInterval = 15 * time.Second
+	// A client that doesn't take an event within streamWriteTimeout is
+	// disconnected; it can reconnect and resume with Last-Event-ID.
+	streamWriteTimeout = 10 * time.Second
+	// How many live events are held for a connection before it has to catch
+	// up from the database instead
+	streamBufferSize  = 64
+	streamReplayPage  = 100
+	streamRetryMillis = 3000
+	// Events are published as their transactions commit, so one can arrive
+	// after a newer one. A client still takes it for this long after the
+	// events around it.
+	streamLagWindow = time.Minute
+
+	// Events are kept long enough for clients to resume after a short
+	// disconnect
+	gobitEventRetention     = 24 * time.Hour
+	gobitEventPruneInterval = time.Hour
+)
+
+// gobitEventMessage is what goes out over pub/sub. The gobit itself is
+// loaded on the way to the streams, since what each client may see depends
+// on who it is.
+type gobitEventMessage struct {
+	ID         int64     `json:"id"`
+	Type       string    `json:"type"`
+	GobitID    uuid.UUID `json:"gobit_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Visibility string    `json:"visibility"`
+}
+
+type deletedGobitEvent struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// publishGobitEvent records a gobit appearing or disappearing and tells
+// every open stream about it.
+func (cfg *apiConfig) publishGobitEvent(ctx context.Context, kind string, gobit database.Gobit) error {
+	event, err := cfg.db.CreateGobitEvent(ctx, database.CreateGobitEventParams{
+		Type:       kind,
+		GobitID:    gobit.ID,
+		UserID:     gobit.UserID,
+		Visibility: gobit.Visibility,
+	})
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(gobitEventMessage{
+		ID:         event.ID,
+		Type:       event.Type,
+		GobitID:    event.GobitID,
+		UserID:     event.UserID,
+		Visibility: event.Visibility,
+	})
+	if err != nil {
+		return err
+	}
+	return cfg.pubsub.Publish(ctx, gobitEventsChannel, payload)
+}
+
+// gobitDeleted streams the removal of a gobit. Failures are logged; the
+// gobit stays deleted.
+func (cfg *apiConfig) gobitDeleted(ctx context.Context, gobit database.Gobit) {
+	if err := cfg.publishGobitEvent(ctx, eventGobitDeleted, gobit); err != nil {
+		log.Printf("Error streaming deletion of gobit %s: %v", gobit.ID, err)
+	}
+	if err := cfg.federateDeleted(ctx, gobit); err != nil {
+		log.Printf("Error federating deletion of gobit %s: %v", gobit.ID, err)
+	}
+}
+
+// pruneGobitEvents drops events too old to resume from.
+func (cfg *apiConfig) pruneGobitEvents(ctx context.Context) error {
+	pruned, err := cfg.db.DeleteGobitEventsBefore(ctx, time.Now().Add(-gobitEventRetention))
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		log.Printf("Pruned %d gobit events", pruned)
+	}
+	return nil
+}
+
+// gobitEventFilter decides which events one client sees and what it gets
+// for each, applying the same rules as the listings.
+type gobitEventFilter struct {
+	cfg       *apiConfig
+	r         *http.Request
+	viewerID  uuid.NullUUID
+	authorID  uuid.NullUUID
+	following bool
+	// threadID limits events to the thread around one gobit
+	threadID uuid.NullUUID
+	// lastID is the newest event passed on, or skipped, for this client
+	lastID int64
+	// Every event up to floor has been handled. seen holds the ones handled
+	// above it, in order, until they are older than streamLagWindow.
+	floor int64
+	seen  map[int64]bool
+	order []seenGobitEvent
+}
+
+type seenGobitEvent struct {
+	id int64
+	at time.Time
+}
+
+// gobitStream is one client's Server-Sent Events connection.
+type gobitStream struct {
+	*gobitEventFilter
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// streamGobits streams gobit.created and gobit.deleted events as
+// Server-Sent Events. Like the timeline, it shows what the optional bearer
+// token's user may see. ?author_id= limits it to one author and
+// ?following=true to the people the viewer follows. Clients resume with the
+// Last-Event-ID header, or last_event_id on the first connection.
+func (cfg *apiConfig) streamGobits(w http.ResponseWriter, r *http.Request) {
+	filter := &gobitEventFilter{
+		cfg:      cfg,
+		r:        r,
+		viewerID: cfg.optionalViewer(r),
+	}
+
+	query := r.URL.Query()
+	if authorIDStr := query.Get("author_id"); authorIDStr != "" {
+		authorID, err := uuid.Parse(authorIDStr)
+		if err != nil {
+			http.Error(w, "Invalid author_id format", http.StatusBadRequest)
+			return
+		}
+		filter.authorID = uuid.NullUUID{UUID: authorID, Valid: true}
+	}
+	if query.Get("following") == "true" {
+		if !filter.viewerID.Valid {
+			http.Error(w, "Unauthorized: following requires a valid token", http.StatusUnauthorized)
+			return
+		}
+		filter.following = true
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = query.Get("last_event_id")
+	}
+	resume := lastEventID != ""
+	if resume {
+		lastID, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastID < 0 {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		filter.lastID = lastID
+		filter.floor = lastID
+	}
+
+	stream := &gobitStream{
+		gobitEventFilter: filter,
+		w:                w,
+		rc:               http.NewResponseController(w),
+	}
+
+	// Subscribe before catching up so nothing published meanwhile is missed
+	sub := cfg.gobitEvents.Subscribe(filter, streamBufferSize)
+	defer func() { sub.Close() }()
+	if !resume {
+		if err := filter.start(); err != nil {
+			log.Printf("Error starting gobit stream: %v", err)
+			http.Error(w, "Failed to start stream", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := stream.write(fmt.Sprintf("retry: %d\n\n", streamRetryMillis)); err != nil {
+		return
+	}
+
+	if resume {
+		if err := filter.catchUp(stream.send); err != nil {
+			log.Printf("Error replaying gobit events after %d: %v", filter.lastID, err)
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if err := stream.write(": heartbeat\n\n"); err != nil {
+				return
+			}
+		case delivery, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind; pick up the rest from the
+				// database
+				sub = cfg.gobitEvents.Subscribe(filter, streamBufferSize)
+				if err := filter.catchUp(stream.send); err != nil {
+					log.Printf("Error catching up gobit events after %d: %v", filter.lastID, err)
+					return
+				}
+				continue
+			}
+			if filter.handled(delivery.Event.ID) {
+				continue
+			}
+			if err := stream.writeEvent(delivery.Event, delivery.Data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// send writes a stored event if this client should see it.
+func (s *gobitStream) send(event gobitEventMessage) error {
+	data, ok := s.next(event)
+	if !ok {
+		return nil
+	}
+	return s.writeEvent(event, data)
+}
+
+func (s *gobitStream) writeEvent(event gobitEventMessage, data []byte) error {
+	return s.write(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data))
+}
+
+// write sends a chunk of the stream straight away, giving up on clients
+// that have stopped reading.
+func (s *gobitStream) write(chunk string) error {
+	s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if _, err := s.w.Write([]byte(chunk)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// catchUp hands every stored event after floor to send, which also picks up
+// events that committed after newer ones were seen. Those already handled
+// are skipped.
+func (f *gobitEventFilter) catchUp(send func(gobitEventMessage) error) error {
+	after := f.floor
+	for {
+		events, err := f.cfg.db.ListGobitEventsAfter(f.r.Context(), database.ListGobitEventsAfterParams{
+			ID:    after,
+			Limit: streamReplayPage,
+		})
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			after = event.ID
+			err := send(gobitEventMessage{
+				ID:         event.ID,
+				Type:       event.Type,
+				GobitID:    event.GobitID,
+				UserID:     event.UserID,
+				Visibility: event.Visibility,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(events) < streamReplayPage {
+			return nil
+		}
+	}
+}
+
+// start has a new client take events from now on, so that catching up after
+// falling behind doesn't replay everything stored.
+func (f *gobitEventFilter) start() error {
+	latest, err := f.cfg.db.GetLatestGobitEventID(f.r.Context())
+	if err != nil {
+		return err
+	}
+	f.lastID = latest
+	f.floor = latest
+	return nil
+}
+
+// next returns a stored event's payload if this client should see it and
+// hasn't already.
+func (f *gobitEventFilter) next(event gobitEventMessage) ([]byte, bool) {
+	if f.handled(event.ID) {
+		return nil, false
+	}
+
+	payloads, err := f.cfg.resolveGobitEvent(f.r.Context(), event, []*gobitEventFilter{f})
+	if err != nil {
+		// One bad event shouldn't end the stream
+		log.Printf("Error preparing gobit event %d: %v", event.ID, err)
+		return nil, false
+	}
+	data, ok := payloads[f]
+	return data, ok
+}
+
+// handled reports whether the event was already passed on, or skipped, for
+// this client, and marks it handled if not. Live events overlap a catch-up,
+// and can arrive out of order, so this goes by ID rather than by the newest
+// event seen.
+func (f *gobitEventFilter) handled(id int64) bool {
+	now := time.Now()
+	for len(f.order) > 0 && now.Sub(f.order[0].at) > streamLagWindow {
+		delete(f.seen, f.order[0].id)
+		f.floor = max(f.floor, f.order[0].id)
+		f.order = f.order[1:]
+	}
+
+	if id <= f.floor || f.seen[id] {
+		return true
+	}
+	if f.seen == nil {
+		f.seen = make(map[int64]bool)
+	}
+	f.seen[id] = true
+	f.order = append(f.order, seenGobitEvent{id: id, at: now})
+	f.lastID = max(f.lastID, id)
+	return false
+}
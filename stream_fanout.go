@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+// gobitEventFanoutBuffer is how many events the fanout holds while it works
+// through earlier ones; past that every stream catches up from the database.
+const gobitEventFanoutBuffer = 1024
+
+// gobitEventFanout takes each gobit event off pub/sub once and hands it to
+// the open streams that should see it. Who may see an event is worked out
+// once for all of them rather than by every stream on its own.
+type gobitEventFanout struct {
+	cfg *apiConfig
+
+	mu   sync.Mutex
+	subs map[*gobitEventSubscription]struct{}
+}
+
+// gobitEventSubscription receives the events one stream's filter lets
+// through. Like a pubsub.Subscription, it is dropped and C is closed when
+// the stream falls more than its buffer behind or events may have gone
+// missing, so the stream can catch up from the database and subscribe
+// again. C is also closed by Close.
+type gobitEventSubscription struct {
+	C <-chan gobitEventDelivery
+
+	c      chan gobitEventDelivery
+	filter *gobitEventFilter
+	fanout *gobitEventFanout
+	closed bool
+}
+
+// gobitEventDelivery is an event and the payload one stream gets for it.
+type gobitEventDelivery struct {
+	Event gobitEventMessage
+	Data  []byte
+}
+
+func newGobitEventFanout(cfg *apiConfig) *gobitEventFanout {
+	return &gobitEventFanout{
+		cfg:  cfg,
+		subs: make(map[*gobitEventSubscription]struct{}),
+	}
+}
+
+// Subscribe starts handing the events filter lets through to the returned
+// subscription, holding up to buffer of them for a slow stream.
+func (f *gobitEventFanout) Subscribe(filter *gobitEventFilter, buffer int) *gobitEventSubscription {
+	c := make(chan gobitEventDelivery, buffer)
+	sub := &gobitEventSubscription{C: c, c: c, filter: filter, fanout: f}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *gobitEventSubscription) Close() {
+	s.fanout.mu.Lock()
+	defer s.fanout.mu.Unlock()
+	s.fanout.drop(s)
+}
+
+// drop removes a subscription and closes its channel. f.mu must be held.
+func (f *gobitEventFanout) drop(sub *gobitEventSubscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(f.subs, sub)
+	close(sub.c)
+}
+
+// run relays gobit events until ctx is done.
+func (f *gobitEventFanout) run(ctx context.Context) {
+	sub := f.cfg.pubsub.Subscribe(gobitEventsChannel, gobitEventFanoutBuffer)
+	defer func() { sub.Close() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case payload, ok := <-sub.C:
+			if !ok {
+				// Events went by unseen, so every stream has to pick them
+				// up from the database
+				sub = f.cfg.pubsub.Subscribe(gobitEventsChannel, gobitEventFanoutBuffer)
+				f.mu.Lock()
+				for s := range f.subs {
+					f.drop(s)
+				}
+				f.mu.Unlock()
+				continue
+			}
+
+			var event gobitEventMessage
+			if err := json.Unmarshal(payload, &event); err != nil {
+				log.Printf("Error decoding gobit event: %v", err)
+				continue
+			}
+			f.deliver(ctx, event)
+		}
+	}
+}
+
+// deliver hands the event to every subscription that should see it.
+func (f *gobitEventFanout) deliver(ctx context.Context, event gobitEventMessage) {
+	f.mu.Lock()
+	filters := make([]*gobitEventFilter, 0, len(f.subs))
+	for sub := range f.subs {
+		filters = append(filters, sub.filter)
+	}
+	f.mu.Unlock()
+	if len(filters) == 0 {
+		return
+	}
+
+	payloads, err := f.cfg.resolveGobitEvent(ctx, event, filters)
+	if err != nil {
+		// One bad event shouldn't end the streams
+		log.Printf("Error preparing gobit event %d: %v", event.ID, err)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subs {
+		data, ok := payloads[sub.filter]
+		if !ok {
+			continue
+		}
+		select {
+		case sub.c <- gobitEventDelivery{Event: event, Data: data}:
+		default:
+			f.drop(sub)
+		}
+	}
+}
+
+// resolveGobitEvent works out what the client behind each filter gets for
+// the event, applying the same rules as the listings, and leaves out those
+// that shouldn't see it. The gobit, its author and how each viewer stands
+// with the author are looked up once for every filter. A created gobit's
+// payload carries the viewer's own reactions and votes, so that part is
+// built once per viewer.
+func (cfg *apiConfig) resolveGobitEvent(ctx context.Context, event gobitEventMessage, filters []*gobitEventFilter) (map[*gobitEventFilter][]byte, error) {
+	var candidates []*gobitEventFilter
+	viewerSet := make(map[uuid.UUID]bool)
+	threadSet := make(map[uuid.UUID]bool)
+	for _, f := range filters {
+		if f.authorID.Valid && f.authorID.UUID != event.UserID {
+			continue
+		}
+		candidates = append(candidates, f)
+		if f.viewerID.Valid && f.viewerID.UUID != event.UserID {
+			viewerSet[f.viewerID.UUID] = true
+		}
+		if f.threadID.Valid {
+			threadSet[f.threadID.UUID] = true
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	audience := make(map[uuid.UUID]database.ListGobitEventAudienceRow, len(viewerSet))
+	if len(viewerSet) > 0 {
+		viewerIDs := make([]uuid.UUID, 0, len(viewerSet))
+		for viewerID := range viewerSet {
+			viewerIDs = append(viewerIDs, viewerID)
+		}
+		rows, err := cfg.db.ListGobitEventAudience(ctx, database.ListGobitEventAudienceParams{
+			AuthorID:  event.UserID,
+			ViewerIds: viewerIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			audience[row.ViewerID] = row
+		}
+	}
+
+	threads := make(map[uuid.UUID]bool, len(threadSet))
+	for threadID := range threadSet {
+		inThread, err := cfg.inThread(ctx, threadID, event.GobitID)
+		if err != nil {
+			return nil, err
+		}
+		threads[threadID] = inThread
+	}
+
+	// A created gobit is shown as it is now: it may have been removed, or
+	// its author shadowbanned, since the event
+	visibility := event.Visibility
+	var gobit database.Gobit
+	shadowbanned := false
+	if event.Type == eventGobitCreated {
+		var err error
+		gobit, err = cfg.db.GetGobit(ctx, event.GobitID)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if gobit.Status != gobitPublished || gobit.HiddenAt.Valid || gobit.DeletedAt.Valid {
+			return nil, nil
+		}
+		visibility = gobit.Visibility
+
+		author, err := cfg.db.GetUserByID(ctx, event.UserID)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		shadowbanned = author.AccountStatus == accountShadowbanned
+	}
+
+	var deleted []byte
+	if event.Type == eventGobitDeleted {
+		var err error
+		deleted, err = json.Marshal(deletedGobitEvent{ID: event.GobitID, UserID: event.UserID})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	type view struct {
+		viewerID      uuid.UUID
+		includeAuthor bool
+	}
+	created := make(map[view][]byte)
+
+	payloads := make(map[*gobitEventFilter][]byte, len(candidates))
+	for _, f := range candidates {
+		own := f.viewerID.Valid && f.viewerID.UUID == event.UserID
+		standing := audience[f.viewerID.UUID]
+
+		if f.following && !own && !standing.Following {
+			continue
+		}
+		if f.threadID.Valid && !threads[f.threadID.UUID] {
+			continue
+		}
+
+		// Unlisted gobits stay off the global stream, and muted authors
+		// only show up when asked for by name or in a thread
+		if !own && !f.authorID.Valid && !f.threadID.Valid {
+			if event.Visibility == visibilityUnlisted && !f.following {
+				continue
+			}
+			if standing.Muting {
+				continue
+			}
+		}
+
+		if !own {
+			if standing.Blocked || shadowbanned {
+				continue
+			}
+			switch visibility {
+			case visibilityPublic, visibilityUnlisted:
+			case visibilityFollowers:
+				if !standing.Following {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+
+		if event.Type == eventGobitDeleted {
+			payloads[f] = deleted
+			continue
+		}
+
+		// Created gobits get the same response as GET /api/gobits/{gobitID}
+		key := view{viewerID: f.viewerID.UUID, includeAuthor: includesAuthor(f.r)}
+		data, ok := created[key]
+		if !ok {
+			responseGobits, err := cfg.gobitResponses(f.r, []database.Gobit{gobit})
+			if err == nil && len(responseGobits) > 0 {
+				data, err = json.Marshal(responseGobits[0])
+			}
+			if err != nil {
+				log.Printf("Error preparing gobit event %d: %v", event.ID, err)
+				data = nil
+			}
+			created[key] = data
+		}
+		if data != nil {
+			payloads[f] = data
+		}
+	}
+	return payloads, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+type threadResponse struct {
+	// Ancestors runs from the top of the thread down to the gobit's parent
+	Ancestors []createdGobit `json:"ancestors"`
+	Gobit     createdGobit   `json:"gobit"`
+	// Replies holds every reply below the gobit in the order they were
+	// published; use reply_to_id to nest them
+	Replies []createdGobit `json:"replies"`
+}
+
+// getThread returns a gobit with the conversation around it. Gobits the
+// viewer can't open are left out, along with anything only reachable
+// through them.
+func (cfg *apiConfig) getThread(w http.ResponseWriter, r *http.Request) {
+	gobitID, err := uuid.Parse(r.PathValue("gobitID"))
+	if err != nil {
+		http.Error(w, "Invalid gobit ID format", http.StatusBadRequest)
+		return
+	}
+
+	// A repost has no thread of its own, so show the original's
+	gobit, err := cfg.db.GetGobit(r.Context(), gobitID)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error getting gobit %s for thread: %v", gobitID, err)
+		http.Error(w, "Failed to get thread", http.StatusInternalServerError)
+		return
+	}
+	if err == nil && gobit.RepostOfID.Valid {
+		gobitID = gobit.RepostOfID.UUID
+	}
+
+	threadIDs, err := cfg.db.ListThreadGobitIDs(r.Context(), gobitID)
+	if err != nil {
+		log.Printf("Error getting thread for gobit %s: %v", gobitID, err)
+		http.Error(w, "Failed to get thread", http.StatusInternalServerError)
+		return
+	}
+
+	gobits, err := cfg.db.GetVisibleGobitsByIDs(r.Context(), database.GetVisibleGobitsByIDsParams{
+		Ids:      threadIDs,
+		ViewerID: cfg.optionalViewer(r),
+	})
+	if err != nil {
+		log.Printf("Error getting thread gobits for %s: %v", gobitID, err)
+		http.Error(w, "Failed to get thread", http.StatusInternalServerError)
+		return
+	}
+
+	byID := make(map[uuid.UUID]database.Gobit, len(gobits))
+	for _, gobit := range gobits {
+		byID[gobit.ID] = gobit
+	}
+	focus, ok := byID[gobitID]
+	if !ok {
+		http.Error(w, "gobit not found", http.StatusNotFound)
+		return
+	}
+
+	// Walk up until the top of the thread or a gobit the viewer can't see
+	var ancestors []database.Gobit
+	for parentID := focus.ReplyToID; parentID.Valid; {
+		parent, ok := byID[parentID.UUID]
+		if !ok {
+			break
+		}
+		ancestors = append([]database.Gobit{parent}, ancestors...)
+		parentID = parent.ReplyToID
+	}
+
+	// Gobits come back oldest first, and a reply is always published after
+	// the gobit it answers, so one pass finds every reachable reply
+	reachable := map[uuid.UUID]bool{gobitID: true}
+	var replies []database.Gobit
+	for _, gobit := range gobits {
+		if gobit.ReplyToID.Valid && reachable[gobit.ReplyToID.UUID] && !reachable[gobit.ID] {
+			reachable[gobit.ID] = true
+			replies = append(replies, gobit)
+		}
+	}
+
+	all := append(append(ancestors, focus), replies...)
+	responseGobits, err := cfg.gobitResponses(r, all)
+	if err != nil {
+		log.Printf("Error building thread response for %s: %v", gobitID, err)
+		http.Error(w, "Failed to get thread", http.StatusInternalServerError)
+		return
+	}
+	thread := threadResponse{
+		Ancestors: responseGobits[:len(ancestors)],
+		Gobit:     responseGobits[len(ancestors)],
+		Replies:   responseGobits[len(ancestors)+1:],
+	}
+
+	data, err := json.Marshal(thread)
+	if err != nil {
+		log.Printf("Error marshalling thread response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// inThread reports whether gobitID is part of the thread around threadID:
+// above it, below it or the gobit itself.
+func (cfg *apiConfig) inThread(ctx context.Context, threadID, gobitID uuid.UUID) (bool, error) {
+	threadIDs, err := cfg.db.ListThreadGobitIDs(ctx, threadID)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range threadIDs {
+		if id == gobitID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// likeEscaper escapes the characters LIKE treats specially, so search terms
+// match literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// getFeed returns the caller's home feed, newest first. Pass the
+// published_at and id of the last gobit as ?before= and ?before_id= to get
+// the next page.
+func (cfg *apiConfig) getFeed(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for feed: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for feed: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	params := database.GetFeedParams{
+		ViewerID: userID,
+		Limit:    defaultFeedLimit,
+	}
+	query := r.URL.Query()
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxFeedLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		params.Limit = int32(limit)
+	}
+	if beforeStr := query.Get("before"); beforeStr != "" {
+		before, err := time.Parse(time.RFC3339Nano, beforeStr)
+		if err != nil {
+			http.Error(w, "Invalid before: expected an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		params.Before = sql.NullTime{Time: before, Valid: true}
+	}
+	if beforeIDStr := query.Get("before_id"); beforeIDStr != "" {
+		if !params.Before.Valid {
+			http.Error(w, "before_id needs before", http.StatusBadRequest)
+			return
+		}
+		beforeID, err := uuid.Parse(beforeIDStr)
+		if err != nil {
+			http.Error(w, "Invalid before_id format", http.StatusBadRequest)
+			return
+		}
+		params.BeforeID = uuid.NullUUID{UUID: beforeID, Valid: true}
+	}
+
+	gobits, err := cfg.db.GetFeed(r.Context(), params)
+	if err != nil {
+		log.Printf("cannot get feed for user %s: %v", userID, err)
+		http.Error(w, "Failed to get feed", http.StatusInternalServerError)
+		return
+	}
+
+	responseGobits, err := cfg.gobitResponses(r, gobits)
+	if err != nil {
+		log.Printf("cannot build feed response for user %s: %v", userID, err)
+		http.Error(w, "Failed to get feed", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(responseGobits)
+	if err != nil {
+		log.Printf("Error marshalling feed response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// searchGobits finds gobits whose body contains ?q=, newest first. It sees
+// what the public timeline sees.
+func (cfg *apiConfig) searchGobits(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	term := strings.TrimSpace(query.Get("q"))
+	if term == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	params := database.SearchGobitsParams{
+		Pattern:  "%" + likeEscaper.Replace(term) + "%",
+		ViewerID: cfg.optionalViewer(r),
+		Limit:    defaultSearchLimit,
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		params.Limit = int32(limit)
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		params.Offset = int32(offset)
+	}
+
+	gobits, err := cfg.db.SearchGobits(r.Context(), params)
+	if err != nil {
+		log.Printf("cannot search gobits: %v", err)
+		http.Error(w, "Failed to search gobits", http.StatusInternalServerError)
+		return
+	}
+
+	responseGobits, err := cfg.gobitResponses(r, gobits)
+	if err != nil {
+		log.Printf("cannot build search response: %v", err)
+		http.Error(w, "Failed to search gobits", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(responseGobits)
+	if err != nil {
+		log.Printf("Error marshalling search response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	defaultGobitTrashRetention = 30 * 24 * time.Hour
+	gobitPurgeInterval         = time.Hour
+
+	defaultRemovedGobitsLimit = 50
+	maxRemovedGobitsLimit     = 200
+)
+
+type trashedGobit struct {
+	createdGobit
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+func (cfg *apiConfig) toTrashedGobit(gobit database.Gobit) trashedGobit {
+	trashed := trashedGobit{
+		createdGobit: toCreatedGobit(gobit),
+		PurgeAt:      gobit.DeletedAt.Time.Add(cfg.gobitTrashRetention),
+	}
+	trashed.setRemoval(gobit)
+	return trashed
+}
+
+func (cfg *apiConfig) listTrash(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for trash listing: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for trash listing: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	gobits, err := cfg.db.ListTrashedGobits(r.Context(), userID)
+	if err != nil {
+		log.Printf("cannot list trash for user %s: %v", userID, err)
+		http.Error(w, "Failed to list trash", http.StatusInternalServerError)
+		return
+	}
+
+	responseGobits := make([]trashedGobit, len(gobits))
+	for i, gobit := range gobits {
+		responseGobits[i] = cfg.toTrashedGobit(gobit)
+	}
+
+	data, err := json.Marshal(responseGobits)
+	if err != nil {
+		log.Printf("Error marshalling trash response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) restoreGobit(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for restore: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for restore: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	gobitID, err := uuid.Parse(r.PathValue("gobitID"))
+	if err != nil {
+		http.Error(w, "Invalid gobit ID format", http.StatusBadRequest)
+		return
+	}
+
+	// Only gobits the author deleted themselves can come back
+	gobit, err := cfg.db.RestoreGobit(r.Context(), database.RestoreGobitParams{
+		ID:     gobitID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "gobit not found in trash", http.StatusNotFound)
+		} else if isUniqueViolation(err) {
+			// A repost can't come back once the gobit has been reposted again
+			http.Error(w, "You have already reposted this gobit", http.StatusConflict)
+		} else {
+			log.Printf("Error restoring gobit %s for user %s: %v", gobitID, userID, err)
+			http.Error(w, "Failed to restore gobit", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if gobit.Status == gobitPublished {
+		if err := cfg.publishGobitEvent(r.Context(), eventGobitCreated, gobit); err != nil {
+			log.Printf("Error streaming restored gobit %s: %v", gobit.ID, err)
+		}
+	}
+
+	responseGobit := toCreatedGobit(gobit)
+
+	data, err := json.Marshal(responseGobit)
+	if err != nil {
+		log.Printf("Error marshalling restore response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// listRemovedGobits shows moderators every hidden or deleted gobit that
+// hasn't been purged yet, most recently removed first.
+func (cfg *apiConfig) listRemovedGobits(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.authorizeRole(w, r, roleModerator, roleAdmin); !ok {
+		return
+	}
+
+	params := database.ListRemovedGobitsParams{
+		Limit: defaultRemovedGobitsLimit,
+	}
+	query := r.URL.Query()
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxRemovedGobitsLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		params.Limit = int32(limit)
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		params.Offset = int32(offset)
+	}
+
+	gobits, err := cfg.db.ListRemovedGobits(r.Context(), params)
+	if err != nil {
+		log.Printf("cannot list removed gobits: %v", err)
+		http.Error(w, "Failed to list removed gobits", http.StatusInternalServerError)
+		return
+	}
+
+	responseGobits := make([]createdGobit, len(gobits))
+	for i, gobit := range gobits {
+		responseGobits[i] = toCreatedGobit(gobit)
+		responseGobits[i].setRemoval(gobit)
+	}
+
+	data, err := json.Marshal(responseGobits)
+	if err != nil {
+		log.Printf("Error marshalling removed gobits response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// purgeDeletedGobits permanently removes gobits that their authors deleted
+// longer than the retention period ago. Moderator removals stay.
+func (cfg *apiConfig) purgeDeletedGobits(ctx context.Context) error {
+	cutoff := time.Now().Add(-cfg.gobitTrashRetention)
+	purged, err := cfg.db.PurgeDeletedGobits(ctx, sql.NullTime{Time: cutoff, Valid: true})
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("Purged %d deleted gobits", purged)
+	}
+	return nil
+}
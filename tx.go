@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+
+	"github.com/twomotive/gohost/internal/database"
+)
+
+// withTx runs fn against queries in a single transaction, committing if fn
+// returns nil and rolling back otherwise.
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(cfg.db.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
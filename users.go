@@ -1,27 +1,35 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"log"
 	"net/http"
+	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/twomotive/gohost/internal/auth"
 	"github.com/twomotive/gohost/internal/database"
+	"github.com/twomotive/gohost/internal/mailer"
 )
 
+const emailVerificationTTL = 24 * time.Hour
+
 type userRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
 type createdUser struct {
-	ID          uuid.UUID `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Email       string    `json:"email"`
-	IsGohostRed bool      `json:"is_gohost_red"`
+	ID            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	IsGohostRed   bool      `json:"is_gohost_red"`
 }
 
 func (cfg *apiConfig) createUsers(w http.ResponseWriter, r *http.Request) {
@@ -73,13 +81,7 @@ func (cfg *apiConfig) createUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseUser := createdUser{
-		ID:          user.ID,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-		Email:       user.Email,
-		IsGohostRed: user.IsGohostRed.Valid && user.IsGohostRed.Bool, // Handle sql.NullBool
-	}
+	responseUser := toCreatedUser(user)
 
 	data, err := json.Marshal(responseUser)
 	if err != nil {
@@ -93,6 +95,10 @@ func (cfg *apiConfig) createUsers(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// updateUser replaces the email and password together. It makes the same
+// checks as patchUser: the current password is required, a new email only
+// takes over once it is verified and the new password signs out every other
+// session.
 func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -120,7 +126,7 @@ func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req userRequest
+	var req userPatchRequest
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		log.Printf("JSON user update decode error: %v", err)
@@ -128,46 +134,222 @@ func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Email == "" {
+	if req.Email == nil || *req.Email == "" {
 		http.Error(w, "Email is required", http.StatusBadRequest)
 		return
 	}
-	if req.Password == "" {
+	if req.Password == nil || *req.Password == "" {
 		http.Error(w, "Password is required", http.StatusBadRequest)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(req.Password)
+	cfg.changeUser(w, r, userID, req)
+}
+
+// userPatchRequest leaves out any field that isn't being changed. Changing
+// the email or password also requires current_password.
+type userPatchRequest struct {
+	Email           *string `json:"email"`
+	Password        *string `json:"password"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+type userPatchResponse struct {
+	createdUser
+	// PendingEmail is a new address that replaces email once it is verified
+	PendingEmail string `json:"pending_email,omitempty"`
+	// A password change signs out every other session, so the caller gets a
+	// fresh refresh token to keep this one.
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+func toCreatedUser(user database.User) createdUser {
+	return createdUser{
+		ID:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		IsGohostRed:   user.IsGohostRed.Valid && user.IsGohostRed.Bool,
+	}
+}
+
+// sendEmailVerification supersedes any outstanding verification for the user
+// and mails a new token to email. The address becomes the user's once the
+// token comes back.
+func (cfg *apiConfig) sendEmailVerification(ctx context.Context, userID uuid.UUID, email string) error {
+	err := cfg.db.ExpireEmailVerificationsForUser(ctx, userID)
 	if err != nil {
-		log.Printf("cannot hash password during update: %v", err)
-		http.Error(w, "Internal server error processing request", http.StatusInternalServerError)
+		return err
+	}
+
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = cfg.db.CreateEmailVerification(ctx, database.CreateEmailVerificationParams{
+		Token:     token,
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Verify your Gohost email address",
+		Body:    "Confirm this address by sending the token below to POST /api/users/verify within 24 hours.\n\n" + token,
+	})
+}
+
+func (cfg *apiConfig) patchUser(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		log.Printf("Error getting bearer token for update: %v", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	params := database.UpdateUserParams{
-		ID:             userID,
-		Email:          req.Email,
-		HashedPassword: hashedPassword,
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for update: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
 	}
+	// --- Authentication End ---
 
-	user, err := cfg.db.UpdateUser(r.Context(), params)
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req userPatchRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
+		log.Printf("JSON user patch decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return
+	}
+
+	cfg.changeUser(w, r, userID, req)
+}
+
+// changeUser applies an email or password change for patchUser and
+// updateUser and writes the response.
+func (cfg *apiConfig) changeUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID, req userPatchRequest) {
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Unauthorized: Unknown user", http.StatusUnauthorized)
+		} else {
+			log.Printf("Error getting user %s for update: %v", userID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
 
-		log.Printf("cannot update user %s: %v", userID, err)
-		http.Error(w, "Internal server error updating user", http.StatusInternalServerError)
+	var violations []violation
+	changeEmail := req.Email != nil && *req.Email != user.Email
+	if changeEmail {
+		if _, err := mail.ParseAddress(*req.Email); err != nil || strings.ContainsAny(*req.Email, " <>") {
+			violations = append(violations, violation{
+				Field:   "email",
+				Code:    "invalid_format",
+				Message: "Email must be a valid address",
+			})
+		}
+	}
+	changePassword := req.Password != nil
+	if changePassword && *req.Password == "" {
+		violations = append(violations, violation{
+			Field:   "password",
+			Code:    "required",
+			Message: "Password cannot be empty",
+		})
+	}
+	if len(violations) > 0 {
+		respondWithViolations(w, violations)
 		return
 	}
 
-	// Respond with the updated user data (excluding password)
-	responseUser := createdUser{ // Re-use createdUser struct for response
-		ID:          user.ID,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-		Email:       user.Email,
-		IsGohostRed: user.IsGohostRed.Valid && user.IsGohostRed.Bool, // Handle sql.NullBool
+	// Sensitive changes need the current password, not just a valid token
+	if changeEmail || changePassword {
+		if req.CurrentPassword == "" {
+			http.Error(w, "current_password is required to change email or password", http.StatusUnauthorized)
+			return
+		}
+		if err := auth.CheckPasswordHash(user.HashedPassword, req.CurrentPassword); err != nil {
+			log.Printf("Current password mismatch for user %s", userID)
+			http.Error(w, "Current password is incorrect", http.StatusUnauthorized)
+			return
+		}
 	}
 
-	data, err := json.Marshal(responseUser)
+	response := userPatchResponse{}
+
+	// The new address only replaces the current one once it is verified
+	if changeEmail {
+		_, err = cfg.db.GetUserByEmail(r.Context(), *req.Email)
+		if err == nil {
+			http.Error(w, "Email is already in use", http.StatusConflict)
+			return
+		}
+		if err != sql.ErrNoRows {
+			log.Printf("Error checking email for user %s: %v", userID, err)
+			http.Error(w, "Internal server error updating user", http.StatusInternalServerError)
+			return
+		}
+
+		if err := cfg.sendEmailVerification(r.Context(), userID, *req.Email); err != nil {
+			log.Printf("Error sending email verification to user %s: %v", userID, err)
+			http.Error(w, "Failed to send verification email", http.StatusInternalServerError)
+			return
+		}
+		response.PendingEmail = *req.Email
+	}
+
+	if changePassword {
+		hashedPassword, err := auth.HashPassword(*req.Password)
+		if err != nil {
+			log.Printf("cannot hash password during update: %v", err)
+			http.Error(w, "Internal server error processing request", http.StatusInternalServerError)
+			return
+		}
+
+		// A new password ends every other session, and a failure part way
+		// leaves the old password in place
+		err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+			user, err = q.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+				ID:             userID,
+				HashedPassword: hashedPassword,
+			})
+			if err != nil {
+				return err
+			}
+			if err := q.RevokeUserRefreshTokens(r.Context(), userID); err != nil {
+				return err
+			}
+			response.RefreshToken, err = issueRefreshToken(r.Context(), q, userID)
+			return err
+		})
+		if err != nil {
+			log.Printf("cannot update password for user %s: %v", userID, err)
+			http.Error(w, "Internal server error updating user", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response.createdUser = toCreatedUser(user)
+
+	data, err := json.Marshal(response)
 	if err != nil {
 		log.Printf("Error marshalling update response: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -175,6 +357,60 @@ func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK) // 200 OK for successful update
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req verifyEmailRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("JSON verify email decode error: %v", err)
+		http.Error(w, "Invalid request body: expected JSON format", http.StatusBadRequest)
+		return
+	}
+
+	// The token is only used up if the address change goes through
+	var verification database.EmailVerification
+	var user database.User
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		verification, err = q.UseEmailVerification(r.Context(), req.Token)
+		if err != nil {
+			return err
+		}
+
+		// The token's address becomes the user's, replacing any other
+		user, err = q.SetVerifiedUserEmail(r.Context(), database.SetVerifiedUserEmailParams{
+			ID:    verification.UserID,
+			Email: verification.Email,
+		})
+		return err
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Invalid or expired verification token", http.StatusBadRequest)
+		} else if isUniqueViolation(err) {
+			http.Error(w, "Email is already in use", http.StatusConflict)
+		} else {
+			log.Printf("Error verifying email: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	data, err := json.Marshal(toCreatedUser(user))
+	if err != nil {
+		log.Printf("Error marshalling verify response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	w.Write(data)
 }
@@ -5,9 +5,106 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"unicode/utf8"
 )
 
-func handleValidate(w http.ResponseWriter, r *http.Request) {
+// Maximum gobit length in Unicode characters, by membership tier.
+const (
+	maxGobitLength          = 140
+	maxGobitLengthGohostRed = 500
+)
+
+// violation is a single reason a request failed validation.
+type violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Limit   int    `json:"limit,omitempty"`
+}
+
+type validationErrorResponse struct {
+	Error      string      `json:"error"`
+	Violations []violation `json:"violations"`
+}
+
+// validatedBody is a gobit body that passed validation.
+type validatedBody struct {
+	Cleaned string
+	Flagged bool
+}
+
+// validateGobitBody runs every check a gobit body must pass before it is
+// stored, whether it is being created or edited. All violations are
+// collected rather than stopping at the first one.
+func (cfg *apiConfig) validateGobitBody(body string, isGohostRed bool) (validatedBody, []violation) {
+	var violations []violation
+
+	if !utf8.ValidString(body) {
+		violations = append(violations, violation{
+			Field:   "body",
+			Code:    "invalid_encoding",
+			Message: "Body must be valid UTF-8",
+		})
+		return validatedBody{}, violations
+	}
+
+	if strings.TrimSpace(body) == "" {
+		violations = append(violations, violation{
+			Field:   "body",
+			Code:    "required",
+			Message: "Body cannot be empty",
+		})
+	}
+
+	limit := maxGobitLength
+	if isGohostRed {
+		limit = maxGobitLengthGohostRed
+	}
+	if utf8.RuneCountInString(body) > limit {
+		violations = append(violations, violation{
+			Field:   "body",
+			Code:    "too_long",
+			Message: "Length is too long",
+			Limit:   limit,
+		})
+	}
+
+	result := cfg.moderation.Check(body)
+	if result.Rejected {
+		violations = append(violations, violation{
+			Field:   "body",
+			Code:    "prohibited_content",
+			Message: "Body contains prohibited content",
+		})
+	}
+
+	if len(violations) > 0 {
+		return validatedBody{}, violations
+	}
+
+	return validatedBody{Cleaned: result.Cleaned, Flagged: result.Flagged}, nil
+}
+
+// respondWithViolations writes a 400 listing every validation violation.
+func respondWithViolations(w http.ResponseWriter, violations []violation) {
+	response := validationErrorResponse{
+		Error:      violations[0].Message,
+		Violations: violations,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("Error marshalling validation errors: %v", err)
+		http.Error(w, "Validation failed", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(data)
+}
+
+func (cfg *apiConfig) handleValidate(w http.ResponseWriter, r *http.Request) {
 
 	type valid struct {
 		Body string `json:"body"`
@@ -27,78 +124,36 @@ func handleValidate(w http.ResponseWriter, r *http.Request) {
 	type returnVal struct {
 		Valid        bool   `json:"valid"`
 		Cleaned_body string `json:"cleaned_body"`
+		Flagged      bool   `json:"flagged,omitempty"`
 	}
 
-	type returnErr struct {
-		Error string `json:"error"`
-	}
-
-	if len(isValid.Body) <= 140 {
-
-		text, err := removeBadWords(isValid.Body)
-		if err != nil {
-			log.Printf("Error removing words from JSON: %s", err)
-			w.WriteHeader(500)
-			return
-		}
-
-		returnVal := returnVal{
-			Valid:        true,
-			Cleaned_body: text,
-		}
-
-		data, err := json.Marshal(returnVal)
-		if err != nil {
-			log.Printf("Error marshalling JSON: %s", err)
-			w.WriteHeader(500)
-			return
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(200)
-		w.Write(data)
-	} else {
-
-		returnErr := returnErr{
-			Error: "Length is too long",
-		}
-
-		data, err := json.Marshal(returnErr)
-		if err != nil {
-			log.Printf("Error marshalling JSON: %s", err)
-			w.WriteHeader(500)
-			return
+	// Authentication is optional here; a valid token applies the caller's tier limit
+	isGohostRed := false
+	if viewerID := cfg.optionalViewer(r); viewerID.Valid {
+		if user, err := cfg.db.GetUserByID(r.Context(), viewerID.UUID); err == nil {
+			isGohostRed = user.IsGohostRed.Valid && user.IsGohostRed.Bool
 		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(400)
-		w.Write(data)
 	}
 
-}
-
-func removeBadWords(s string) (string, error) {
-	badWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
+	validated, violations := cfg.validateGobitBody(isValid.Body, isGohostRed)
+	if len(violations) > 0 {
+		respondWithViolations(w, violations)
+		return
 	}
 
-	words := strings.Fields(s)
-
-	var result strings.Builder
-
-	for i, word := range words {
-		if i > 0 {
-			result.WriteString(" ")
-		}
-
-		wordLower := strings.ToLower(word)
-		if _, exists := badWords[wordLower]; exists {
-			result.WriteString("****")
-		} else {
-			result.WriteString(word)
-		}
+	response := returnVal{
+		Valid:        true,
+		Cleaned_body: validated.Cleaned,
+		Flagged:      validated.Flagged,
 	}
 
-	return result.String(), nil
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("Error marshalling JSON: %s", err)
+		w.WriteHeader(500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	w.Write(data)
 }
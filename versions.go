@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The gobits and users API comes in two versions. Version 1 is the original:
+// bare JSON bodies and plain-text errors. Version 2 wraps every response in
+// an envelope, pages listings with cursors and always embeds authors.
+const (
+	apiV1 = 1
+	apiV2 = 2
+
+	mediaTypeV1 = "application/vnd.gohost.v1+json"
+	mediaTypeV2 = "application/vnd.gohost.v2+json"
+)
+
+// apiV1DeprecatedAt is when version 2 shipped and version 1 was deprecated.
+var apiV1DeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// defaultAPIV1Sunset is how long version 1 clients have to move to version 2,
+// unless API_V1_SUNSET says otherwise.
+var defaultAPIV1Sunset = apiV1DeprecatedAt.AddDate(0, 6, 0)
+
+// v2Response is the envelope around every version 2 response: data on
+// success, error on failure.
+type v2Response struct {
+	Data       json.RawMessage `json:"data,omitempty"`
+	Pagination *v2Pagination   `json:"pagination,omitempty"`
+	Error      *v2Error        `json:"error,omitempty"`
+}
+
+type v2Pagination struct {
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	Limit      int    `json:"limit"`
+}
+
+type v2Error struct {
+	Status     int         `json:"status"`
+	Message    string      `json:"message"`
+	Violations []violation `json:"violations,omitempty"`
+}
+
+// versionedMux registers each route three times: under /api/v1, under
+// /api/v2, and at its unversioned path, which serves version 1 unless the
+// Accept header asks for version 2. A path version wins over the header.
+type versionedMux struct {
+	mux routeMux
+	cfg *apiConfig
+}
+
+// HandleFunc registers h for both versions. Version 2 puts its responses in
+// the envelope.
+func (v versionedMux) HandleFunc(pattern string, h func(http.ResponseWriter, *http.Request)) {
+	v.HandleVersions(pattern, h, h)
+}
+
+// HandleVersions registers a separate handler for each version, for routes
+// whose version 2 response differs by more than the envelope. v2 may write
+// the envelope itself with respondWithV2.
+func (v versionedMux) HandleVersions(pattern string, v1, v2 func(http.ResponseWriter, *http.Request)) {
+	method, path, _ := strings.Cut(pattern, " ")
+	rest := strings.TrimPrefix(path, "/api")
+
+	deprecated := v.cfg.deprecateV1(http.HandlerFunc(v1))
+	enveloped := v2Envelope(http.HandlerFunc(v2))
+	v.mux.Handle(method+" /api/v1"+rest, deprecated)
+	v.mux.Handle(method+" /api/v2"+rest, enveloped)
+	v.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		if acceptedVersion(r) == apiV2 {
+			enveloped(w, r)
+			return
+		}
+		deprecated.ServeHTTP(w, r)
+	})
+}
+
+// acceptedVersion reads the version asked for in the Accept header, either
+// as application/vnd.gohost.v2+json or as application/json; version=2. It
+// defaults to version 1 so existing clients are unaffected.
+func acceptedVersion(r *http.Request) int {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case mediaTypeV1:
+			return apiV1
+		case mediaTypeV2:
+			return apiV2
+		case "application/json":
+			if version, err := strconv.Atoi(params["version"]); err == nil && (version == apiV1 || version == apiV2) {
+				return version
+			}
+		}
+	}
+	return apiV1
+}
+
+// deprecateV1 marks version 1 responses as deprecated (RFC 9745), says when
+// they will stop working (RFC 8594) and links to the version 2 route.
+func (cfg *apiConfig) deprecateV1(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		successor := "/api/v2" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api"), "/v1")
+		w.Header().Set("Deprecation", "@"+strconv.FormatInt(apiV1DeprecatedAt.Unix(), 10))
+		w.Header().Set("Sunset", cfg.apiV1Sunset.UTC().Format(http.TimeFormat))
+		w.Header().Add("Link", "<"+successor+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withAuthors returns r asking for author summaries, which version 2 always
+// embeds.
+func withAuthors(r *http.Request) *http.Request {
+	if includesAuthor(r) {
+		return r
+	}
+	r = r.Clone(r.Context())
+	query := r.URL.Query()
+	query.Add("include", "author")
+	r.URL.RawQuery = query.Encode()
+	return r
+}
+
+// v2Envelope serves a handler as version 2. Plain JSON bodies become the
+// envelope's data and errors its error. Anything else, such as responses
+// already in the envelope, event streams, syndication feeds and downloads,
+// passes through untouched.
+func v2Envelope(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ew := &envelopeWriter{w: w}
+		next.ServeHTTP(ew, withAuthors(r))
+		if !ew.buffered {
+			return
+		}
+
+		if ew.status >= http.StatusBadRequest {
+			respondWithV2Error(w, ew.status, ew.body.Bytes())
+			return
+		}
+		respondWithV2(w, ew.status, v2Response{Data: ew.body.Bytes()})
+	}
+}
+
+// envelopeWriter holds back a JSON or error response so it can be put in
+// an envelope, and passes any other response straight through.
+type envelopeWriter struct {
+	w        http.ResponseWriter
+	status   int
+	wrote    bool
+	buffered bool
+	body     bytes.Buffer
+}
+
+func (ew *envelopeWriter) Header() http.Header {
+	return ew.w.Header()
+}
+
+func (ew *envelopeWriter) WriteHeader(status int) {
+	if ew.wrote {
+		return
+	}
+	ew.wrote = true
+	ew.status = status
+
+	header := ew.w.Header()
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	switch {
+	case status == http.StatusNoContent || status == http.StatusNotModified:
+	case header.Get("Content-Disposition") != "":
+	case mediaType == "application/json" || (mediaType == "text/plain" && status >= http.StatusBadRequest):
+		ew.buffered = true
+		return
+	}
+	ew.w.WriteHeader(status)
+}
+
+func (ew *envelopeWriter) Write(p []byte) (int, error) {
+	if !ew.wrote {
+		ew.WriteHeader(http.StatusOK)
+	}
+	if ew.buffered {
+		return ew.body.Write(p)
+	}
+	return ew.w.Write(p)
+}
+
+// Unwrap lets http.ResponseController flush event streams.
+func (ew *envelopeWriter) Unwrap() http.ResponseWriter {
+	return ew.w
+}
+
+// respondWithV2 writes a version 2 envelope.
+func respondWithV2(w http.ResponseWriter, status int, response v2Response) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("Error marshalling v2 response: %v", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", mediaTypeV2)
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// respondWithV2Error writes a version 2 error from a version 1 error body:
+// either plain text or a validation failure.
+func respondWithV2Error(w http.ResponseWriter, status int, body []byte) {
+	apiErr := &v2Error{Status: status}
+	var failed validationErrorResponse
+	if err := json.Unmarshal(body, &failed); err == nil && failed.Error != "" {
+		apiErr.Message = failed.Error
+		apiErr.Violations = failed.Violations
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	respondWithV2(w, status, v2Response{Error: apiErr})
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// versionedTestServer serves a few routes through versionedMux:
+//
+//   - GET /api/things answers with a JSON body saying whether authors were
+//     asked for
+//   - GET /api/missing fails with a plain-text 404
+//   - POST /api/things fails validation
+//   - GET /api/split has a different handler per version
+//   - GET /api/events streams text/event-stream
+func versionedTestServer(t *testing.T, sunset time.Time) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	api := versionedMux{mux: mux, cfg: &apiConfig{apiV1Sunset: sunset}}
+
+	api.HandleFunc("GET /api/things", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"authors": %t}`, includesAuthor(r))
+	})
+	api.HandleFunc("GET /api/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "thing not found", http.StatusNotFound)
+	})
+	api.HandleFunc("POST /api/things", func(w http.ResponseWriter, r *http.Request) {
+		respondWithViolations(w, []violation{{Field: "name", Code: "required", Message: "Name cannot be empty"}})
+	})
+	api.HandleVersions("GET /api/split", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`"one"`))
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`"two"`))
+	})
+	api.HandleFunc("GET /api/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("event: ping\ndata: {}\n\n"))
+	})
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// fetch sends a request with the given Accept header and reads the response.
+func fetch(t *testing.T, method, url, accept string) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, body
+}
+
+func TestVersionedRoutes(t *testing.T) {
+	sunset := time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)
+	ts := versionedTestServer(t, sunset)
+
+	tests := []struct {
+		name    string
+		path    string
+		accept  string
+		version int
+		// successor is the Link a version 1 response points to
+		successor string
+	}{
+		{name: "v1 path", path: "/api/v1/things", version: apiV1, successor: "/api/v2/things"},
+		{name: "v2 path", path: "/api/v2/things", version: apiV2},
+		{name: "unversioned", path: "/api/things", version: apiV1, successor: "/api/v2/things"},
+		{name: "unversioned, v2 media type", path: "/api/things", accept: mediaTypeV2, version: apiV2},
+		{name: "unversioned, version parameter", path: "/api/things", accept: "application/json; version=2", version: apiV2},
+		{name: "unversioned, v1 media type", path: "/api/things", accept: mediaTypeV1, version: apiV1, successor: "/api/v2/things"},
+		{name: "unversioned, first match wins", path: "/api/things", accept: mediaTypeV1 + ", " + mediaTypeV2, version: apiV1, successor: "/api/v2/things"},
+		{name: "unversioned, unknown version", path: "/api/things", accept: "application/json; version=3", version: apiV1, successor: "/api/v2/things"},
+		{name: "unversioned, plain json", path: "/api/things", accept: "application/json", version: apiV1, successor: "/api/v2/things"},
+		{name: "path beats header", path: "/api/v1/things", accept: mediaTypeV2, version: apiV1, successor: "/api/v2/things"},
+		{name: "path beats header for v2", path: "/api/v2/things", accept: mediaTypeV1, version: apiV2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := fetch(t, http.MethodGet, ts.URL+tt.path, tt.accept)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+
+			switch tt.version {
+			case apiV1:
+				if got := resp.Header.Get("Content-Type"); got != "application/json" {
+					t.Errorf("Content-Type = %q, want application/json", got)
+				}
+				if string(body) != `{"authors": false}` {
+					t.Errorf("body = %s, want the bare response", body)
+				}
+				if got, want := resp.Header.Get("Deprecation"), "@"+strconv.FormatInt(apiV1DeprecatedAt.Unix(), 10); got != want {
+					t.Errorf("Deprecation = %q, want %q", got, want)
+				}
+				if got, want := resp.Header.Get("Sunset"), "Mon, 19 Apr 2027 00:00:00 GMT"; got != want {
+					t.Errorf("Sunset = %q, want %q", got, want)
+				}
+				if got, want := resp.Header.Get("Link"), "<"+tt.successor+`>; rel="successor-version"`; got != want {
+					t.Errorf("Link = %q, want %q", got, want)
+				}
+			case apiV2:
+				if got := resp.Header.Get("Content-Type"); got != mediaTypeV2 {
+					t.Errorf("Content-Type = %q, want %q", got, mediaTypeV2)
+				}
+				var envelope v2Response
+				if err := json.Unmarshal(body, &envelope); err != nil {
+					t.Fatalf("body %s is not an envelope: %v", body, err)
+				}
+				// Version 2 always embeds authors
+				if string(envelope.Data) != `{"authors":true}` {
+					t.Errorf("data = %s, want the response with authors", envelope.Data)
+				}
+				for _, header := range []string{"Deprecation", "Sunset", "Link"} {
+					if got := resp.Header.Get(header); got != "" {
+						t.Errorf("%s = %q on a version 2 response", header, got)
+					}
+				}
+			}
+
+			varies := resp.Header.Get("Vary") == "Accept"
+			if unversioned := tt.path == "/api/things"; varies != unversioned {
+				t.Errorf("Vary: Accept = %v, want %v", varies, unversioned)
+			}
+		})
+	}
+}
+
+func TestVersionedRouteHandlers(t *testing.T) {
+	ts := versionedTestServer(t, defaultAPIV1Sunset)
+
+	tests := []struct {
+		path   string
+		accept string
+		want   string
+	}{
+		{path: "/api/v1/split", want: `"one"`},
+		{path: "/api/v2/split", want: `{"data":"two"}`},
+		{path: "/api/split", want: `"one"`},
+		{path: "/api/split", accept: mediaTypeV2, want: `{"data":"two"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.accept, func(t *testing.T) {
+			_, body := fetch(t, http.MethodGet, ts.URL+tt.path, tt.accept)
+			if string(body) != tt.want {
+				t.Errorf("body = %s, want %s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestV2Errors(t *testing.T) {
+	ts := versionedTestServer(t, defaultAPIV1Sunset)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		want   v2Error
+		v1Body string
+	}{
+		{
+			name:   "plain text",
+			method: http.MethodGet,
+			path:   "/missing",
+			status: http.StatusNotFound,
+			want:   v2Error{Status: http.StatusNotFound, Message: "thing not found"},
+			v1Body: "thing not found\n",
+		},
+		{
+			name:   "validation",
+			method: http.MethodPost,
+			path:   "/things",
+			status: http.StatusBadRequest,
+			want: v2Error{
+				Status:     http.StatusBadRequest,
+				Message:    "Name cannot be empty",
+				Violations: []violation{{Field: "name", Code: "required", Message: "Name cannot be empty"}},
+			},
+			v1Body: `{"error":"Name cannot be empty","violations":[{"field":"name","code":"required","message":"Name cannot be empty"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := fetch(t, tt.method, ts.URL+"/api/v2"+tt.path, "")
+			if resp.StatusCode != tt.status {
+				t.Errorf("v2 status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			var envelope v2Response
+			if err := json.Unmarshal(body, &envelope); err != nil || envelope.Error == nil {
+				t.Fatalf("v2 body %s is not an error envelope: %v", body, err)
+			}
+			got, _ := json.Marshal(envelope.Error)
+			want, _ := json.Marshal(tt.want)
+			if string(got) != string(want) {
+				t.Errorf("error = %s, want %s", got, want)
+			}
+
+			resp, body = fetch(t, tt.method, ts.URL+"/api/v1"+tt.path, "")
+			if resp.StatusCode != tt.status {
+				t.Errorf("v1 status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if string(body) != tt.v1Body {
+				t.Errorf("v1 body = %q, want %q", body, tt.v1Body)
+			}
+		})
+	}
+}
+
+func TestV2PassesStreamsThrough(t *testing.T) {
+	ts := versionedTestServer(t, defaultAPIV1Sunset)
+
+	resp, body := fetch(t, http.MethodGet, ts.URL+"/api/v2/events", "")
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+	if string(body) != "event: ping\ndata: {}\n\n" {
+		t.Errorf("body = %q, want the stream as written", body)
+	}
+}
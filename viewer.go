@@ -0,0 +1,25 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/auth"
+)
+
+// optionalViewer returns the caller's user ID when the request carries a
+// valid bearer token, for endpoints that work anonymously but show more to
+// signed-in users. Missing or invalid tokens are treated as anonymous.
+func (cfg *apiConfig) optionalViewer(r *http.Request) uuid.NullUUID {
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+
+	userID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+
+	return uuid.NullUUID{UUID: userID, Valid: true}
+}
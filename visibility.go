@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+// Who can read a gobit. Public gobits appear everywhere; unlisted ones can be
+// opened by anyone but stay off the timeline and search; followers-only
+// gobits are shown to the author's followers; private ones only to the
+// author.
+const (
+	visibilityPublic    = "public"
+	visibilityFollowers = "followers"
+	visibilityUnlisted  = "unlisted"
+	visibilityPrivate   = "private"
+)
+
+// gobitVisibility checks the visibility a gobit is being created with,
+// defaulting to public.
+func gobitVisibility(visibility string) (string, []violation) {
+	switch visibility {
+	case "":
+		return visibilityPublic, nil
+	case visibilityPublic, visibilityFollowers, visibilityUnlisted, visibilityPrivate:
+		return visibility, nil
+	default:
+		return "", []violation{{
+			Field:   "visibility",
+			Code:    "invalid",
+			Message: "visibility must be one of public, followers, unlisted or private",
+		}}
+	}
+}
+
+// gobitVisibleTo reports whether the gobit's visibility lets the viewer open
+// it. Status, removal, shadowbans and blocks are checked separately.
+func (cfg *apiConfig) gobitVisibleTo(ctx context.Context, gobit database.Gobit, viewerID uuid.NullUUID) (bool, error) {
+	if viewerID.Valid && viewerID.UUID == gobit.UserID {
+		return true, nil
+	}
+
+	switch gobit.Visibility {
+	case visibilityPublic, visibilityUnlisted:
+		return true, nil
+	case visibilityFollowers:
+		if !viewerID.Valid {
+			return false, nil
+		}
+		return cfg.db.IsFollowing(ctx, database.IsFollowingParams{
+			FollowerID: viewerID.UUID,
+			FolloweeID: gobit.UserID,
+		})
+	default:
+		return false, nil
+	}
+}
+
+// visibleOriginal loads a gobit the viewer can open, following a repost to
+// the gobit it shares. ok is false if either is out of the viewer's sight.
+func (cfg *apiConfig) visibleOriginal(ctx context.Context, gobitID uuid.UUID, viewerID uuid.NullUUID) (gobit database.Gobit, ok bool, err error) {
+	gobits, err := cfg.db.GetVisibleGobitsByIDs(ctx, database.GetVisibleGobitsByIDsParams{
+		Ids:      []uuid.UUID{gobitID},
+		ViewerID: viewerID,
+	})
+	if err != nil || len(gobits) == 0 {
+		return database.Gobit{}, false, err
+	}
+	if !gobits[0].RepostOfID.Valid {
+		return gobits[0], true, nil
+	}
+	return cfg.visibleOriginal(ctx, gobits[0].RepostOfID.UUID, viewerID)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/twomotive/gohost/internal/auth" // Import auth package
+	"github.com/twomotive/gohost/internal/database"
 )
 
 type stripWebhookRequest struct {
@@ -79,6 +80,15 @@ func (cfg *apiConfig) handleStripWebhook(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Kept for the user's data export
+	_, err = cfg.db.CreateSubscriptionEvent(r.Context(), database.CreateSubscriptionEventParams{
+		UserID: userID,
+		Event:  req.Event,
+	})
+	if err != nil {
+		log.Printf("Error recording subscription event for user %s: %v", userID, err)
+	}
+
 	log.Printf("User %s successfully upgraded to Gohost Red via webhook.", userID)
 	w.WriteHeader(http.StatusNoContent) // 204 - Success
 }
@@ -0,0 +1,513 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/twomotive/gohost/internal/auth"
+	"github.com/twomotive/gohost/internal/database"
+)
+
+const (
+	topicTimeline      = "timeline"
+	topicNotifications = "notifications"
+	topicMessages      = "messages"
+	// Thread topics are "thread:" followed by the ID of a gobit in the thread
+	topicThreadPrefix = "thread:"
+
+	wsWriteTimeout = 10 * time.Second
+	// A connection that sends nothing, not even a pong, for wsPongWait is
+	// dropped. Pings go out often enough for a healthy client to answer.
+	wsPongWait     = 60 * time.Second
+	wsPingInterval = wsPongWait * 9 / 10
+	// How long to wait for the client to answer a close frame
+	wsCloseGrace = 2 * time.Second
+	// Revoked sessions and locked accounts are noticed within this long
+	wsSessionCheckInterval = 30 * time.Second
+	wsSendBuffer           = 64
+	wsMaxMessageBytes      = 4096
+	wsMaxTopics            = 20
+
+	// Close codes from the range left to applications
+	wsCloseTokenExpired   = 4001
+	wsCloseSessionRevoked = 4003
+)
+
+// Connections are authenticated by bearer token rather than cookies, so
+// pages on other origins can't borrow a user's session and any origin may
+// connect.
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// wsCommand is a message from the client.
+type wsCommand struct {
+	Action string `json:"action"`
+	Topic  string `json:"topic"`
+}
+
+// wsMessage is a message to the client. Type is subscribed, unsubscribed,
+// event, resync, pong or error.
+type wsMessage struct {
+	Type  string          `json:"type"`
+	Topic string          `json:"topic,omitempty"`
+	Event string          `json:"event,omitempty"`
+	ID    int64           `json:"id,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// wsClient is one WebSocket connection and the topics it follows.
+type wsClient struct {
+	cfg    *apiConfig
+	conn   *websocket.Conn
+	r      *http.Request
+	claims auth.Claims
+
+	ctx  context.Context
+	send chan wsMessage
+	// topics is only touched by the goroutine reading commands
+	topics    map[string]context.CancelFunc
+	watchers  sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// serveWebSocket upgrades to a WebSocket carrying live timeline, thread and
+// notification events. It takes the same access tokens as the rest of the
+// API, as a bearer token or, for browsers, ?access_token=. The connection
+// is closed when the token expires or its session is revoked.
+func (cfg *apiConfig) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	// --- Authentication Start ---
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		tokenString = r.URL.Query().Get("access_token")
+		if tokenString == "" {
+			log.Printf("Error getting bearer token for websocket: %v", err)
+			http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+		// Responses built for this connection look for the viewer here
+		r.Header.Set("Authorization", "Bearer "+tokenString)
+	}
+
+	claims, err := auth.ParseJWT(tokenString, cfg.jwtSecret)
+	if err != nil {
+		log.Printf("Error validating JWT for websocket: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+	// --- Authentication End ---
+
+	reason, live, err := cfg.sessionLive(r.Context(), claims)
+	if err != nil {
+		log.Printf("Error checking session for websocket: %v", err)
+		http.Error(w, "Failed to open websocket", http.StatusInternalServerError)
+		return
+	}
+	if !live {
+		http.Error(w, "Unauthorized: "+reason, http.StatusUnauthorized)
+		return
+	}
+
+	// The upgrader answers the request itself when it fails
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Error upgrading websocket for %s: %v", claims.UserID, err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	client := &wsClient{
+		cfg:    cfg,
+		conn:   conn,
+		r:      r,
+		claims: claims,
+		ctx:    ctx,
+		send:   make(chan wsMessage, wsSendBuffer),
+		topics: make(map[string]context.CancelFunc),
+	}
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		client.writeLoop()
+	}()
+
+	client.readLoop()
+	cancel()
+	client.watchers.Wait()
+	<-writerDone
+	conn.Close()
+}
+
+// sessionLive reports whether the token's session may still be used, and if
+// not, why. Tokens issued before sessions were recorded carry no session
+// and are only checked against the account.
+func (cfg *apiConfig) sessionLive(ctx context.Context, claims auth.Claims) (string, bool, error) {
+	user, err := cfg.db.GetUserByID(ctx, claims.UserID)
+	if err == sql.ErrNoRows {
+		return "Unknown user", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if message, locked := accountLockout(user); locked {
+		return message, false, nil
+	}
+
+	if claims.SessionID == "" {
+		return "", true, nil
+	}
+	session, err := cfg.db.GetSession(ctx, database.GetSessionParams{
+		UserID:    claims.UserID,
+		SessionID: claims.SessionID,
+	})
+	if err == sql.ErrNoRows {
+		return "Session revoked", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if session.RevokedAt.Valid || time.Now().After(session.ExpiresAt) {
+		return "Session revoked", false, nil
+	}
+	return "", true, nil
+}
+
+// readLoop handles the client's commands until the connection fails or is
+// closed.
+func (c *wsClient) readLoop() {
+	c.conn.SetReadLimit(wsMaxMessageBytes)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("Websocket for %s closed: %v", c.claims.UserID, err)
+			}
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var cmd wsCommand
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			c.enqueue(wsMessage{Type: "error", Error: "Invalid message: expected JSON format"})
+			continue
+		}
+		switch cmd.Action {
+		case "subscribe":
+			c.subscribe(cmd.Topic)
+		case "unsubscribe":
+			c.unsubscribe(cmd.Topic)
+		case "ping":
+			// For clients that can't send ping frames, such as browsers
+			c.enqueue(wsMessage{Type: "pong"})
+		default:
+			c.enqueue(wsMessage{Type: "error", Error: "action must be subscribe, unsubscribe or ping"})
+		}
+	}
+}
+
+// writeLoop is the only writer of data frames. It also pings the client and
+// closes the connection once the token expires or the session ends.
+func (c *wsClient) writeLoop() {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	sessionCheck := time.NewTicker(wsSessionCheckInterval)
+	defer sessionCheck.Stop()
+
+	var expired <-chan time.Time
+	if !c.claims.ExpiresAt.IsZero() {
+		expiry := time.NewTimer(time.Until(c.claims.ExpiresAt))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				c.conn.Close()
+				return
+			}
+		case <-ping.C:
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+			if err != nil {
+				c.conn.Close()
+				return
+			}
+		case <-sessionCheck.C:
+			reason, live, err := c.cfg.sessionLive(c.ctx, c.claims)
+			if err != nil {
+				log.Printf("Error checking websocket session for %s: %v", c.claims.UserID, err)
+				continue
+			}
+			if !live {
+				c.closeWith(wsCloseSessionRevoked, reason)
+				return
+			}
+		case <-expired:
+			c.closeWith(wsCloseTokenExpired, "Token expired")
+			return
+		}
+	}
+}
+
+// closeWith starts a clean close. The read loop ends once the client answers
+// or the grace period runs out.
+func (c *wsClient) closeWith(code int, reason string) {
+	c.closeOnce.Do(func() {
+		deadline := time.Now().Add(wsCloseGrace)
+		c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+		c.conn.SetReadDeadline(deadline)
+	})
+}
+
+// enqueue queues a message for the writer. A client too slow to keep up is
+// disconnected rather than holding up its topics.
+func (c *wsClient) enqueue(msg wsMessage) {
+	select {
+	case c.send <- msg:
+	case <-c.ctx.Done():
+	default:
+		c.closeWith(websocket.CloseTryAgainLater, "Too slow to keep up")
+	}
+}
+
+func (c *wsClient) subscribe(topic string) {
+	if _, ok := c.topics[topic]; ok {
+		c.enqueue(wsMessage{Type: "subscribed", Topic: topic})
+		return
+	}
+	if len(c.topics) >= wsMaxTopics {
+		c.enqueue(wsMessage{Type: "error", Topic: topic, Error: "Too many topics"})
+		return
+	}
+
+	viewerID := uuid.NullUUID{UUID: c.claims.UserID, Valid: true}
+	var watch func(ctx context.Context)
+	switch {
+	case topic == topicTimeline:
+		filter := &gobitEventFilter{cfg: c.cfg, r: c.r, viewerID: viewerID, following: true}
+		watch = func(ctx context.Context) { c.watchGobitEvents(ctx, topic, filter) }
+	case topic == topicNotifications:
+		watch = func(ctx context.Context) { c.watchNotifications(ctx, topic) }
+	case topic == topicMessages:
+		watch = func(ctx context.Context) { c.watchMessages(ctx, topic) }
+	case strings.HasPrefix(topic, topicThreadPrefix):
+		gobitID, err := uuid.Parse(strings.TrimPrefix(topic, topicThreadPrefix))
+		if err != nil {
+			c.enqueue(wsMessage{Type: "error", Topic: topic, Error: "Invalid gobit ID format"})
+			return
+		}
+		// A repost has no thread of its own, so follow the original's
+		gobit, ok, err := c.cfg.visibleOriginal(c.ctx, gobitID, viewerID)
+		if err != nil {
+			log.Printf("Error getting gobit %s for thread topic: %v", gobitID, err)
+			c.enqueue(wsMessage{Type: "error", Topic: topic, Error: "Failed to subscribe"})
+			return
+		}
+		if !ok {
+			c.enqueue(wsMessage{Type: "error", Topic: topic, Error: "gobit not found"})
+			return
+		}
+		filter := &gobitEventFilter{
+			cfg:      c.cfg,
+			r:        c.r,
+			viewerID: viewerID,
+			threadID: uuid.NullUUID{UUID: gobit.ID, Valid: true},
+		}
+		watch = func(ctx context.Context) { c.watchGobitEvents(ctx, topic, filter) }
+	default:
+		c.enqueue(wsMessage{Type: "error", Topic: topic, Error: "topic must be timeline, notifications, messages or thread:{gobitID}"})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.topics[topic] = cancel
+	c.enqueue(wsMessage{Type: "subscribed", Topic: topic})
+
+	c.watchers.Add(1)
+	go func() {
+		defer c.watchers.Done()
+		watch(ctx)
+	}()
+}
+
+func (c *wsClient) unsubscribe(topic string) {
+	cancel, ok := c.topics[topic]
+	if !ok {
+		c.enqueue(wsMessage{Type: "error", Topic: topic, Error: "Not subscribed"})
+		return
+	}
+	cancel()
+	delete(c.topics, topic)
+	c.enqueue(wsMessage{Type: "unsubscribed", Topic: topic})
+}
+
+// watchGobitEvents relays the gobit events the filter lets through until
+// the topic is unsubscribed.
+func (c *wsClient) watchGobitEvents(ctx context.Context, topic string, filter *gobitEventFilter) {
+	relay := func(event gobitEventMessage, data []byte) {
+		c.enqueue(wsMessage{Type: "event", Topic: topic, Event: event.Type, ID: event.ID, Data: data})
+	}
+
+	sub := c.cfg.gobitEvents.Subscribe(filter, streamBufferSize)
+	defer func() { sub.Close() }()
+	if err := filter.start(); err != nil {
+		log.Printf("Error starting %s topic: %v", topic, err)
+		c.enqueue(wsMessage{Type: "error", Topic: topic, Error: "Failed to subscribe"})
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case delivery, ok := <-sub.C:
+			if !ok {
+				sub = c.cfg.gobitEvents.Subscribe(filter, streamBufferSize)
+				err := filter.catchUp(func(event gobitEventMessage) error {
+					if data, ok := filter.next(event); ok {
+						relay(event, data)
+					}
+					return nil
+				})
+				if err != nil {
+					log.Printf("Error catching up gobit events after %d: %v", filter.lastID, err)
+				}
+				continue
+			}
+			if !filter.handled(delivery.Event.ID) {
+				relay(delivery.Event, delivery.Data)
+			}
+		}
+	}
+}
+
+// watchNotifications relays the user's new notifications until the topic
+// is unsubscribed.
+func (c *wsClient) watchNotifications(ctx context.Context, topic string) {
+	c.watch(ctx, notificationsChannel, func(payload []byte) {
+		var event notificationEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			log.Printf("Error decoding notification event: %v", err)
+			return
+		}
+		if event.UserID != c.claims.UserID {
+			return
+		}
+
+		notification, err := c.cfg.db.GetNotification(ctx, database.GetNotificationParams{
+			ID:     event.NotificationID,
+			UserID: c.claims.UserID,
+		})
+		if err != nil {
+			if err != sql.ErrNoRows {
+				log.Printf("Error getting notification %s: %v", event.NotificationID, err)
+			}
+			return
+		}
+		responses, err := c.cfg.notificationResponses(ctx, []database.Notification{notification})
+		if err != nil {
+			log.Printf("Error building notification %s: %v", event.NotificationID, err)
+			return
+		}
+		data, err := json.Marshal(responses[0])
+		if err != nil {
+			log.Printf("Error marshalling notification %s: %v", event.NotificationID, err)
+			return
+		}
+		c.enqueue(wsMessage{Type: "event", Topic: topic, Event: "notification", Data: data})
+	}, func() {
+		// Missed notifications are still listed by GET /api/notifications
+		c.enqueue(wsMessage{Type: "resync", Topic: topic})
+	})
+}
+
+// watchMessages relays activity in the user's conversations until the topic
+// is unsubscribed. New messages come with their content; deletions and read
+// receipts as the event itself.
+func (c *wsClient) watchMessages(ctx context.Context, topic string) {
+	c.watch(ctx, messagesChannel, func(payload []byte) {
+		var event messageEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			log.Printf("Error decoding message event: %v", err)
+			return
+		}
+
+		member, err := c.cfg.db.IsConversationMember(ctx, database.IsConversationMemberParams{
+			ConversationID: event.ConversationID,
+			UserID:         c.claims.UserID,
+		})
+		if err != nil {
+			log.Printf("Error checking membership of conversation %s: %v", event.ConversationID, err)
+			return
+		}
+		if !member {
+			return
+		}
+
+		data := json.RawMessage(payload)
+		if event.Type == eventMessageCreated && event.MessageID != nil {
+			message, err := c.cfg.db.GetMessage(ctx, database.GetMessageParams{
+				ID:             *event.MessageID,
+				ConversationID: event.ConversationID,
+			})
+			if err != nil {
+				if err != sql.ErrNoRows {
+					log.Printf("Error getting message %s: %v", *event.MessageID, err)
+				}
+				return
+			}
+			data, err = json.Marshal(toMessageResponse(message, nil))
+			if err != nil {
+				log.Printf("Error marshalling message %s: %v", message.ID, err)
+				return
+			}
+		}
+		c.enqueue(wsMessage{Type: "event", Topic: topic, Event: event.Type, Data: data})
+	}, func() {
+		// Missed messages are still listed by GET /api/conversations
+		c.enqueue(wsMessage{Type: "resync", Topic: topic})
+	})
+}
+
+// watch hands each message on a pub/sub channel to handle until ctx is
+// done. If the subscription is dropped for falling behind, it subscribes
+// again and calls missed.
+func (c *wsClient) watch(ctx context.Context, channel string, handle func([]byte), missed func()) {
+	sub := c.cfg.pubsub.Subscribe(channel, streamBufferSize)
+	defer func() { sub.Close() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case payload, ok := <-sub.C:
+			if !ok {
+				sub = c.cfg.pubsub.Subscribe(channel, streamBufferSize)
+				missed()
+				continue
+			}
+			handle(payload)
+		}
+	}
+}